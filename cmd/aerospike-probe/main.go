@@ -0,0 +1,211 @@
+// aerospike-probe is the readiness and liveness probe of the Aerospike server container.
+// The init container copies it into the config volume of the pod and the kubelet runs it with exec probes.
+//
+// The readiness probe succeeds when the node answers info status and is a member of an integral cluster.
+// The liveness probe succeeds while the node cold starts and, once it has started, as long as it answers info status.
+// The service port is not opened till the cold start is done, so the start is recorded in a file by the first successful probe.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	as "github.com/ashishshinde/aerospike-client-go"
+)
+
+const (
+	modeReadiness = "readiness"
+	modeLiveness  = "liveness"
+
+	// Credentials on security enabled clusters, set by the operator from the admin user secret.
+	adminUserEnvVar     = "AEROSPIKE_ADMIN_USER"
+	adminPasswordEnvVar = "AEROSPIKE_ADMIN_PASSWORD"
+)
+
+// tlsFiles are the files of the TLS connection, as in the tls config of the server.
+type tlsFiles struct {
+	caFile   string
+	caPath   string
+	certFile string
+	keyFile  string
+}
+
+func main() {
+	mode := flag.String("mode", modeReadiness, "Probe to run, readiness or liveness")
+	host := flag.String("host", "127.0.0.1", "Aerospike server host")
+	port := flag.Int("port", 3000, "Aerospike service port")
+	tlsName := flag.String("tls-name", "", "TLS name of the service port, the port is plain if not given")
+	var files tlsFiles
+	flag.StringVar(&files.caFile, "tls-ca-file", "", "CA certificate file of the service port")
+	flag.StringVar(&files.caPath, "tls-ca-path", "", "Directory of the CA certificate files of the service port")
+	flag.StringVar(&files.certFile, "tls-cert-file", "", "Client certificate file, required by servers authenticating the clients")
+	flag.StringVar(&files.keyFile, "tls-key-file", "", "Client certificate key file")
+	timeout := flag.Duration("timeout", 5*time.Second, "Info request timeout")
+	startedFile := flag.String("started-file", "/tmp/aerospike-started", "File recording that the node has started")
+	flag.Parse()
+
+	var tlsConfig *tls.Config
+	var err error
+	if *tlsName != "" {
+		tlsConfig, err = newTLSConfig(files)
+	}
+	if err == nil {
+		switch *mode {
+		case modeReadiness:
+			err = probeReadiness(*host, *port, *tlsName, tlsConfig, *timeout, *startedFile)
+		case modeLiveness:
+			err = probeLiveness(*host, *port, *tlsName, tlsConfig, *timeout, *startedFile)
+		default:
+			err = fmt.Errorf("Unknown probe mode %s", *mode)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// probeReadiness checks that the node answers info status and is a member of an integral cluster.
+func probeReadiness(host string, port int, tlsName string, tlsConfig *tls.Config, timeout time.Duration, startedFile string) error {
+	info, err := requestInfo(host, port, tlsName, tlsConfig, timeout, "status", "statistics")
+	if err != nil {
+		return err
+	}
+	if info["status"] != "ok" {
+		return fmt.Errorf("Node status is %s", info["status"])
+	}
+	if err := recordStarted(startedFile); err != nil {
+		return err
+	}
+
+	stats := parseStats(info["statistics"])
+	clusterSize, _ := strconv.Atoi(stats["cluster_size"])
+	if clusterSize < 1 || stats["cluster_integrity"] != "true" {
+		return fmt.Errorf("Node is not a member of an integral cluster: cluster_size %s, cluster_integrity %s", stats["cluster_size"], stats["cluster_integrity"])
+	}
+	return nil
+}
+
+// probeLiveness checks that the node answers info status. A node not answering before it has started is cold starting.
+func probeLiveness(host string, port int, tlsName string, tlsConfig *tls.Config, timeout time.Duration, startedFile string) error {
+	info, err := requestInfo(host, port, tlsName, tlsConfig, timeout, "status")
+	if err != nil {
+		if _, statErr := os.Stat(startedFile); os.IsNotExist(statErr) {
+			fmt.Println("Node is starting")
+			return nil
+		}
+		return err
+	}
+	if info["status"] == "ok" {
+		// The node answered, so it is live. The readiness probe fails till the start is recorded
+		if err := recordStarted(startedFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	return nil
+}
+
+func requestInfo(host string, port int, tlsName string, tlsConfig *tls.Config, timeout time.Duration, names ...string) (map[string]string, error) {
+	policy := as.NewClientPolicy()
+	policy.Timeout = timeout
+	policy.User = os.Getenv(adminUserEnvVar)
+	policy.Password = os.Getenv(adminPasswordEnvVar)
+
+	asHost := as.NewHost(host, port)
+	if tlsName != "" {
+		// The server certificate is verified against the TLS name
+		policy.TlsConfig = tlsConfig
+		asHost.TLSName = tlsName
+	}
+
+	conn, err := as.NewConnection(policy, asHost)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to %s: %v", asHost, err)
+	}
+	defer conn.Close()
+
+	if err := conn.Login(policy); err != nil {
+		return nil, fmt.Errorf("Failed to authenticate to %s: %v", asHost, err)
+	}
+
+	info, err := as.RequestInfo(conn, names...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get info %v from %s: %v", names, asHost, err)
+	}
+	return info, nil
+}
+
+// newTLSConfig returns the TLS config trusting the CA certificates of the files, with the client certificate if given.
+func newTLSConfig(files tlsFiles) (*tls.Config, error) {
+	caFiles := []string{}
+	if files.caFile != "" {
+		caFiles = append(caFiles, files.caFile)
+	}
+	if files.caPath != "" {
+		paths, err := filepath.Glob(filepath.Join(files.caPath, "*"))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list CA path %s: %v", files.caPath, err)
+		}
+		caFiles = append(caFiles, paths...)
+	}
+	if len(caFiles) == 0 {
+		return nil, fmt.Errorf("No CA certificate given for the TLS name")
+	}
+
+	pool := x509.NewCertPool()
+	for _, caFile := range caFiles {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read CA certificate %s: %v", caFile, err)
+		}
+		// The files of a CA path without certificates are skipped, a CA file must have one
+		if !pool.AppendCertsFromPEM(pem) && caFile == files.caFile {
+			return nil, fmt.Errorf("No CA certificate found in %s", caFile)
+		}
+	}
+	tlsConfig := &tls.Config{RootCAs: pool}
+
+	if files.certFile != "" || files.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(files.certFile, files.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load client certificate %s: %v", files.certFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// recordStarted creates the started file if it does not exist. Without it a node not answering is taken as cold starting.
+func recordStarted(startedFile string) error {
+	_, err := os.Stat(startedFile)
+	if err == nil {
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to check started file %s: %v", startedFile, err)
+	}
+	if err := ioutil.WriteFile(startedFile, []byte(time.Now().UTC().Format(time.RFC3339)), 0644); err != nil {
+		return fmt.Errorf("Failed to record start in %s: %v", startedFile, err)
+	}
+	return nil
+}
+
+// parseStats parses the key=value;key=value info response.
+func parseStats(res string) map[string]string {
+	stats := map[string]string{}
+	for _, stat := range strings.Split(strings.TrimSpace(res), ";") {
+		if kv := strings.SplitN(stat, "=", 2); len(kv) == 2 {
+			stats[kv[0]] = kv[1]
+		}
+	}
+	return stats
+}
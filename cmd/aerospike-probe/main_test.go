@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStats(t *testing.T) {
+	statsTests := []struct {
+		res  string
+		want map[string]string
+	}{
+		{"", map[string]string{}},
+		{"cluster_size=3;cluster_integrity=true\n", map[string]string{"cluster_size": "3", "cluster_integrity": "true"}},
+		{"cluster_key=A1B2;paxos_principal=BB9=1;;invalid", map[string]string{"cluster_key": "A1B2", "paxos_principal": "BB9=1"}},
+	}
+
+	for _, tt := range statsTests {
+		if got := parseStats(tt.res); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseStats(%q) = %v, want %v", tt.res, got, tt.want)
+		}
+	}
+}
+
+func TestRecordStarted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aerospike-probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	startedFile := filepath.Join(dir, "aerospike-started")
+	if err := recordStarted(startedFile); err != nil {
+		t.Fatalf("recordStarted() error = %v", err)
+	}
+	started, err := ioutil.ReadFile(startedFile)
+	if err != nil {
+		t.Fatalf("recordStarted() did not create the started file: %v", err)
+	}
+
+	// The first start is kept
+	if err := recordStarted(startedFile); err != nil {
+		t.Fatalf("recordStarted() error = %v", err)
+	}
+	if got, _ := ioutil.ReadFile(startedFile); string(got) != string(started) {
+		t.Errorf("recordStarted() = %s, want first start %s", got, started)
+	}
+
+	if err := recordStarted(filepath.Join(dir, "missing", "aerospike-started")); err == nil {
+		t.Errorf("recordStarted() in a missing directory returned no error")
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aerospike-probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A self signed certificate is the CA and the client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "aerospike-a-0.test-runner"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPath := filepath.Join(dir, "cacerts")
+	if err := os.Mkdir(caPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(caPath, "cacert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	for file, data := range map[string][]byte{
+		caFile:      pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyFile:     pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+		invalidFile: []byte("invalid"),
+	} {
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tlsTests := []struct {
+		name      string
+		files     tlsFiles
+		wantErr   bool
+		wantCerts int
+	}{
+		{"no CA", tlsFiles{}, true, 0},
+		{"CA file", tlsFiles{caFile: caFile}, false, 0},
+		{"CA path", tlsFiles{caPath: caPath}, false, 0},
+		{"client certificate", tlsFiles{caFile: caFile, certFile: caFile, keyFile: keyFile}, false, 1},
+		{"missing CA file", tlsFiles{caFile: filepath.Join(dir, "missing.pem")}, true, 0},
+		{"invalid CA file", tlsFiles{caFile: invalidFile}, true, 0},
+		{"missing key file", tlsFiles{caFile: caFile, certFile: caFile}, true, 0},
+	}
+
+	for _, tt := range tlsTests {
+		tlsConfig, err := newTLSConfig(tt.files)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newTLSConfig(%s) error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil || len(tlsConfig.RootCAs.Subjects()) != 1 {
+			t.Errorf("newTLSConfig(%s) does not verify the server against the CA", tt.name)
+		}
+		if len(tlsConfig.Certificates) != tt.wantCerts {
+			t.Errorf("newTLSConfig(%s) client certificates = %d, want %d", tt.name, len(tlsConfig.Certificates), tt.wantCerts)
+		}
+	}
+}
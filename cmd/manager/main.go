@@ -20,7 +20,6 @@ import (
 	ctrAdmission "github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/admission"
 	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
 	"github.com/aerospike/aerospike-kubernetes-operator/version"
-	"github.com/aerospike/aerospike-management-lib/asconfig"
 
 	log "github.com/inconshreveable/log15"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -31,7 +30,9 @@ import (
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	storagev1 "k8s.io/api/storage/v1"
 	k8v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8Runtime "k8s.io/apimachinery/pkg/runtime"
@@ -62,6 +63,9 @@ var (
 const (
 	logLevelEnvVar   = "LOG_LEVEL"
 	syncPeriodEnvVar = "SYNC_PERIOD_SECOND"
+	// configSchemaDirEnvVar is the dir with additional aerospike-server config schemas, e.g. a mounted ConfigMap
+	configSchemaDirEnvVar    = "CONFIG_SCHEMA_DIR"
+	configSchemaPollInterval = 30 * time.Second
 )
 
 var mgr ctrl.Manager
@@ -153,6 +157,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Schemas are loaded only once, before the manager and the webhook start
+	schemaDir := os.Getenv(configSchemaDirEnvVar)
+	logger.Info("Init aerospike-server config schemas", log.Ctx{"schemaDir": schemaDir})
+	if err := configschema.Init(schemaDir); err != nil {
+		logger.Error("Failed to init aerospike-server config schemas", log.Ctx{"err": err})
+		os.Exit(1)
+	}
+
 	scheme := k8Runtime.NewScheme()
 	SchemeBuilder := k8Runtime.NewSchemeBuilder(addKnownTypes)
 	if err := SchemeBuilder.AddToScheme(scheme); err != nil {
@@ -179,6 +191,16 @@ func main() {
 		logger.Error("Failed to add scheme", log.Ctx{"err": err})
 		os.Exit(1)
 	}
+	err = batchv1.AddToScheme(scheme)
+	if err != nil {
+		logger.Error("Failed to add scheme", log.Ctx{"err": err})
+		os.Exit(1)
+	}
+	err = policyv1beta1.AddToScheme(scheme)
+	if err != nil {
+		logger.Error("Failed to add scheme", log.Ctx{"err": err})
+		os.Exit(1)
+	}
 
 	d := getSyncPeriod()
 	logger.Info("Set sync period", log.Ctx{"period": d})
@@ -267,11 +289,18 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger.Info("Init aerospike-server config schemas")
-	asconfig.InitFromMap(configschema.SchemaMap)
+	stopCh := signals.SetupSignalHandler()
+	if schemaDir != "" {
+		go func() {
+			if configschema.WatchSchemaDir(schemaDir, configSchemaPollInterval, stopCh) {
+				logger.Info("Config schemas changed, restarting to load them", log.Ctx{"schemaDir": schemaDir})
+				os.Exit(0)
+			}
+		}()
+	}
 
 	logger.Info("Start the Cmd")
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stopCh); err != nil {
 		logger.Error("Manager exited non-zero", log.Ctx{"err": err})
 		os.Exit(1)
 	}
@@ -0,0 +1,12 @@
+#!/usr/bin/env bash
+
+# Builds the init container image. The build context is the repository root, the image builds the probe from cmd/aerospike-probe.
+# The tag should match the probeInitContainerImage of the operator.
+# Usage: docker/init/build.sh [image]
+
+set -e
+
+IMAGE=${1:-aerospike/aerospike-kubernetes-init:0.0.13}
+ROOT_DIR="$(cd "$(dirname "$0")/../.." && pwd)"
+
+docker build -f "$ROOT_DIR/docker/init/Dockerfile" -t "$IMAGE" "$ROOT_DIR"
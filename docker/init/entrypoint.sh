@@ -48,5 +48,8 @@ cp /configs/on-start.sh /usr/bin/on-start.sh
 if [ -f /configs/features.conf ]; then
         cp /configs/features.conf "${CONFIG_VOLUME}"/
 fi
+
+# Install the readiness and liveness probe used by the server container.
+cp /usr/bin/aerospike-probe "${CONFIG_VOLUME}"/
 chmod +x /usr/bin/on-start.sh
 /usr/bin/peer-finder -on-start=/usr/bin/on-start.sh -service=$K8_SERVICE -ns=${NAMESPACE} -domain=cluster.local
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	lib "github.com/aerospike/aerospike-management-lib"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -23,6 +24,11 @@ type AerospikeClusterSpec struct {
 	Size int32 `json:"size"`
 	// Aerospike server image
 	Image string `json:"image"`
+	// Version of the Aerospike server in the image e.g. 5.2.0.7. If not given it is taken from the image tag.
+	// Required for images pinned by digest or with tags not starting with the server version.
+	Version string `json:"version,omitempty"`
+	// Edition of the Aerospike server, enterprise or community. If not given it is detected from the image name.
+	Edition AerospikeEdition `json:"edition,omitempty"`
 	// If set true then multiple pods can be created per Kubernetes Node.
 	// This will create a NodePort service for each Pod.
 	// NodePort, as the name implies, opens a specific port on all the Kubernetes Nodes ,
@@ -42,7 +48,11 @@ type AerospikeClusterSpec struct {
 	// AerospikeAccessControl has the Aerospike roles and users definitions. Required if aerospike cluster security is enabled.
 	AerospikeAccessControl *AerospikeAccessControlSpec `json:"aerospikeAccessControl,omitempty"`
 	// AerospikeConfig sets config in aerospike.conf file. Other configs are taken as default
-	AerospikeConfig Values `json:"aerospikeConfig"`
+	// Required if aerospikeConfFile is not given.
+	AerospikeConfig Values `json:"aerospikeConfig,omitempty"`
+	// AerospikeConfFile has the config in native aerospike.conf format. It is parsed into aerospikeConfig by the operator
+	// and takes precedence over aerospikeConfig.
+	AerospikeConfFile *AerospikeConfFileSpec `json:"aerospikeConfFile,omitempty"`
 	// Define resources requests and limits for Aerospike Server Container. Please contact aerospike for proper sizing exercise
 	// Only Memory and Cpu resources can be given
 	// Resources.Limits should be more than Resources.Requests.
@@ -55,16 +65,402 @@ type AerospikeClusterSpec struct {
 	AerospikeNetworkPolicy AerospikeNetworkPolicy `json:"aerospikeNetworkPolicy,omitempty"`
 	// Additional configuration for create Aerospike pods.
 	PodSpec AerospikePodSpec `json:"podSpec,omitempty"`
+	// UpgradeStrategy controls how the Aerospike server image is upgraded.
+	UpgradeStrategy *AerospikeUpgradeStrategy `json:"upgradeStrategy,omitempty"`
+	// ConfigDriftPolicy enables the periodic check of the config running on the nodes against the config rendered by the operator.
+	ConfigDriftPolicy *AerospikeConfigDriftPolicy `json:"configDriftPolicy,omitempty"`
+	// Paused stops applying spec changes to the cluster. The operator only computes the plan of the pending actions and updates the observed status.
+	Paused bool `json:"paused,omitempty"`
+	// MaintenanceWindows are the times disruptive actions are allowed, i.e. rolling restart, upgrade, scale down and rack deletion.
+	// Disruptive actions are deferred till a window opens, other actions are applied right away. Disruptive actions are always allowed if not given.
+	MaintenanceWindows []AerospikeMaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// Evacuation lists the pods and Kubernetes nodes to move the Aerospike nodes off, e.g. pods with bad disks or nodes being decommissioned.
+	Evacuation *AerospikeEvacuationSpec `json:"evacuation,omitempty"`
+	// LostNodeRecovery re-creates the pods stuck on lost Kubernetes nodes with new volumes, e.g. pods with local PVs on a dead node.
+	// Recovery is disabled if not given.
+	LostNodeRecovery *AerospikeLostNodeRecoverySpec `json:"lostNodeRecovery,omitempty"`
+	// SecondaryIndexes are the secondary indexes created and kept by the operator.
+	SecondaryIndexes *AerospikeSecondaryIndexSpec `json:"secondaryIndexes,omitempty"`
+	// UDFModules are the ConfigMaps having the Lua UDF modules to register. Changes to the ConfigMaps requeue the cluster and are registered right away.
+	UDFModules []AerospikeUDFModuleSource `json:"udfModules,omitempty"`
+	// PodPlacement controls the anti-affinity and the spreading of the Aerospike pods. Changes apply to the pods scheduled afterwards.
+	PodPlacement *AerospikePodPlacementSpec `json:"podPlacement,omitempty"`
+	// PodDisruptionBudget controls the PodDisruptionBudgets created for the Aerospike pods, so that node drains and evictions
+	// do not take down more nodes than the data can survive. The budgets are created with the defaults if not given.
+	PodDisruptionBudget *AerospikePodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+	// Timeouts controls how long the operator waits for the pods to get ready and for the migrations to finish.
+	Timeouts *AerospikeTimeoutsSpec `json:"timeouts,omitempty"`
+	// Services are the Services the clients connect through, in addition to the headless service of the cluster.
+	Services *AerospikeServicesSpec `json:"services,omitempty"`
 }
 
+const (
+	// PlanAnnotation requests a dry-run plan of the pending actions when set to "true". Spec changes are not applied till it is removed.
+	PlanAnnotation = "aerospike.com/plan"
+
+	// PausedAnnotation pauses the cluster like spec.paused when set to "true", e.g. during an incident without editing the spec.
+	PausedAnnotation = "aerospike.com/paused"
+)
+
+// Pod operation annotations. On the AerospikeCluster the value is a comma separated list of pod names,
+// on a pod of the cluster the value is "true". The operator removes the annotation once the operation is done.
+const (
+	// RestartAnnotation restarts the Aerospike server container of the pods, shared memory is kept for a warm restart.
+	RestartAnnotation = "aerospike.com/restart"
+
+	// ColdRestartAnnotation deletes the pods so that they are recreated and the Aerospike server does a cold restart.
+	ColdRestartAnnotation = "aerospike.com/cold-restart"
+
+	// ReinitVolumesAnnotation wipes and re-initializes the volumes of the pods using their init method.
+	ReinitVolumesAnnotation = "aerospike.com/reinit-volumes"
+
+	// ReplacePVCsAnnotation deletes the PVCs of the pods so that the pods are recreated with new volumes, e.g. to move off failing hardware.
+	ReplacePVCsAnnotation = "aerospike.com/replace-pvcs"
+)
+
+// AerospikeWeekday is a day of the week.
+// +kubebuilder:validation:Enum=Mon;Tue;Wed;Thu;Fri;Sat;Sun
+type AerospikeWeekday string
+
+// AerospikeMaintenanceWindow is a recurring time window for disruptive actions.
+type AerospikeMaintenanceWindow struct {
+	// Days of the week the window starts on. The window starts every day if not given.
+	Days []AerospikeWeekday `json:"days,omitempty"`
+	// StartTime of the window in 24 hour HH:MM format e.g. 22:30.
+	StartTime string `json:"startTime"`
+	// DurationMinutes is the length of the window.
+	DurationMinutes int32 `json:"durationMinutes"`
+	// TimeZone of the start time as an IANA time zone name e.g. Europe/Berlin. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// AerospikeEvacuationSpec lists the pods and Kubernetes nodes to evacuate.
+// StatefulSets can only remove pods from the end, so an evacuated pod is drained and re-created with new volumes instead.
+// Its data is migrated back from the other nodes. On scale down the pods at the end of the rack are removed as usual.
+type AerospikeEvacuationSpec struct {
+	// Pods to evacuate. Each pod is evacuated once, it is evacuated again if removed from the list and added back.
+	Pods []string `json:"pods,omitempty"`
+	// Nodes are the Kubernetes nodes to evacuate. Pods are not scheduled on these nodes and the pods running on them are evacuated.
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// AerospikeLostNodeRecoverySpec controls the recovery of pods stuck on lost Kubernetes nodes.
+// A node is lost if it is deleted or not ready. A pod is stuck if it is unschedulable and its PVs have node affinity to a lost node.
+type AerospikeLostNodeRecoverySpec struct {
+	// TimeoutSeconds is the time a pod is stuck before it is recovered. Defaults to 600.
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AerospikeSecondaryIndexType is the type of the indexed bin values.
+// +kubebuilder:validation:Enum=numeric;string;geo2dsphere
+type AerospikeSecondaryIndexType string
+
+const (
+	// AerospikeSecondaryIndexNumeric indexes integer values.
+	AerospikeSecondaryIndexNumeric AerospikeSecondaryIndexType = "numeric"
+
+	// AerospikeSecondaryIndexString indexes string values.
+	AerospikeSecondaryIndexString AerospikeSecondaryIndexType = "string"
+
+	// AerospikeSecondaryIndexGeo2DSphere indexes GeoJSON values.
+	AerospikeSecondaryIndexGeo2DSphere AerospikeSecondaryIndexType = "geo2dsphere"
+)
+
+// AerospikeSecondaryIndexCollectionType is the collection type of the indexed bin.
+// +kubebuilder:validation:Enum=list;mapkeys;mapvalues
+type AerospikeSecondaryIndexCollectionType string
+
+const (
+	// AerospikeSecondaryIndexCollectionList indexes the list elements.
+	AerospikeSecondaryIndexCollectionList AerospikeSecondaryIndexCollectionType = "list"
+
+	// AerospikeSecondaryIndexCollectionMapKeys indexes the map keys.
+	AerospikeSecondaryIndexCollectionMapKeys AerospikeSecondaryIndexCollectionType = "mapkeys"
+
+	// AerospikeSecondaryIndexCollectionMapValues indexes the map values.
+	AerospikeSecondaryIndexCollectionMapValues AerospikeSecondaryIndexCollectionType = "mapvalues"
+)
+
+// AerospikeSecondaryIndexDropPolicy specifies what is done with the indexes removed from the spec.
+// +kubebuilder:validation:Enum=Retain;Drop
+type AerospikeSecondaryIndexDropPolicy string
+
+const (
+	// AerospikeSecondaryIndexRetain keeps the removed indexes on the cluster.
+	AerospikeSecondaryIndexRetain AerospikeSecondaryIndexDropPolicy = "Retain"
+
+	// AerospikeSecondaryIndexDrop drops the removed indexes from the cluster.
+	AerospikeSecondaryIndexDrop AerospikeSecondaryIndexDropPolicy = "Drop"
+)
+
+// AerospikeSecondaryIndex is a secondary index on a bin.
+type AerospikeSecondaryIndex struct {
+	// Name of the index, unique in the namespace.
+	Name string `json:"name"`
+	// Namespace of the index. It should be defined in aerospikeConfig.
+	Namespace string `json:"namespace"`
+	// Set of the index. The index covers all sets of the namespace if not given.
+	Set string `json:"set,omitempty"`
+	// Bin is the indexed bin.
+	Bin string `json:"bin"`
+	// Type of the indexed bin values, numeric, string or geo2dsphere.
+	Type AerospikeSecondaryIndexType `json:"type"`
+	// CollectionType of the indexed bin, list, mapkeys or mapvalues. The bin value itself is indexed if not given.
+	CollectionType AerospikeSecondaryIndexCollectionType `json:"collectionType,omitempty"`
+}
+
+// AerospikeSecondaryIndexSpec has the secondary indexes managed by the operator.
+// Indexes created out of band are left as is. A changed index is dropped and created again.
+type AerospikeSecondaryIndexSpec struct {
+	// Indexes to create.
+	Indexes []AerospikeSecondaryIndex `json:"indexes,omitempty"`
+	// DropPolicy for the indexes removed from the spec, Retain or Drop. Defaults to Retain.
+	DropPolicy AerospikeSecondaryIndexDropPolicy `json:"dropPolicy,omitempty"`
+}
+
+// AerospikeUDFModuleSource is a ConfigMap having Lua UDF modules. Each key ending with .lua is a module named by the key.
+type AerospikeUDFModuleSource struct {
+	// ConfigMapName is the name of the ConfigMap in the cluster namespace.
+	ConfigMapName string `json:"configMapName"`
+}
+
+// AerospikeAntiAffinityMode is how strictly a pod anti-affinity rule is enforced.
+// +kubebuilder:validation:Enum=Required;Preferred;None
+type AerospikeAntiAffinityMode string
+
+const (
+	// AerospikeAntiAffinityRequired does not schedule the pods breaking the rule.
+	AerospikeAntiAffinityRequired AerospikeAntiAffinityMode = "Required"
+
+	// AerospikeAntiAffinityPreferred schedules the pods breaking the rule if there is no other node.
+	AerospikeAntiAffinityPreferred AerospikeAntiAffinityMode = "Preferred"
+
+	// AerospikeAntiAffinityNone does not add the rule.
+	AerospikeAntiAffinityNone AerospikeAntiAffinityMode = "None"
+)
+
+// AerospikePodPlacementSpec controls the anti-affinity and the spreading of the Aerospike pods.
+type AerospikePodPlacementSpec struct {
+	// HostAntiAffinity keeps the pods of a rack on different Kubernetes nodes.
+	// Defaults to Required, or None if multiPodPerHost is set.
+	HostAntiAffinity AerospikeAntiAffinityMode `json:"hostAntiAffinity,omitempty"`
+	// PreferredAntiAffinityTopologyKeys are the node labels of the topology domains, e.g. the zones of a region rack, the pods
+	// of a rack prefer not to share. The pods are spread across the domains as far as the scheduler can, there is no maximum skew.
+	PreferredAntiAffinityTopologyKeys []string `json:"preferredAntiAffinityTopologyKeys,omitempty"`
+	// ClusterAntiAffinity keeps the pods off the nodes running pods of other Aerospike clusters, e.g. clusters sharing a node pool.
+	ClusterAntiAffinity *AerospikeClusterAntiAffinity `json:"clusterAntiAffinity,omitempty"`
+}
+
+// AerospikeClusterAntiAffinity keeps the pods off the nodes running pods of other Aerospike clusters.
+type AerospikeClusterAntiAffinity struct {
+	// Mode of the anti-affinity, Required or Preferred. Defaults to Required.
+	Mode AerospikeAntiAffinityMode `json:"mode,omitempty"`
+	// ClusterNames are the Aerospike clusters to avoid. All other Aerospike clusters are avoided if not given.
+	ClusterNames []string `json:"clusterNames,omitempty"`
+	// Namespaces of the clusters to avoid. Defaults to the namespace of this cluster.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// TopologyKey is the node label of the domains shared with the other clusters. Defaults to kubernetes.io/hostname.
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// AerospikePodDisruptionBudgetScope is the set of pods covered by a PodDisruptionBudget.
+// +kubebuilder:validation:Enum=Cluster;Rack
+type AerospikePodDisruptionBudgetScope string
+
+const (
+	// AerospikePodDisruptionBudgetCluster creates one budget for all pods of the cluster.
+	AerospikePodDisruptionBudgetCluster AerospikePodDisruptionBudgetScope = "Cluster"
+
+	// AerospikePodDisruptionBudgetRack creates one budget for the pods of each rack.
+	// Each rack can lose maxUnavailable pods at once, so the data stays available only if each rack holds a full copy of the data.
+	AerospikePodDisruptionBudgetRack AerospikePodDisruptionBudgetScope = "Rack"
+)
+
+// AerospikePodDisruptionBudgetSpec controls the PodDisruptionBudgets of the Aerospike pods.
+// The budgets only limit evictions, the pods restarted or removed by the operator wait for migrations instead.
+type AerospikePodDisruptionBudgetSpec struct {
+	// Disabled removes the budgets created by the operator.
+	Disabled bool `json:"disabled,omitempty"`
+	// Scope of the budgets. Defaults to Cluster. Rack only keeps the data available if each rack holds a full copy of the data,
+	// i.e. the replication factor of all namespaces is at least the number of racks.
+	Scope AerospikePodDisruptionBudgetScope `json:"scope,omitempty"`
+	// MaxUnavailable is the number of pods of a budget that can be evicted at once.
+	// Defaults to one less than the lowest replication factor of the namespaces, at least 1.
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+}
+
+// AerospikeTimeoutsSpec controls how long the operator waits for the pods and the migrations.
+// A timeout fails the reconcile and sets the Degraded condition, which is cleared by the next successful reconcile.
+type AerospikeTimeoutsSpec struct {
+	// PodReadySeconds is how long a pod not ready yet can make no progress. A pod makes progress when the server starts answering info,
+	// and while its cluster size or remaining migrations change. A server not answering info, e.g. during a cold start, makes no progress,
+	// so this has to be longer than the cold start of the namespaces. Defaults to 180 seconds.
+	PodReadySeconds int32 `json:"podReadySeconds,omitempty"`
+	// ColdStartSeconds is the longest wait for a pod to get ready while it makes progress. Defaults to 4 hours.
+	ColdStartSeconds int32 `json:"coldStartSeconds,omitempty"`
+	// MigrationSeconds is the longest wait for the migrations to finish before a pod is stopped. Defaults to 6 hours.
+	MigrationSeconds int32 `json:"migrationSeconds,omitempty"`
+}
+
+// AerospikeServicesSpec configures the client facing Services of the cluster.
+type AerospikeServicesSpec struct {
+	// ClusterService creates a Service named <cluster name>-client balancing the seed connections of the clients across the ready pods.
+	ClusterService *AerospikeClusterServiceSpec `json:"clusterService,omitempty"`
+	// PodService creates a LoadBalancer Service for each pod, named after the pod, so that the clients outside Kubernetes reach every node.
+	// Use the loadBalancer network type to advertise the load balancer addresses to the clients.
+	PodService *AerospikePodServiceSpec `json:"podService,omitempty"`
+}
+
+// AerospikeClusterServiceSpec configures the cluster seed Service.
+type AerospikeClusterServiceSpec struct {
+	// Type of the Service, ClusterIP or LoadBalancer. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;LoadBalancer
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Annotations added to the Service, e.g. to configure the cloud load balancer. Annotations removed from the spec are removed from the Service.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LoadBalancerSourceRanges restricts the client addresses allowed through a load balancer.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+// AerospikePodServiceSpec configures the per pod LoadBalancer Services.
+type AerospikePodServiceSpec struct {
+	// Annotations added to the Services, e.g. to configure the cloud load balancers. Annotations removed from the spec are removed from the Services.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LoadBalancerSourceRanges restricts the client addresses allowed through the load balancers.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+// AerospikeConfigDriftRemediation is the action taken for the pods running with a drifted config.
+// +kubebuilder:validation:Enum=None;SetConfig;Restart
+type AerospikeConfigDriftRemediation string
+
+const (
+	// AerospikeConfigDriftRemediationNone only reports the drift in status.
+	AerospikeConfigDriftRemediationNone AerospikeConfigDriftRemediation = "None"
+
+	// AerospikeConfigDriftRemediationSetConfig re-applies the drifted dynamic params with set-config.
+	AerospikeConfigDriftRemediationSetConfig AerospikeConfigDriftRemediation = "SetConfig"
+
+	// AerospikeConfigDriftRemediationRestart restarts the drifted pods, one pod per check.
+	AerospikeConfigDriftRemediationRestart AerospikeConfigDriftRemediation = "Restart"
+)
+
+// AerospikeConfigDriftPolicy controls the config drift detection.
+type AerospikeConfigDriftPolicy struct {
+	// IntervalSeconds is the time between the drift checks. Drift detection is disabled if not set.
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+	// Remediation for the drifted pods, None, SetConfig or Restart. Defaults to None.
+	Remediation AerospikeConfigDriftRemediation `json:"remediation,omitempty"`
+}
+
+// AerospikeUpgradeStrategy controls how the Aerospike server image is upgraded.
+type AerospikeUpgradeStrategy struct {
+	// Paused pauses an in progress upgrade. Pods already upgraded keep running the new image.
+	Paused bool `json:"paused,omitempty"`
+	// Canary upgrades a canary pod or rack first and checks health gates before upgrading rest of the cluster.
+	Canary *AerospikeCanarySpec `json:"canary,omitempty"`
+	// AutoRollback rolls the upgraded pods back to the previous image if the upgrade fails.
+	AutoRollback bool `json:"autoRollback,omitempty"`
+	// HopImages override the images for the intermediate versions of a version change that cannot be done in one step e.g. a 4.9 image to upgrade from 4.8 to 5.x.
+	// By default the image repository tagged with the intermediate version is used e.g. aerospike/aerospike-server-enterprise:4.9.
+	HopImages []string `json:"hopImages,omitempty"`
+}
+
+// AerospikeCanaryScope specifies what is upgraded in the canary phase.
+// +kubebuilder:validation:Enum=pod;rack
+type AerospikeCanaryScope string
+
+const (
+	// AerospikeCanaryScopePod upgrades a single pod in the canary phase.
+	AerospikeCanaryScopePod AerospikeCanaryScope = "pod"
+
+	// AerospikeCanaryScopeRack upgrades all pods of a single rack in the canary phase.
+	AerospikeCanaryScopeRack AerospikeCanaryScope = "rack"
+)
+
+// AerospikeCanarySpec specifies the canary phase of an upgrade.
+type AerospikeCanarySpec struct {
+	// Scope of the canary, pod or rack. Defaults to pod.
+	Scope AerospikeCanaryScope `json:"scope,omitempty"`
+	// SoakSeconds is the time to wait after the canary is upgraded before checking the health gates. Defaults to 300.
+	SoakSeconds int32 `json:"soakSeconds,omitempty"`
+	// HealthCheckJob is an optional Job run after the soak period. The Job must succeed for the upgrade to continue.
+	HealthCheckJob *batchv1.JobSpec `json:"healthCheckJob,omitempty"`
+	// RequireApproval holds the upgrade after the canary passes the health gates till ApprovedImage is set to the new image.
+	RequireApproval bool `json:"requireApproval,omitempty"`
+	// ApprovedImage approves continuing the upgrade to this image after the canary phase.
+	ApprovedImage string `json:"approvedImage,omitempty"`
+}
+
+// AerospikeEdition specifies the Aerospike server edition.
+// +kubebuilder:validation:Enum=enterprise;community
+// +k8s:openapi-gen=true
+type AerospikeEdition string
+
+const (
+	// AerospikeEditionUnspecified implies detecting the edition from the image name.
+	AerospikeEditionUnspecified AerospikeEdition = ""
+
+	// AerospikeEditionEnterprise specifies the Aerospike Enterprise Edition.
+	AerospikeEditionEnterprise AerospikeEdition = "enterprise"
+
+	// AerospikeEditionCommunity specifies the Aerospike Community Edition.
+	AerospikeEditionCommunity AerospikeEdition = "community"
+)
+
 // AerospikePodSpec contain configuration for created Aeropsike cluster pods.
 type AerospikePodSpec struct {
 	// Sidecars to add to pods.
 	Sidecars []corev1.Container `json:"sidecars,omitempty"`
 
+	// TerminationGracePeriodSeconds is the time given to the pod to stop, including the preStop quiesce.
+	// Defaults to the Kubernetes default of 30 seconds.
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PreStop controls the quiesce of the Aerospike node when Kubernetes stops the pod, e.g. on node drain or eviction.
+	// The node is quiesced by default.
+	PreStop *AerospikePreStopSpec `json:"preStop,omitempty"`
+
+	// Probes controls the readiness and liveness probes of the Aerospike server container. The probes are not added by default.
+	Probes *AerospikeProbesSpec `json:"probes,omitempty"`
+
 	// TODO: Add affinity and tolerations.
 }
 
+// AerospikeProbesSpec controls the readiness and liveness probes of the Aerospike server container.
+// The probes run the aerospike-probe binary installed by the init container.
+type AerospikeProbesSpec struct {
+	// Enabled adds the probes and the init container image installing the probe binary. Changing it restarts the pods.
+	// Without the probes the pods are ready as soon as the server process starts.
+	Enabled bool `json:"enabled,omitempty"`
+	// Readiness probe settings. The pod is ready when the node answers info status and is a member of an integral cluster.
+	Readiness *AerospikeProbeSpec `json:"readiness,omitempty"`
+	// Liveness probe settings. The node is live while it cold starts and, once started, as long as it answers info status.
+	Liveness *AerospikeProbeSpec `json:"liveness,omitempty"`
+}
+
+// AerospikeProbeSpec is the timing of a probe. The Kubernetes defaults of the probe are used for the fields not given.
+type AerospikeProbeSpec struct {
+	// InitialDelaySeconds is the time after the container start before the probe is run.
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// PeriodSeconds is the interval of the probe.
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// TimeoutSeconds is the timeout of the probe.
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// FailureThreshold is the number of consecutive failures after which the probe fails.
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// AerospikePreStopSpec controls the preStop hook of the Aerospike server container.
+// The hook quiesces the node and reclusters, so that the clients move their traffic to the other nodes before the server stops.
+// Quiesce needs the enterprise edition, the hook does nothing on other servers.
+type AerospikePreStopSpec struct {
+	// Disabled removes the preStop hook.
+	Disabled bool `json:"disabled,omitempty"`
+	// DrainSeconds is the time to wait after the quiesce for the client traffic to drain. Defaults to 15.
+	DrainSeconds int32 `json:"drainSeconds,omitempty"`
+}
+
 // ValidatePodSpecChange indicates if a change to to pod spec is safe to apply.
 func (v *AerospikePodSpec) ValidatePodSpecChange(new AerospikePodSpec) error {
 	// All changes are valid for now.
@@ -77,6 +473,12 @@ type RackConfig struct {
 	Namespaces []string `json:"namespaces,omitempty"`
 	// Racks is the list of all racks
 	Racks []Rack `json:"racks"`
+	// ZoneTopologyKey is the node label matched with the rack zone. Defaults to topology.kubernetes.io/zone,
+	// nodes without it are matched with failure-domain.beta.kubernetes.io/zone.
+	ZoneTopologyKey string `json:"zoneTopologyKey,omitempty"`
+	// RegionTopologyKey is the node label matched with the rack region. Defaults to topology.kubernetes.io/region,
+	// nodes without it are matched with failure-domain.beta.kubernetes.io/region.
+	RegionTopologyKey string `json:"regionTopologyKey,omitempty"`
 }
 
 // Rack specifies single rack config
@@ -91,6 +493,12 @@ type Rack struct {
 	RackLabel string `json:"rackLabel,omitempty"`
 	// K8s Node name for setting rack affinity. Rack pods will be deployed in given k8s Node
 	NodeName string `json:"nodeName,omitempty"`
+	// NodeSelectorRequirements for setting rack affinity. Rack pods will be deployed in k8s nodes matching all the requirements
+	NodeSelectorRequirements []corev1.NodeSelectorRequirement `json:"nodeSelectorRequirements,omitempty"`
+	// Size is the number of pods in this rack. The racks without a size share the remaining cluster size by weight.
+	Size int32 `json:"size,omitempty"`
+	// Weight is the share of the remaining cluster size for this rack relative to the other racks without a size. Defaults to 1.
+	Weight int32 `json:"weight,omitempty"`
 	// AerospikeConfig overrides the common AerospikeConfig for this Rack. This is merged with global Aerospike config.
 	InputAerospikeConfig *Values `json:"aerospikeConfig,omitempty"`
 	// Effective/operative Aerospike config. The resultant is merge of rack Aerospike config and the global Aerospike config
@@ -99,6 +507,28 @@ type Rack struct {
 	InputStorage *AerospikeStorageSpec `json:"storage,omitempty"`
 	// Effective/operative storage. The resultant is user input if specified else global storage
 	Storage AerospikeStorageSpec `json:"effectiveStorage"`
+	// PodSpec overrides the pod spec for the pods in this rack, e.g. for racks on a dedicated node pool.
+	InputPodSpec *AerospikeRackPodSpec `json:"podSpec,omitempty"`
+	// Effective/operative pod spec. The resultant is the rack pod spec with the resources merged over the global resources
+	PodSpec AerospikeRackPodSpec `json:"effectivePodSpec,omitempty"`
+}
+
+// AerospikeRackPodSpec overrides the global spec for the pods of a rack.
+type AerospikeRackPodSpec struct {
+	// Image is the Aerospike server image of the rack pods. Defaults to the cluster image.
+	Image string `json:"image,omitempty"`
+	// Resources for the Aerospike server container. These are merged with the global resources.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Tolerations of the rack pods.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// NodeSelector of the rack pods.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Affinity of the rack pods. It is merged with the rack and anti-affinity rules set by the operator.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Labels to add to the rack pods. The labels set by the operator cannot be overridden.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations to add to the rack pods.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // DeepCopy implements deepcopy func for RackConfig
@@ -219,6 +649,17 @@ func (v *AerospikeAccessControlSpec) DeepCopy() *AerospikeAccessControlSpec {
 	return &dst
 }
 
+// AerospikeConfFileSpec has the aerospike.conf text, given inline or in a ConfigMap.
+type AerospikeConfFileSpec struct {
+	// Text is the content of the aerospike.conf file.
+	Text string `json:"text,omitempty"`
+	// ConfigMapName is the name of the ConfigMap in the cluster namespace having the aerospike.conf file.
+	// Changes to the ConfigMap are applied on the next update of the AerospikeCluster.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// ConfigMapKey is the key of the aerospike.conf file in the ConfigMap. Defaults to aerospike.conf.
+	ConfigMapKey string `json:"configMapKey,omitempty"`
+}
+
 // AerospikeConfigSecretSpec has secret info created by user. User need to create secret having tls files, feature key for cluster
 type AerospikeConfigSecretSpec struct {
 	SecretName string `json:"secretName"`
@@ -535,7 +976,31 @@ type AerospikeClusterStatus struct {
 	AerospikeClusterSpec
 
 	// Details about the current condition of the AerospikeCluster resource.
-	//Conditions []apiextensions.CustomResourceDefinitionCondition `json:"conditions"`
+	Conditions []AerospikeClusterCondition `json:"conditions,omitempty"`
+
+	// Upgrade has the progress of the latest Aerospike server image upgrade.
+	Upgrade *AerospikeUpgradeStatus `json:"upgrade,omitempty"`
+
+	// UpgradePlan has the steps of the latest version change that goes through intermediate versions.
+	UpgradePlan *AerospikeUpgradePlan `json:"upgradePlan,omitempty"`
+
+	// ConfigDrift has the result of the latest config drift check.
+	ConfigDrift *AerospikeConfigDriftStatus `json:"configDrift,omitempty"`
+
+	// Plan has the actions the operator would take to apply the spec. It is computed when the cluster is paused or a plan is requested by annotation.
+	Plan *AerospikeReconcilePlan `json:"plan,omitempty"`
+
+	// PodOperations are the latest pod operations requested by annotation or started by the operator to recover a pod.
+	PodOperations []AerospikePodOperation `json:"podOperations,omitempty"`
+
+	// Rosters has the roster state of the strong consistency namespaces.
+	Rosters []AerospikeNamespaceRoster `json:"rosters,omitempty"`
+
+	// SecondaryIndexStates has the build state of the secondary indexes in the spec.
+	SecondaryIndexStates []AerospikeSecondaryIndexState `json:"secondaryIndexStates,omitempty"`
+
+	// RegisteredUDFModules are the UDF modules registered by the operator.
+	RegisteredUDFModules []AerospikeUDFModuleStatus `json:"registeredUDFModules,omitempty"`
 
 	// Pods has Aerospike specific status of the pods. This is map instead of the conventional map as list convention to allow each pod to patch update its own status. The map key is the name of the pod.
 	// +patchStrategy=strategic
@@ -548,8 +1013,307 @@ type AerospikeClusterStatus struct {
 	// Error status
 }
 
+// AerospikeUpgradePhase is the phase of an Aerospike server image upgrade.
+type AerospikeUpgradePhase string
+
+const (
+	// AerospikeUpgradePhaseCanary means the canary pods are being upgraded.
+	AerospikeUpgradePhaseCanary AerospikeUpgradePhase = "Canary"
+
+	// AerospikeUpgradePhaseSoaking means the canary pods are upgraded and the soak period is running.
+	AerospikeUpgradePhaseSoaking AerospikeUpgradePhase = "Soaking"
+
+	// AerospikeUpgradePhaseHealthCheck means the health check Job is running for the canary.
+	AerospikeUpgradePhaseHealthCheck AerospikeUpgradePhase = "HealthCheck"
+
+	// AerospikeUpgradePhaseCanaryFailed means the canary failed a health gate. The upgrade is held.
+	AerospikeUpgradePhaseCanaryFailed AerospikeUpgradePhase = "CanaryFailed"
+
+	// AerospikeUpgradePhaseAwaitingApproval means the canary passed the health gates and the upgrade waits for approval.
+	AerospikeUpgradePhaseAwaitingApproval AerospikeUpgradePhase = "AwaitingApproval"
+
+	// AerospikeUpgradePhaseRollingOut means the rest of the cluster is being upgraded.
+	AerospikeUpgradePhaseRollingOut AerospikeUpgradePhase = "RollingOut"
+
+	// AerospikeUpgradePhaseRollingBack means the upgrade failed and the upgraded pods are being rolled back to the previous image.
+	AerospikeUpgradePhaseRollingBack AerospikeUpgradePhase = "RollingBack"
+
+	// AerospikeUpgradePhaseRolledBack means the upgrade failed and all pods run the previous image. The upgrade is held.
+	AerospikeUpgradePhaseRolledBack AerospikeUpgradePhase = "RolledBack"
+
+	// AerospikeUpgradePhaseCompleted means all pods run the target image.
+	AerospikeUpgradePhaseCompleted AerospikeUpgradePhase = "Completed"
+)
+
+// AerospikeUpgradeStatus is the progress of an Aerospike server image upgrade.
+type AerospikeUpgradeStatus struct {
+	// TargetImage is the image being upgraded to.
+	TargetImage string `json:"targetImage"`
+	// PreviousImage is the last known good image the cluster ran before the upgrade.
+	PreviousImage string `json:"previousImage,omitempty"`
+	// Racks has the target and previous image of the racks which override the cluster image in the spec or ran an
+	// overridden image before the upgrade. The other racks use TargetImage and PreviousImage.
+	Racks []AerospikeRackUpgradeStatus `json:"racks,omitempty"`
+	// Phase of the upgrade.
+	Phase AerospikeUpgradePhase `json:"phase"`
+	// Paused is true if the upgrade is paused by the upgrade strategy.
+	Paused bool `json:"paused,omitempty"`
+	// CanaryPods are the pods upgraded in the canary phase.
+	CanaryPods []string `json:"canaryPods,omitempty"`
+	// CanaryUpgradedTime is the time the canary pods were upgraded. The soak period starts at this time.
+	CanaryUpgradedTime *metav1.Time `json:"canaryUpgradedTime,omitempty"`
+	// Message has details about the phase e.g. the failed health gate.
+	Message string `json:"message,omitempty"`
+}
+
+// AerospikeRackUpgradeStatus is the target and previous image of a rack in an upgrade.
+type AerospikeRackUpgradeStatus struct {
+	// ID of the rack.
+	ID int `json:"id"`
+	// TargetImage is the image the rack is being upgraded to.
+	TargetImage string `json:"targetImage"`
+	// PreviousImage is the last known good image the rack ran before the upgrade.
+	PreviousImage string `json:"previousImage,omitempty"`
+}
+
+// AerospikeUpgradeStepPhase is the phase of an upgrade plan step.
+type AerospikeUpgradeStepPhase string
+
+const (
+	// AerospikeUpgradeStepPending means the step has not started.
+	AerospikeUpgradeStepPending AerospikeUpgradeStepPhase = "Pending"
+
+	// AerospikeUpgradeStepInProgress means the cluster is being upgraded to the step image.
+	AerospikeUpgradeStepInProgress AerospikeUpgradeStepPhase = "InProgress"
+
+	// AerospikeUpgradeStepCompleted means all pods run the step image.
+	AerospikeUpgradeStepCompleted AerospikeUpgradeStepPhase = "Completed"
+)
+
+// AerospikeUpgradeStep is a full rolling upgrade to one version of an upgrade plan.
+type AerospikeUpgradeStep struct {
+	// Version is the Aerospike server version of the step.
+	Version string `json:"version"`
+	// Image is the image the cluster is upgraded to in the step.
+	Image string `json:"image"`
+	// Phase of the step.
+	Phase AerospikeUpgradeStepPhase `json:"phase"`
+}
+
+// AerospikeUpgradePlan is the list of steps to change the cluster version through the intermediate versions that cannot be skipped.
+type AerospikeUpgradePlan struct {
+	// TargetImage is the image in the spec the plan was made for.
+	TargetImage string `json:"targetImage"`
+	// Steps of the plan in order. The last step is the upgrade to the target image.
+	Steps []AerospikeUpgradeStep `json:"steps,omitempty"`
+}
+
+// AerospikeConfigDriftStatus is the result of a config drift check.
+type AerospikeConfigDriftStatus struct {
+	// LastCheckTime is the time of the latest drift check.
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
+	// Pods are the pods running with a config different from the rendered config.
+	Pods []AerospikePodConfigDrift `json:"pods,omitempty"`
+}
+
+// AerospikePodConfigDrift has the config differences of a pod.
+type AerospikePodConfigDrift struct {
+	// PodName is the name of the drifted pod.
+	PodName string `json:"podName"`
+	// Diffs are the params with a running value different from the rendered value.
+	Diffs []AerospikeConfigDiff `json:"diffs"`
+}
+
+// AerospikeConfigDiff is a param with a running value different from the rendered value.
+type AerospikeConfigDiff struct {
+	// Context of the param, service or namespace.
+	Context string `json:"context"`
+	// ID is the namespace name for the namespace context.
+	ID string `json:"id,omitempty"`
+	// Name of the param.
+	Name string `json:"name"`
+	// Expected is the value rendered by the operator.
+	Expected string `json:"expected"`
+	// Actual is the value running on the node.
+	Actual string `json:"actual"`
+}
+
+// AerospikePlannedActionType is the type of a planned action.
+type AerospikePlannedActionType string
+
+const (
+	// AerospikePlannedActionCreateRack creates the StatefulSet of a new rack.
+	AerospikePlannedActionCreateRack AerospikePlannedActionType = "CreateRack"
+
+	// AerospikePlannedActionScaleDown removes pods from a rack.
+	AerospikePlannedActionScaleDown AerospikePlannedActionType = "ScaleDown"
+
+	// AerospikePlannedActionUpgrade upgrades or downgrades the pods of a rack to the spec image.
+	AerospikePlannedActionUpgrade AerospikePlannedActionType = "Upgrade"
+
+	// AerospikePlannedActionRollingRestart restarts the pods of a rack one at a time to apply a config change.
+	AerospikePlannedActionRollingRestart AerospikePlannedActionType = "RollingRestart"
+
+	// AerospikePlannedActionScaleUp adds pods to a rack.
+	AerospikePlannedActionScaleUp AerospikePlannedActionType = "ScaleUp"
+
+	// AerospikePlannedActionDeleteRack scales down and deletes a rack removed from the spec.
+	AerospikePlannedActionDeleteRack AerospikePlannedActionType = "DeleteRack"
+
+	// AerospikePlannedActionUpdateAccessControl creates, updates or drops roles and users.
+	AerospikePlannedActionUpdateAccessControl AerospikePlannedActionType = "UpdateAccessControl"
+
+	// AerospikePlannedActionEvacuate drains the evacuated pods of a rack and re-creates them with new volumes.
+	AerospikePlannedActionEvacuate AerospikePlannedActionType = "Evacuate"
+
+	// AerospikePlannedActionRelocate drains the pods of a rack placed on nodes not matching the rack placement and re-creates them with new volumes.
+	AerospikePlannedActionRelocate AerospikePlannedActionType = "Relocate"
+)
+
+// AerospikePlannedAction is an action the operator would take to apply the spec.
+type AerospikePlannedAction struct {
+	// Type of the action.
+	Type AerospikePlannedActionType `json:"type"`
+	// RackID is the rack the action applies to. Not set for cluster wide actions.
+	RackID *int `json:"rackID,omitempty"`
+	// Pods are the pods created, removed or restarted by the action.
+	Pods []string `json:"pods,omitempty"`
+	// Description has the details of the action e.g. the changed config or the roles and users to update.
+	Description string `json:"description,omitempty"`
+}
+
+// AerospikeReconcilePlan is the ordered list of actions the operator would take to apply the spec.
+type AerospikeReconcilePlan struct {
+	// Generation of the cluster object the plan was computed for.
+	Generation int64 `json:"generation"`
+	// ComputedTime is the time the plan was computed.
+	ComputedTime metav1.Time `json:"computedTime"`
+	// Actions in the order they would be applied. Empty if the cluster is in the desired state.
+	Actions []AerospikePlannedAction `json:"actions,omitempty"`
+}
+
+// AerospikePodOperationType is the type of a pod operation.
+type AerospikePodOperationType string
+
+const (
+	// AerospikePodOperationRestart restarts the Aerospike server container.
+	AerospikePodOperationRestart AerospikePodOperationType = "Restart"
+
+	// AerospikePodOperationColdRestart deletes the pod.
+	AerospikePodOperationColdRestart AerospikePodOperationType = "ColdRestart"
+
+	// AerospikePodOperationReinitVolumes wipes and re-initializes the pod volumes.
+	AerospikePodOperationReinitVolumes AerospikePodOperationType = "ReinitVolumes"
+
+	// AerospikePodOperationReplacePVCs replaces the pod PVCs with new ones.
+	AerospikePodOperationReplacePVCs AerospikePodOperationType = "ReplacePVCs"
+
+	// AerospikePodOperationRecoverLostNode replaces the PVCs of a pod stuck on a lost Kubernetes node.
+	AerospikePodOperationRecoverLostNode AerospikePodOperationType = "RecoverLostNode"
+)
+
+// AerospikePodOperationPhase is the phase of a pod operation.
+type AerospikePodOperationPhase string
+
+const (
+	// AerospikePodOperationInProgress means the operation has started.
+	AerospikePodOperationInProgress AerospikePodOperationPhase = "InProgress"
+
+	// AerospikePodOperationCompleted means the pod is running and ready after the operation.
+	AerospikePodOperationCompleted AerospikePodOperationPhase = "Completed"
+
+	// AerospikePodOperationFailed means the operation failed, see the message for details.
+	AerospikePodOperationFailed AerospikePodOperationPhase = "Failed"
+)
+
+// AerospikePodOperation is a pod operation requested by annotation or started by the operator.
+type AerospikePodOperation struct {
+	// PodName is the name of the pod.
+	PodName string `json:"podName"`
+	// Type of the operation.
+	Type AerospikePodOperationType `json:"type"`
+	// Phase of the operation.
+	Phase AerospikePodOperationPhase `json:"phase"`
+	// StartTime is the time the operation started.
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime is the time the operation completed or failed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Message has the failure details.
+	Message string `json:"message,omitempty"`
+}
+
+// AerospikeNamespaceRoster is the roster state of a strong consistency namespace.
+// Nodes are given as nodeID@rackID.
+type AerospikeNamespaceRoster struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// Roster is the nodes in the active roster.
+	Roster []string `json:"roster,omitempty"`
+	// PendingRoster is the roster set and not yet active, it becomes active on recluster.
+	PendingRoster []string `json:"pendingRoster,omitempty"`
+	// ObservedNodes are the nodes in the cluster.
+	ObservedNodes []string `json:"observedNodes,omitempty"`
+	// UnavailablePartitions is the number of partitions not available due to missing roster nodes.
+	UnavailablePartitions int64 `json:"unavailablePartitions"`
+	// DeadPartitions is the number of partitions with potentially lost data.
+	DeadPartitions int64 `json:"deadPartitions"`
+}
+
+// AerospikeSecondaryIndexState is the build state of a secondary index.
+type AerospikeSecondaryIndexState struct {
+	// Name of the index.
+	Name string `json:"name"`
+	// Namespace of the index.
+	Namespace string `json:"namespace"`
+	// State of the index on the nodes, RW once built on all nodes, WO while building.
+	State string `json:"state,omitempty"`
+	// LoadPercent is the lowest build progress of the index across the nodes.
+	LoadPercent int32 `json:"loadPercent"`
+}
+
+// AerospikeUDFModuleStatus is a registered UDF module.
+type AerospikeUDFModuleStatus struct {
+	// Name of the module.
+	Name string `json:"name"`
+	// ConfigMapName is the ConfigMap the module is registered from.
+	ConfigMapName string `json:"configMapName"`
+	// Hash is the SHA-256 hash of the registered module content.
+	Hash string `json:"hash"`
+}
+
+// AerospikeClusterConditionType is a valid value for AerospikeClusterCondition.Type
+type AerospikeClusterConditionType string
+
+const (
+	// AerospikeClusterVersionMismatch means the Aerospike server version running on some pods is not the desired version.
+	AerospikeClusterVersionMismatch AerospikeClusterConditionType = "VersionMismatch"
+
+	// AerospikeClusterPaused means the spec changes are not applied because the cluster is paused by spec or annotation.
+	AerospikeClusterPaused AerospikeClusterConditionType = "Paused"
+
+	// AerospikeClusterMaintenanceDeferred means disruptive actions are waiting for the next maintenance window.
+	AerospikeClusterMaintenanceDeferred AerospikeClusterConditionType = "MaintenanceDeferred"
+
+	// AerospikeClusterDegraded means the cluster is not in the desired state because of a failure, e.g. a rolled back upgrade.
+	AerospikeClusterDegraded AerospikeClusterConditionType = "Degraded"
+)
+
+// AerospikeClusterCondition describes the state of the AerospikeCluster at a certain point.
+type AerospikeClusterCondition struct {
+	// Type of the condition.
+	Type AerospikeClusterConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is the last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a unique, one-word, CamelCase reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable message indicating details about the last transition.
+	Message string `json:"message,omitempty"`
+}
+
 // AerospikeNetworkType specifies the type of network address to use.
-// +kubebuilder:validation:Enum=pod;hostInternal;hostExternal
+// +kubebuilder:validation:Enum=pod;hostInternal;hostExternal;loadBalancer
 // +k8s:openapi-gen=true
 type AerospikeNetworkType string
 
@@ -565,6 +1329,9 @@ const (
 
 	// AerospikeNetworkTypeHostExternal specifies access using the Kubernetes host's external IP. If the cluster runs single pod per Kunernetes host, the access port will the actual aerospike port else it will be a mapped port.
 	AerospikeNetworkTypeHostExternal AerospikeNetworkType = "hostExternal"
+
+	// AerospikeNetworkTypeLoadBalancer specifies access using the ingress address of the pod's LoadBalancer Service and the actual Aerospike service port. Needs services.podService.
+	AerospikeNetworkTypeLoadBalancer AerospikeNetworkType = "loadBalancer"
 )
 
 // AerospikeNetworkPolicy specifies how clients and tools access the Aerospike cluster.
@@ -662,6 +1429,9 @@ type AerospikePodStatus struct {
 	// Aerospike server instance summary for this pod.
 	Aerospike AerospikeInstanceSummary `json:"aerospike,omitempty"`
 
+	// AerospikeVersion is the Aerospike server version verified running on this pod.
+	AerospikeVersion string `json:"aerospikeVersion,omitempty"`
+
 	// InitializedVolumePaths is the list of device path that have already been initialized.
 	InitializedVolumePaths []string `json:"initializedVolumePaths"`
 }
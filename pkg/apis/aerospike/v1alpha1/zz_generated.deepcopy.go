@@ -5,6 +5,7 @@
 package v1alpha1
 
 import (
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -16,6 +17,27 @@ func (in *AerospikeAccessControlSpec) DeepCopyInto(out *AerospikeAccessControlSp
 	return
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeCanarySpec) DeepCopyInto(out *AerospikeCanarySpec) {
+	*out = *in
+	if in.HealthCheckJob != nil {
+		in, out := &in.HealthCheckJob, &out.HealthCheckJob
+		*out = new(batchv1.JobSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeCanarySpec.
+func (in *AerospikeCanarySpec) DeepCopy() *AerospikeCanarySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeCanarySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeClientAdminPolicy) DeepCopyInto(out *AerospikeClientAdminPolicy) {
 	clone := in.DeepCopy()
@@ -51,6 +73,49 @@ func (in *AerospikeCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeClusterAntiAffinity) DeepCopyInto(out *AerospikeClusterAntiAffinity) {
+	*out = *in
+	if in.ClusterNames != nil {
+		in, out := &in.ClusterNames, &out.ClusterNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeClusterAntiAffinity.
+func (in *AerospikeClusterAntiAffinity) DeepCopy() *AerospikeClusterAntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeClusterAntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeClusterCondition) DeepCopyInto(out *AerospikeClusterCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeClusterCondition.
+func (in *AerospikeClusterCondition) DeepCopy() *AerospikeClusterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeClusterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeClusterList) DeepCopyInto(out *AerospikeClusterList) {
 	*out = *in
@@ -84,6 +149,34 @@ func (in *AerospikeClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeClusterServiceSpec) DeepCopyInto(out *AerospikeClusterServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeClusterServiceSpec.
+func (in *AerospikeClusterServiceSpec) DeepCopy() *AerospikeClusterServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeClusterServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeClusterSpec) DeepCopyInto(out *AerospikeClusterSpec) {
 	*out = *in
@@ -94,6 +187,11 @@ func (in *AerospikeClusterSpec) DeepCopyInto(out *AerospikeClusterSpec) {
 		*out = (*in).DeepCopy()
 	}
 	in.AerospikeConfig.DeepCopyInto(&out.AerospikeConfig)
+	if in.AerospikeConfFile != nil {
+		in, out := &in.AerospikeConfFile, &out.AerospikeConfFile
+		*out = new(AerospikeConfFileSpec)
+		**out = **in
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = new(v1.ResourceRequirements)
@@ -106,6 +204,63 @@ func (in *AerospikeClusterSpec) DeepCopyInto(out *AerospikeClusterSpec) {
 	in.RackConfig.DeepCopyInto(&out.RackConfig)
 	in.AerospikeNetworkPolicy.DeepCopyInto(&out.AerospikeNetworkPolicy)
 	in.PodSpec.DeepCopyInto(&out.PodSpec)
+	if in.UpgradeStrategy != nil {
+		in, out := &in.UpgradeStrategy, &out.UpgradeStrategy
+		*out = new(AerospikeUpgradeStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigDriftPolicy != nil {
+		in, out := &in.ConfigDriftPolicy, &out.ConfigDriftPolicy
+		*out = new(AerospikeConfigDriftPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]AerospikeMaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Evacuation != nil {
+		in, out := &in.Evacuation, &out.Evacuation
+		*out = new(AerospikeEvacuationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LostNodeRecovery != nil {
+		in, out := &in.LostNodeRecovery, &out.LostNodeRecovery
+		*out = new(AerospikeLostNodeRecoverySpec)
+		**out = **in
+	}
+	if in.SecondaryIndexes != nil {
+		in, out := &in.SecondaryIndexes, &out.SecondaryIndexes
+		*out = new(AerospikeSecondaryIndexSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UDFModules != nil {
+		in, out := &in.UDFModules, &out.UDFModules
+		*out = make([]AerospikeUDFModuleSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodPlacement != nil {
+		in, out := &in.PodPlacement, &out.PodPlacement
+		*out = new(AerospikePodPlacementSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(AerospikePodDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(AerospikeTimeoutsSpec)
+		**out = **in
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = new(AerospikeServicesSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -123,6 +278,57 @@ func (in *AerospikeClusterSpec) DeepCopy() *AerospikeClusterSpec {
 func (in *AerospikeClusterStatus) DeepCopyInto(out *AerospikeClusterStatus) {
 	*out = *in
 	in.AerospikeClusterSpec.DeepCopyInto(&out.AerospikeClusterSpec)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]AerospikeClusterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(AerospikeUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradePlan != nil {
+		in, out := &in.UpgradePlan, &out.UpgradePlan
+		*out = new(AerospikeUpgradePlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigDrift != nil {
+		in, out := &in.ConfigDrift, &out.ConfigDrift
+		*out = new(AerospikeConfigDriftStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(AerospikeReconcilePlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodOperations != nil {
+		in, out := &in.PodOperations, &out.PodOperations
+		*out = make([]AerospikePodOperation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rosters != nil {
+		in, out := &in.Rosters, &out.Rosters
+		*out = make([]AerospikeNamespaceRoster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecondaryIndexStates != nil {
+		in, out := &in.SecondaryIndexStates, &out.SecondaryIndexStates
+		*out = make([]AerospikeSecondaryIndexState, len(*in))
+		copy(*out, *in)
+	}
+	if in.RegisteredUDFModules != nil {
+		in, out := &in.RegisteredUDFModules, &out.RegisteredUDFModules
+		*out = make([]AerospikeUDFModuleStatus, len(*in))
+		copy(*out, *in)
+	}
 	if in.Pods != nil {
 		in, out := &in.Pods, &out.Pods
 		*out = make(map[string]AerospikePodStatus, len(*in))
@@ -143,6 +349,78 @@ func (in *AerospikeClusterStatus) DeepCopy() *AerospikeClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeConfFileSpec) DeepCopyInto(out *AerospikeConfFileSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeConfFileSpec.
+func (in *AerospikeConfFileSpec) DeepCopy() *AerospikeConfFileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeConfFileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeConfigDiff) DeepCopyInto(out *AerospikeConfigDiff) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeConfigDiff.
+func (in *AerospikeConfigDiff) DeepCopy() *AerospikeConfigDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeConfigDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeConfigDriftPolicy) DeepCopyInto(out *AerospikeConfigDriftPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeConfigDriftPolicy.
+func (in *AerospikeConfigDriftPolicy) DeepCopy() *AerospikeConfigDriftPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeConfigDriftPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeConfigDriftStatus) DeepCopyInto(out *AerospikeConfigDriftStatus) {
+	*out = *in
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]AerospikePodConfigDrift, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeConfigDriftStatus.
+func (in *AerospikeConfigDriftStatus) DeepCopy() *AerospikeConfigDriftStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeConfigDriftStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeConfigSecretSpec) DeepCopyInto(out *AerospikeConfigSecretSpec) {
 	clone := in.DeepCopy()
@@ -150,6 +428,32 @@ func (in *AerospikeConfigSecretSpec) DeepCopyInto(out *AerospikeConfigSecretSpec
 	return
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeEvacuationSpec) DeepCopyInto(out *AerospikeEvacuationSpec) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeEvacuationSpec.
+func (in *AerospikeEvacuationSpec) DeepCopy() *AerospikeEvacuationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeEvacuationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeInstanceSummary) DeepCopyInto(out *AerospikeInstanceSummary) {
 	clone := in.DeepCopy()
@@ -157,6 +461,74 @@ func (in *AerospikeInstanceSummary) DeepCopyInto(out *AerospikeInstanceSummary)
 	return
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeLostNodeRecoverySpec) DeepCopyInto(out *AerospikeLostNodeRecoverySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeLostNodeRecoverySpec.
+func (in *AerospikeLostNodeRecoverySpec) DeepCopy() *AerospikeLostNodeRecoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeLostNodeRecoverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeMaintenanceWindow) DeepCopyInto(out *AerospikeMaintenanceWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]AerospikeWeekday, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeMaintenanceWindow.
+func (in *AerospikeMaintenanceWindow) DeepCopy() *AerospikeMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeNamespaceRoster) DeepCopyInto(out *AerospikeNamespaceRoster) {
+	*out = *in
+	if in.Roster != nil {
+		in, out := &in.Roster, &out.Roster
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingRoster != nil {
+		in, out := &in.PendingRoster, &out.PendingRoster
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObservedNodes != nil {
+		in, out := &in.ObservedNodes, &out.ObservedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeNamespaceRoster.
+func (in *AerospikeNamespaceRoster) DeepCopy() *AerospikeNamespaceRoster {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeNamespaceRoster)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeNetworkPolicy) DeepCopyInto(out *AerospikeNetworkPolicy) {
 	clone := in.DeepCopy()
@@ -179,49 +551,579 @@ func (in *AerospikePersistentVolumeSpec) DeepCopyInto(out *AerospikePersistentVo
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AerospikePodSpec) DeepCopyInto(out *AerospikePodSpec) {
+func (in *AerospikePlannedAction) DeepCopyInto(out *AerospikePlannedAction) {
 	*out = *in
-	if in.Sidecars != nil {
-		in, out := &in.Sidecars, &out.Sidecars
-		*out = make([]v1.Container, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.RackID != nil {
+		in, out := &in.RackID, &out.RackID
+		*out = new(int)
+		**out = **in
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodSpec.
-func (in *AerospikePodSpec) DeepCopy() *AerospikePodSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePlannedAction.
+func (in *AerospikePlannedAction) DeepCopy() *AerospikePlannedAction {
 	if in == nil {
 		return nil
 	}
-	out := new(AerospikePodSpec)
+	out := new(AerospikePlannedAction)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AerospikePodStatus) DeepCopyInto(out *AerospikePodStatus) {
-	clone := in.DeepCopy()
-	*out = *clone
+func (in *AerospikePodConfigDrift) DeepCopyInto(out *AerospikePodConfigDrift) {
+	*out = *in
+	if in.Diffs != nil {
+		in, out := &in.Diffs, &out.Diffs
+		*out = make([]AerospikeConfigDiff, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodConfigDrift.
+func (in *AerospikePodConfigDrift) DeepCopy() *AerospikePodConfigDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePodConfigDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AerospikeRoleSpec) DeepCopyInto(out *AerospikeRoleSpec) {
-	clone := in.DeepCopy()
-	*out = *clone
+func (in *AerospikePodDisruptionBudgetSpec) DeepCopyInto(out *AerospikePodDisruptionBudgetSpec) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodDisruptionBudgetSpec.
+func (in *AerospikePodDisruptionBudgetSpec) DeepCopy() *AerospikePodDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePodDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AerospikeStorageSpec) DeepCopyInto(out *AerospikeStorageSpec) {
-	clone := in.DeepCopy()
-	*out = *clone
+func (in *AerospikePodOperation) DeepCopyInto(out *AerospikePodOperation) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodOperation.
+func (in *AerospikePodOperation) DeepCopy() *AerospikePodOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePodOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikePodPlacementSpec) DeepCopyInto(out *AerospikePodPlacementSpec) {
+	*out = *in
+	if in.PreferredAntiAffinityTopologyKeys != nil {
+		in, out := &in.PreferredAntiAffinityTopologyKeys, &out.PreferredAntiAffinityTopologyKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterAntiAffinity != nil {
+		in, out := &in.ClusterAntiAffinity, &out.ClusterAntiAffinity
+		*out = new(AerospikeClusterAntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodPlacementSpec.
+func (in *AerospikePodPlacementSpec) DeepCopy() *AerospikePodPlacementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePodPlacementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikePodServiceSpec) DeepCopyInto(out *AerospikePodServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodServiceSpec.
+func (in *AerospikePodServiceSpec) DeepCopy() *AerospikePodServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePodServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikePodSpec) DeepCopyInto(out *AerospikePodSpec) {
+	*out = *in
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PreStop != nil {
+		in, out := &in.PreStop, &out.PreStop
+		*out = new(AerospikePreStopSpec)
+		**out = **in
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(AerospikeProbesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePodSpec.
+func (in *AerospikePodSpec) DeepCopy() *AerospikePodSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePodSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikePodStatus) DeepCopyInto(out *AerospikePodStatus) {
+	clone := in.DeepCopy()
+	*out = *clone
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikePreStopSpec) DeepCopyInto(out *AerospikePreStopSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikePreStopSpec.
+func (in *AerospikePreStopSpec) DeepCopy() *AerospikePreStopSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikePreStopSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeProbeSpec) DeepCopyInto(out *AerospikeProbeSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeProbeSpec.
+func (in *AerospikeProbeSpec) DeepCopy() *AerospikeProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeProbesSpec) DeepCopyInto(out *AerospikeProbesSpec) {
+	*out = *in
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(AerospikeProbeSpec)
+		**out = **in
+	}
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(AerospikeProbeSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeProbesSpec.
+func (in *AerospikeProbesSpec) DeepCopy() *AerospikeProbesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeProbesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeRackPodSpec) DeepCopyInto(out *AerospikeRackPodSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeRackPodSpec.
+func (in *AerospikeRackPodSpec) DeepCopy() *AerospikeRackPodSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeRackPodSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeRackUpgradeStatus) DeepCopyInto(out *AerospikeRackUpgradeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeRackUpgradeStatus.
+func (in *AerospikeRackUpgradeStatus) DeepCopy() *AerospikeRackUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeRackUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeReconcilePlan) DeepCopyInto(out *AerospikeReconcilePlan) {
+	*out = *in
+	in.ComputedTime.DeepCopyInto(&out.ComputedTime)
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]AerospikePlannedAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeReconcilePlan.
+func (in *AerospikeReconcilePlan) DeepCopy() *AerospikeReconcilePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeReconcilePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeRoleSpec) DeepCopyInto(out *AerospikeRoleSpec) {
+	clone := in.DeepCopy()
+	*out = *clone
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeSecondaryIndex) DeepCopyInto(out *AerospikeSecondaryIndex) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeSecondaryIndex.
+func (in *AerospikeSecondaryIndex) DeepCopy() *AerospikeSecondaryIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeSecondaryIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeSecondaryIndexSpec) DeepCopyInto(out *AerospikeSecondaryIndexSpec) {
+	*out = *in
+	if in.Indexes != nil {
+		in, out := &in.Indexes, &out.Indexes
+		*out = make([]AerospikeSecondaryIndex, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeSecondaryIndexSpec.
+func (in *AerospikeSecondaryIndexSpec) DeepCopy() *AerospikeSecondaryIndexSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeSecondaryIndexSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeSecondaryIndexState) DeepCopyInto(out *AerospikeSecondaryIndexState) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeSecondaryIndexState.
+func (in *AerospikeSecondaryIndexState) DeepCopy() *AerospikeSecondaryIndexState {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeSecondaryIndexState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeServicesSpec) DeepCopyInto(out *AerospikeServicesSpec) {
+	*out = *in
+	if in.ClusterService != nil {
+		in, out := &in.ClusterService, &out.ClusterService
+		*out = new(AerospikeClusterServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodService != nil {
+		in, out := &in.PodService, &out.PodService
+		*out = new(AerospikePodServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeServicesSpec.
+func (in *AerospikeServicesSpec) DeepCopy() *AerospikeServicesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeServicesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeStorageSpec) DeepCopyInto(out *AerospikeStorageSpec) {
+	clone := in.DeepCopy()
+	*out = *clone
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeTimeoutsSpec) DeepCopyInto(out *AerospikeTimeoutsSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeTimeoutsSpec.
+func (in *AerospikeTimeoutsSpec) DeepCopy() *AerospikeTimeoutsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeTimeoutsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeUDFModuleSource) DeepCopyInto(out *AerospikeUDFModuleSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeUDFModuleSource.
+func (in *AerospikeUDFModuleSource) DeepCopy() *AerospikeUDFModuleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeUDFModuleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeUDFModuleStatus) DeepCopyInto(out *AerospikeUDFModuleStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeUDFModuleStatus.
+func (in *AerospikeUDFModuleStatus) DeepCopy() *AerospikeUDFModuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeUDFModuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeUpgradePlan) DeepCopyInto(out *AerospikeUpgradePlan) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]AerospikeUpgradeStep, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeUpgradePlan.
+func (in *AerospikeUpgradePlan) DeepCopy() *AerospikeUpgradePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeUpgradePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeUpgradeStatus) DeepCopyInto(out *AerospikeUpgradeStatus) {
+	*out = *in
+	if in.Racks != nil {
+		in, out := &in.Racks, &out.Racks
+		*out = make([]AerospikeRackUpgradeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.CanaryPods != nil {
+		in, out := &in.CanaryPods, &out.CanaryPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CanaryUpgradedTime != nil {
+		in, out := &in.CanaryUpgradedTime, &out.CanaryUpgradedTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeUpgradeStatus.
+func (in *AerospikeUpgradeStatus) DeepCopy() *AerospikeUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeUpgradeStep) DeepCopyInto(out *AerospikeUpgradeStep) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeUpgradeStep.
+func (in *AerospikeUpgradeStep) DeepCopy() *AerospikeUpgradeStep {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeUpgradeStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AerospikeUpgradeStrategy) DeepCopyInto(out *AerospikeUpgradeStrategy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(AerospikeCanarySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HopImages != nil {
+		in, out := &in.HopImages, &out.HopImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AerospikeUpgradeStrategy.
+func (in *AerospikeUpgradeStrategy) DeepCopy() *AerospikeUpgradeStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(AerospikeUpgradeStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AerospikeUserSpec) DeepCopyInto(out *AerospikeUserSpec) {
 	clone := in.DeepCopy()
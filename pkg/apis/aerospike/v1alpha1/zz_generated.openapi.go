@@ -86,6 +86,20 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikeClusterSpec(ref common.Referenc
 							Format:      "",
 						},
 					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Version of the Aerospike server in the image e.g. 5.2.0.7. If not given it is taken from the image tag. Required for images pinned by digest or with tags not starting with the server version.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"edition": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Edition of the Aerospike server, enterprise or community. If not given it is detected from the image name.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"multiPodPerHost": {
 						SchemaProps: spec.SchemaProps{
 							Description: "If set true then multiple pods can be created per Kubernetes Node. This will create a NodePort service for each Pod. NodePort, as the name implies, opens a specific port on all the Kubernetes Nodes , and any traffic that is sent to this port is forwarded to the service. Here service picks a random port in range (30000-32767), so these port should be open.\n\nIf set false then only single pod can be created per Kubernetes Node. This will create Pods using hostPort setting. The container port will be exposed to the external network at <hostIP>:<hostPort>, where the hostIP is the IP address of the Kubernetes Node where the container is running and the hostPort is the port requested by the user.",
@@ -113,7 +127,7 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikeClusterSpec(ref common.Referenc
 					},
 					"aerospikeConfig": {
 						SchemaProps: spec.SchemaProps{
-							Description: "AerospikeConfig sets config in aerospike.conf file. Other configs are taken as default",
+							Description: "AerospikeConfig sets config in aerospike.conf file. Other configs are taken as default Required if aerospikeConfFile is not given.",
 							Type:        []string{"object"},
 							AdditionalProperties: &spec.SchemaOrBool{
 								Allows: true,
@@ -126,6 +140,12 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikeClusterSpec(ref common.Referenc
 							},
 						},
 					},
+					"aerospikeConfFile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AerospikeConfFile has the config in native aerospike.conf format. It is parsed into aerospikeConfig by the operator and takes precedence over aerospikeConfig.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfFileSpec"),
+						},
+					},
 					"resources": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Define resources requests and limits for Aerospike Server Container. Please contact aerospike for proper sizing exercise Only Memory and Cpu resources can be given Resources.Limits should be more than Resources.Requests.",
@@ -156,12 +176,99 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikeClusterSpec(ref common.Referenc
 							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodSpec"),
 						},
 					},
+					"upgradeStrategy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeStrategy controls how the Aerospike server image is upgraded.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUpgradeStrategy"),
+						},
+					},
+					"configDriftPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ConfigDriftPolicy enables the periodic check of the config running on the nodes against the config rendered by the operator.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfigDriftPolicy"),
+						},
+					},
+					"paused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Paused stops applying spec changes to the cluster. The operator only computes the plan of the pending actions and updates the observed status.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"maintenanceWindows": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaintenanceWindows are the times disruptive actions are allowed, i.e. rolling restart, upgrade, scale down and rack deletion. Disruptive actions are deferred till a window opens, other actions are applied right away. Disruptive actions are always allowed if not given.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeMaintenanceWindow"),
+									},
+								},
+							},
+						},
+					},
+					"evacuation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Evacuation lists the pods and Kubernetes nodes to move the Aerospike nodes off, e.g. pods with bad disks or nodes being decommissioned.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeEvacuationSpec"),
+						},
+					},
+					"lostNodeRecovery": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LostNodeRecovery re-creates the pods stuck on lost Kubernetes nodes with new volumes, e.g. pods with local PVs on a dead node. Recovery is disabled if not given.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeLostNodeRecoverySpec"),
+						},
+					},
+					"secondaryIndexes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecondaryIndexes are the secondary indexes created and kept by the operator.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeSecondaryIndexSpec"),
+						},
+					},
+					"udfModules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UDFModules are the ConfigMaps having the Lua UDF modules to register. Changes to the ConfigMaps requeue the cluster and are registered right away.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUDFModuleSource"),
+									},
+								},
+							},
+						},
+					},
+					"podPlacement": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodPlacement controls the anti-affinity and the spreading of the Aerospike pods. Changes apply to the pods scheduled afterwards.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodPlacementSpec"),
+						},
+					},
+					"podDisruptionBudget": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodDisruptionBudget controls the PodDisruptionBudgets created for the Aerospike pods, so that node drains and evictions do not take down more nodes than the data can survive. The budgets are created with the defaults if not given.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodDisruptionBudgetSpec"),
+						},
+					},
+					"timeouts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeouts controls how long the operator waits for the pods to get ready and for the migrations to finish.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeTimeoutsSpec"),
+						},
+					},
+					"services": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Services are the Services the clients connect through, in addition to the headless service of the cluster.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeServicesSpec"),
+						},
+					},
 				},
-				Required: []string{"size", "image", "aerospikeConfig", "resources"},
+				Required: []string{"size", "image", "resources"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeAccessControlSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfigSecretSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeNetworkPolicy", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeStorageSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.RackConfig", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.ValidationPolicySpec", "k8s.io/api/core/v1.ResourceRequirements"},
+			"github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeAccessControlSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfFileSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfigDriftPolicy", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfigSecretSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeEvacuationSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeLostNodeRecoverySpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeMaintenanceWindow", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeNetworkPolicy", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodDisruptionBudgetSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodPlacementSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeSecondaryIndexSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeServicesSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeStorageSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeTimeoutsSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUDFModuleSource", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUpgradeStrategy", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.RackConfig", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.ValidationPolicySpec", "k8s.io/api/core/v1.ResourceRequirements"},
 	}
 }
 
@@ -178,6 +285,95 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikeClusterStatus(ref common.Refere
 							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeClusterSpec"),
 						},
 					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Details about the current condition of the AerospikeCluster resource.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeClusterCondition"),
+									},
+								},
+							},
+						},
+					},
+					"upgrade": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Upgrade has the progress of the latest Aerospike server image upgrade.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUpgradeStatus"),
+						},
+					},
+					"upgradePlan": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePlan has the steps of the latest version change that goes through intermediate versions.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUpgradePlan"),
+						},
+					},
+					"configDrift": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ConfigDrift has the result of the latest config drift check.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfigDriftStatus"),
+						},
+					},
+					"plan": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Plan has the actions the operator would take to apply the spec. It is computed when the cluster is paused or a plan is requested by annotation.",
+							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeReconcilePlan"),
+						},
+					},
+					"podOperations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodOperations are the latest pod operations requested by annotation or started by the operator to recover a pod.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodOperation"),
+									},
+								},
+							},
+						},
+					},
+					"rosters": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rosters has the roster state of the strong consistency namespaces.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeNamespaceRoster"),
+									},
+								},
+							},
+						},
+					},
+					"secondaryIndexStates": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecondaryIndexStates has the build state of the secondary indexes in the spec.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeSecondaryIndexState"),
+									},
+								},
+							},
+						},
+					},
+					"registeredUDFModules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegisteredUDFModules are the UDF modules registered by the operator.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUDFModuleStatus"),
+									},
+								},
+							},
+						},
+					},
 					"pods": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
@@ -202,7 +398,7 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikeClusterStatus(ref common.Refere
 			},
 		},
 		Dependencies: []string{
-			"github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeClusterSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodStatus"},
+			"github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeClusterCondition", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeClusterSpec", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeConfigDriftStatus", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeNamespaceRoster", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodOperation", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikePodStatus", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeReconcilePlan", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeSecondaryIndexState", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUDFModuleStatus", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUpgradePlan", "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeUpgradeStatus"},
 	}
 }
 
@@ -436,6 +632,13 @@ func schema_pkg_apis_aerospike_v1alpha1_AerospikePodStatus(ref common.ReferenceC
 							Ref:         ref("github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1.AerospikeInstanceSummary"),
 						},
 					},
+					"aerospikeVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AerospikeVersion is the Aerospike server version verified running on this pod.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"initializedVolumePaths": {
 						SchemaProps: spec.SchemaProps{
 							Description: "InitializedVolumePaths is the list of device path that have already been initialized.",
@@ -1,16 +1,24 @@
 package admission
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
 	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
 	log "github.com/inconshreveable/log15"
 	av1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// aerospikeConfFileKey is the default key of the aerospike.conf file in the aerospikeConfFile ConfigMap
+const aerospikeConfFileKey = "aerospike.conf"
+
 // ClusterMutatingAdmissionWebhook admission mutation webhook
 type ClusterMutatingAdmissionWebhook struct {
 	obj    aerospikev1alpha1.AerospikeCluster
@@ -71,6 +79,11 @@ func MutateAerospikeCluster(req webhook.AdmissionRequest) webhook.AdmissionRespo
 func (s *ClusterMutatingAdmissionWebhook) MutateCreate() webhook.AdmissionResponse {
 	s.logger.Info("Mutate AerospikeCluster create")
 
+	if err := s.setAerospikeConfigFromConfFile(); err != nil {
+		s.logger.Error("Mutate AerospikeCluster create failed", log.Ctx{"err": err})
+		return webhook.Denied(err.Error())
+	}
+
 	if err := s.setDefaults(); err != nil {
 		s.logger.Error("Mutate AerospikeCluster create failed", log.Ctx{"err": err})
 		return webhook.Denied(err.Error())
@@ -83,13 +96,136 @@ func (s *ClusterMutatingAdmissionWebhook) MutateCreate() webhook.AdmissionRespon
 func (s *ClusterMutatingAdmissionWebhook) MutateUpdate(old aerospikev1alpha1.AerospikeCluster) webhook.AdmissionResponse {
 	s.logger.Info("Mutate AerospikeCluster update")
 
+	if err := s.setAerospikeConfigFromConfFile(); err != nil {
+		s.logger.Error("Mutate AerospikeCluster update failed", log.Ctx{"err": err})
+		return webhook.Denied(err.Error())
+	}
+
+	// Translate the config before setting defaults, defaults are version specific
+	changes, err := s.translateAerospikeConfig(old)
+	if err != nil {
+		s.logger.Error("Mutate AerospikeCluster update failed", log.Ctx{"err": err})
+		return webhook.Denied(err.Error())
+	}
+
 	// This will insert the defaults also
 	if err := s.setDefaults(); err != nil {
 		s.logger.Error("Mutate AerospikeCluster update failed", log.Ctx{"err": err})
 		return webhook.Denied(err.Error())
 	}
 
-	return webhook.Patched("Patched aerospike spec with updated spec", webhook.JSONPatchOp{Operation: "replace", Path: "/spec", Value: s.obj.Spec})
+	msg := "Patched aerospike spec with updated spec"
+	if len(changes) != 0 {
+		msg = fmt.Sprintf("%s. Translated aerospikeConfig for the new version: %s", msg, strings.Join(changes, ", "))
+	}
+	return webhook.Patched(msg, webhook.JSONPatchOp{Operation: "replace", Path: "/spec", Value: s.obj.Spec})
+}
+
+// setAerospikeConfigFromConfFile replaces the aerospikeConfig with the parsed aerospikeConfFile if given.
+func (s *ClusterMutatingAdmissionWebhook) setAerospikeConfigFromConfFile() error {
+	confFile := s.obj.Spec.AerospikeConfFile
+	if confFile == nil {
+		return nil
+	}
+
+	text := confFile.Text
+	if confFile.ConfigMapName != "" {
+		if text != "" {
+			return fmt.Errorf("Only one of aerospikeConfFile.text and aerospikeConfFile.configMapName can be given")
+		}
+
+		var err error
+		if text, err = s.getConfFileFromConfigMap(confFile); err != nil {
+			return err
+		}
+	}
+	if text == "" {
+		return fmt.Errorf("aerospikeConfFile is empty")
+	}
+
+	version, err := utils.GetAerospikeVersion(&s.obj.Spec)
+	if err != nil {
+		return err
+	}
+
+	config, err := configschema.ParseConfFile(text, version)
+	if err != nil {
+		return fmt.Errorf("Failed to parse aerospikeConfFile: %v", err)
+	}
+
+	s.logger.Info("Set aerospikeConfig from aerospikeConfFile", log.Ctx{"config": config})
+	s.obj.Spec.AerospikeConfig = config
+	return nil
+}
+
+// getConfFileFromConfigMap reads the aerospike.conf text from the aerospikeConfFile ConfigMap.
+func (s *ClusterMutatingAdmissionWebhook) getConfFileFromConfigMap(confFile *aerospikev1alpha1.AerospikeConfFileSpec) (string, error) {
+	if kubeClient == nil {
+		return "", fmt.Errorf("Cannot read aerospikeConfFile ConfigMap %s, client not initialized", confFile.ConfigMapName)
+	}
+
+	key := confFile.ConfigMapKey
+	if key == "" {
+		key = aerospikeConfFileKey
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := kubeClient.Get(context.TODO(), types.NamespacedName{Name: confFile.ConfigMapName, Namespace: s.obj.Namespace}, configMap); err != nil {
+		return "", fmt.Errorf("Failed to get aerospikeConfFile ConfigMap %s: %v", confFile.ConfigMapName, err)
+	}
+
+	text, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf("Key %s not found in aerospikeConfFile ConfigMap %s", key, confFile.ConfigMapName)
+	}
+	return text, nil
+}
+
+// translateAerospikeConfig rewrites the global and rack aerospikeConfig for the new version if the version is changed.
+// It returns the list of changes made.
+func (s *ClusterMutatingAdmissionWebhook) translateAerospikeConfig(old aerospikev1alpha1.AerospikeCluster) ([]string, error) {
+	if old.Spec.Image == "" || s.obj.Spec.AerospikeConfig == nil {
+		return nil, nil
+	}
+
+	oldVersion, err := utils.GetAerospikeVersion(&old.Spec)
+	if err != nil {
+		return nil, err
+	}
+	newVersion, err := utils.GetAerospikeVersion(&s.obj.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if oldVersion == newVersion {
+		return nil, nil
+	}
+
+	config, changes, err := configschema.TranslateConfig(s.obj.Spec.AerospikeConfig, oldVersion, newVersion)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to translate aerospikeConfig from version %s to %s: %v", oldVersion, newVersion, err)
+	}
+	s.obj.Spec.AerospikeConfig = config
+
+	for i, rack := range s.obj.Spec.RackConfig.Racks {
+		if rack.InputAerospikeConfig == nil {
+			continue
+		}
+		rackConfig, rackChanges, err := configschema.TranslateConfig(*rack.InputAerospikeConfig, oldVersion, newVersion)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to translate aerospikeConfig of rack %d from version %s to %s: %v", rack.ID, oldVersion, newVersion, err)
+		}
+		values := aerospikev1alpha1.Values(rackConfig)
+		s.obj.Spec.RackConfig.Racks[i].InputAerospikeConfig = &values
+
+		for _, change := range rackChanges {
+			changes = append(changes, fmt.Sprintf("rack %d: %s", rack.ID, change))
+		}
+	}
+
+	if len(changes) != 0 {
+		s.logger.Info("Translated aerospikeConfig", log.Ctx{"oldVersion": oldVersion, "newVersion": newVersion, "changes": changes})
+	}
+	return changes, nil
 }
 
 func (s *ClusterMutatingAdmissionWebhook) setDefaults() error {
@@ -130,6 +266,12 @@ func (s *ClusterMutatingAdmissionWebhook) setDefaults() error {
 		s.obj.Spec.ValidationPolicy = &validationPolicy
 	}
 
+	// Canary soak period
+	if strategy := s.obj.Spec.UpgradeStrategy; strategy != nil && strategy.Canary != nil && strategy.Canary.SoakSeconds == 0 {
+		s.logger.Info("Set default canary soak period", log.Ctx{"soakSeconds": utils.DefaultCanarySoakSeconds})
+		strategy.Canary.SoakSeconds = utils.DefaultCanarySoakSeconds
+	}
+
 	return nil
 }
 
@@ -146,7 +288,8 @@ func (s *ClusterMutatingAdmissionWebhook) setDefaultRackConf() error {
 				// User has modified defaultRackConfig or used defaultRackID
 				if len(s.obj.Spec.RackConfig.Racks) > 1 ||
 					rack.Zone != "" || rack.Region != "" || rack.RackLabel != "" || rack.NodeName != "" ||
-					rack.InputAerospikeConfig != nil || rack.InputStorage != nil {
+					rack.InputAerospikeConfig != nil || rack.InputStorage != nil || rack.InputPodSpec != nil ||
+					rack.Size != 0 || rack.Weight != 0 {
 					return fmt.Errorf("Invalid RackConfig %v. RackID %d is reserved", s.obj.Spec.RackConfig, utils.DefaultRackID)
 				}
 			}
@@ -168,6 +311,8 @@ func (s *ClusterMutatingAdmissionWebhook) updateRacks() error {
 		return fmt.Errorf("Error updating rack aerospike config: %v", err)
 	}
 
+	s.updateRacksPodSpecFromGlobal()
+
 	return nil
 }
 
@@ -216,6 +361,42 @@ func (s *ClusterMutatingAdmissionWebhook) updateRacksAerospikeConfigFromGlobal()
 	return nil
 }
 
+// updateRacksPodSpecFromGlobal sets the effective pod spec of the racks. The rack resources are merged over the global resources,
+// the image is resolved by the operator so that the racks without an image follow the cluster image.
+func (s *ClusterMutatingAdmissionWebhook) updateRacksPodSpecFromGlobal() {
+	for i, rack := range s.obj.Spec.RackConfig.Racks {
+		if rack.InputPodSpec == nil {
+			s.obj.Spec.RackConfig.Racks[i].PodSpec = aerospikev1alpha1.AerospikeRackPodSpec{}
+			continue
+		}
+
+		podSpec := *rack.InputPodSpec.DeepCopy()
+		if podSpec.Resources != nil && s.obj.Spec.Resources != nil {
+			podSpec.Resources = &corev1.ResourceRequirements{
+				Limits:   mergeResourceList(s.obj.Spec.Resources.Limits, podSpec.Resources.Limits),
+				Requests: mergeResourceList(s.obj.Spec.Resources.Requests, podSpec.Resources.Requests),
+			}
+		}
+		s.logger.Debug("Updated rack pod spec", log.Ctx{"rack id": rack.ID, "podSpec": podSpec})
+		s.obj.Spec.RackConfig.Racks[i].PodSpec = podSpec
+	}
+}
+
+// mergeResourceList returns the global resources with the rack resources overriding them.
+func mergeResourceList(global, rack corev1.ResourceList) corev1.ResourceList {
+	if global == nil && rack == nil {
+		return nil
+	}
+	merged := corev1.ResourceList{}
+	for name, quantity := range global {
+		merged[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range rack {
+		merged[name] = quantity.DeepCopy()
+	}
+	return merged
+}
+
 func (s *ClusterMutatingAdmissionWebhook) setDefaultAerospikeConfigs(config aerospikev1alpha1.Values) error {
 
 	// namespace conf
@@ -29,6 +29,7 @@ type MutatingAdmissionWebhook struct {
 // access the API.
 func NewMutatingAdmissionWebhook(namespace string, mgr manager.Manager, cl client.Client) *MutatingAdmissionWebhook {
 	scheme = mgr.GetScheme()
+	kubeClient = cl
 	return &MutatingAdmissionWebhook{
 		namespace: namespace,
 		client:    cl,
@@ -20,10 +20,14 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var scheme *runtime.Scheme
 
+// kubeClient is used by the mutating webhook to read the objects referred by the AerospikeCluster e.g. aerospikeConfFile ConfigMap
+var kubeClient client.Client
+
 var (
 	aerospikeGroupName = "aerospike.com"
 )
@@ -9,7 +9,6 @@ import (
 	accessControl "github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/asconfig"
 	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
 	"github.com/aerospike/aerospike-management-lib/asconfig"
-	"github.com/aerospike/aerospike-management-lib/deployment"
 	log "github.com/inconshreveable/log15"
 	av1beta1 "k8s.io/api/admission/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -64,25 +63,31 @@ func ValidateAerospikeCluster(req webhook.AdmissionRequest) webhook.AdmissionRes
 func (s *ClusterValidatingAdmissionWebhook) ValidateCreate() error {
 	s.logger.Info("Validate AerospikeCluster create")
 
-	return s.validate()
+	return s.validate(nil)
 }
 
 // ValidateUpdate validate update
 func (s *ClusterValidatingAdmissionWebhook) ValidateUpdate(old aerospikev1alpha1.AerospikeCluster) error {
 	s.logger.Info("Validate AerospikeCluster update")
-	if err := s.validate(); err != nil {
+	if err := s.validate(&old); err != nil {
 		return err
 	}
 
 	// Jump version should not be allowed
-	newVersion := strings.Split(s.obj.Spec.Image, ":")[1]
+	newVersion, err := utils.GetAerospikeVersion(&s.obj.Spec)
+	if err != nil {
+		return err
+	}
 	oldVersion := ""
 
 	if old.Spec.Image != "" {
-		oldVersion = strings.Split(old.Spec.Image, ":")[1]
+		oldVersion, err = utils.GetAerospikeVersion(&old.Spec)
+		if err != nil {
+			return err
+		}
 	}
-	if err := deployment.IsValidUpgrade(oldVersion, newVersion); err != nil {
-		return fmt.Errorf("Failed to start upgrade: %v", err)
+	if err := validateUpgrade(oldVersion, newVersion, s.obj.Spec.Image, s.obj.Spec.UpgradeStrategy); err != nil {
+		return err
 	}
 
 	// Volume storage update is not allowed but cascadeDelete policy is allowed
@@ -113,7 +118,8 @@ func (s *ClusterValidatingAdmissionWebhook) ValidateUpdate(old aerospikev1alpha1
 	return nil
 }
 
-func (s *ClusterValidatingAdmissionWebhook) validate() error {
+// validate validates the spec, old is the cluster before the update and nil on create.
+func (s *ClusterValidatingAdmissionWebhook) validate(old *aerospikev1alpha1.AerospikeCluster) error {
 	s.logger.Debug("Validate AerospikeCluster spec", log.Ctx{"obj.Spec": s.obj.Spec})
 
 	// Validate obj name
@@ -138,16 +144,18 @@ func (s *ClusterValidatingAdmissionWebhook) validate() error {
 		return fmt.Errorf("AerospikeCluster name cannot have spaces")
 	}
 
-	// Validate image type. Only enterprise image allowed for now
-	if !isEnterprise(s.obj.Spec.Image) {
-		return fmt.Errorf("CommunityEdition Cluster not supported")
-	}
-
 	// Validate size
 	if s.obj.Spec.Size == 0 {
 		return fmt.Errorf("Invalid cluster size 0")
 	}
 
+	// Validate edition. Enterprise only features are not allowed for community edition
+	if !utils.IsEnterprise(&s.obj.Spec) {
+		if err := validateCommunityEdition(&s.obj.Spec); err != nil {
+			return err
+		}
+	}
+
 	// TODO: Validate if multiPodPerHost is false then number of kubernetes host should be >= size
 
 	// Validate for AerospikeConfigSecret.
@@ -157,7 +165,7 @@ func (s *ClusterValidatingAdmissionWebhook) validate() error {
 	}
 
 	// Validate Image version
-	version, err := getImageVersion(s.obj.Spec.Image)
+	version, err := utils.GetAerospikeVersion(&s.obj.Spec)
 	if err != nil {
 		return err
 	}
@@ -207,7 +215,7 @@ func (s *ClusterValidatingAdmissionWebhook) validate() error {
 	}
 
 	// Validate rackConfig
-	if err := s.validateRackConfig(); err != nil {
+	if err := s.validateRackConfig(old); err != nil {
 		return err
 	}
 
@@ -216,6 +224,99 @@ func (s *ClusterValidatingAdmissionWebhook) validate() error {
 		return err
 	}
 
+	// Validate maintenance windows
+	for _, window := range s.obj.Spec.MaintenanceWindows {
+		if err := utils.ValidateMaintenanceWindow(window); err != nil {
+			return err
+		}
+	}
+
+	// Validate evacuation
+	if err := s.validateEvacuation(); err != nil {
+		return err
+	}
+
+	// Validate secondary indexes
+	if err := s.validateSecondaryIndexes(); err != nil {
+		return err
+	}
+
+	// Validate upgrade strategy
+	if err := validateUpgradeStrategy(s.obj.Spec.UpgradeStrategy); err != nil {
+		return err
+	}
+
+	// Validate UDF modules
+	if err := validateUDFModules(s.obj.Spec.UDFModules); err != nil {
+		return err
+	}
+
+	// Validate pod placement
+	if err := validatePodPlacement(s.obj.Spec.PodPlacement); err != nil {
+		return err
+	}
+
+	// Validate PodDisruptionBudget
+	if pdb := s.obj.Spec.PodDisruptionBudget; pdb != nil && pdb.MaxUnavailable != nil && *pdb.MaxUnavailable < 0 {
+		return fmt.Errorf("PodDisruptionBudget maxUnavailable cannot be negative: %d", *pdb.MaxUnavailable)
+	}
+
+	// Validate timeouts
+	if timeouts := s.obj.Spec.Timeouts; timeouts != nil {
+		if timeouts.PodReadySeconds < 0 || timeouts.ColdStartSeconds < 0 || timeouts.MigrationSeconds < 0 {
+			return fmt.Errorf("Timeouts cannot be negative: %+v", *timeouts)
+		}
+		if timeouts.PodReadySeconds > 0 && timeouts.ColdStartSeconds > 0 && timeouts.ColdStartSeconds < timeouts.PodReadySeconds {
+			return fmt.Errorf("Timeouts coldStartSeconds %d should not be less than podReadySeconds %d", timeouts.ColdStartSeconds, timeouts.PodReadySeconds)
+		}
+	}
+
+	// Validate services
+	if err := validateServices(s.obj.Spec.Services, s.obj.Spec.AerospikeNetworkPolicy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateSecondaryIndexes checks that the index names are unique in a namespace and the namespaces are defined.
+func (s *ClusterValidatingAdmissionWebhook) validateSecondaryIndexes() error {
+	if s.obj.Spec.SecondaryIndexes == nil {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, index := range s.obj.Spec.SecondaryIndexes.Indexes {
+		if index.Name == "" || index.Namespace == "" || index.Bin == "" || index.Type == "" {
+			return fmt.Errorf("Secondary index name, namespace, bin and type cannot be empty: %v", index)
+		}
+		key := index.Namespace + "/" + index.Name
+		if names[key] {
+			return fmt.Errorf("Duplicate secondary index %s in namespace %s", index.Name, index.Namespace)
+		}
+		names[key] = true
+
+		if !utils.IsAerospikeNamespacePresent(s.obj.Spec.AerospikeConfig, index.Namespace) {
+			return fmt.Errorf("Namespace %s of secondary index %s is not defined in aerospikeConfig", index.Namespace, index.Name)
+		}
+	}
+	return nil
+}
+
+// validateEvacuation checks that the racks pinned to a node do not pin it to an evacuated node.
+func (s *ClusterValidatingAdmissionWebhook) validateEvacuation() error {
+	evacuation := s.obj.Spec.Evacuation
+	if evacuation == nil {
+		return nil
+	}
+
+	for _, rack := range s.obj.Spec.RackConfig.Racks {
+		for _, node := range evacuation.Nodes {
+			if rack.NodeName == node {
+				return fmt.Errorf("Rack %d is pinned to evacuated node %s", rack.ID, node)
+			}
+		}
+	}
 	return nil
 }
 
@@ -239,13 +340,7 @@ func (s *ClusterValidatingAdmissionWebhook) validateRackUpdate(old aerospikev1al
 
 			if oldRack.ID == newRack.ID {
 
-				if oldRack.NodeName != newRack.NodeName ||
-					oldRack.RackLabel != newRack.RackLabel ||
-					oldRack.Region != newRack.Region ||
-					oldRack.Zone != newRack.Zone {
-
-					return fmt.Errorf("Old RackConfig (NodeName, RackLabel, Region, Zone) can not be updated. Old rack %v, new rack %v", oldRack, newRack)
-				}
+				// NodeName, RackLabel, Region, Zone and NodeSelectorRequirements can be updated, the pods are relocated one at a time
 
 				if len(oldRack.AerospikeConfig) != 0 || len(newRack.AerospikeConfig) != 0 {
 					// Config might have changed
@@ -299,10 +394,15 @@ func (s *ClusterValidatingAdmissionWebhook) validateResourceAndLimits() error {
 	return nil
 }
 
-func (s *ClusterValidatingAdmissionWebhook) validateRackConfig() error {
+func (s *ClusterValidatingAdmissionWebhook) validateRackConfig(old *aerospikev1alpha1.AerospikeCluster) error {
 	if len(s.obj.Spec.RackConfig.Racks) != 0 && (int(s.obj.Spec.Size) < len(s.obj.Spec.RackConfig.Racks)) {
 		return fmt.Errorf("Cluster size can not be less than number of Racks")
 	}
+	if len(s.obj.Spec.RackConfig.Racks) != 0 {
+		if err := utils.ValidateRackSizes(int(s.obj.Spec.Size), s.obj.Spec.RackConfig.Racks); err != nil {
+			return err
+		}
+	}
 
 	// Validate namespace names
 	// TODO: Add more validation for namespace name
@@ -312,7 +412,7 @@ func (s *ClusterValidatingAdmissionWebhook) validateRackConfig() error {
 		}
 	}
 
-	version, err := getImageVersion(s.obj.Spec.Image)
+	version, err := utils.GetAerospikeVersion(&s.obj.Spec)
 	if err != nil {
 		return err
 	}
@@ -348,6 +448,20 @@ func (s *ClusterValidatingAdmissionWebhook) validateRackConfig() error {
 				return fmt.Errorf("AerospikeConfig not valid for rack %v", rack)
 			}
 		}
+
+		var oldSpec *aerospikev1alpha1.AerospikeClusterSpec
+		if old != nil {
+			oldSpec = &old.Spec
+		}
+		if err := validateRackPodSpec(&s.obj.Spec, rack, oldSpec); err != nil {
+			return err
+		}
+
+		for _, requirement := range rack.NodeSelectorRequirements {
+			if requirement.Key == "" || requirement.Operator == "" {
+				return fmt.Errorf("Rack %d node selector requirement key and operator cannot be empty: %v", rack.ID, requirement)
+			}
+		}
 	}
 
 	return nil
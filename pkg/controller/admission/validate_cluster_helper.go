@@ -8,9 +8,12 @@ import (
 
 	"github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
 	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
 	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
 	"github.com/aerospike/aerospike-management-lib/asconfig"
+	"github.com/aerospike/aerospike-management-lib/deployment"
 	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // After 4.0, before 31
@@ -316,12 +319,18 @@ func validateNsConfUpdate(logger log.Logger, newConf, oldConf aerospikev1alpha1.
 func validateAerospikeConfigSchema(logger log.Logger, version string, config aerospikev1alpha1.Values) error {
 	logger = logger.New(log.Ctx{"version": version})
 
-	asConf, err := asconfig.NewMapAsConfig(version, config)
+	// Fallback to the nearest lower schema if there is no schema for this version
+	validationVersion, err := configschema.GetValidationVersion(version)
+	if err != nil {
+		return err
+	}
+
+	asConf, err := asconfig.NewMapAsConfig(validationVersion, config)
 	if err != nil {
 		return fmt.Errorf("Failed to load config map by lib: %v", err)
 	}
 
-	valid, validationErr, err := asConf.IsValid(version)
+	valid, validationErr, err := asConf.IsValid(validationVersion)
 	if !valid {
 		errStrs := []string{}
 		for _, e := range validationErr {
@@ -388,14 +397,198 @@ func validateConfigMapVolumes(logger log.Logger, config aerospikev1alpha1.Values
 	return err
 }
 
-func getImageVersion(imageStr string) (string, error) {
-	_, _, version := utils.ParseDockerImageTag(imageStr)
+// validateRackPodSpec validates the rack image version and resources, and that the rack labels do not override the operator labels.
+// On update oldSpec is the spec before the update, the upgrade from the old rack image to the new one is validated like the cluster image.
+func validateRackPodSpec(spec *aerospikev1alpha1.AerospikeClusterSpec, rack aerospikev1alpha1.Rack, oldSpec *aerospikev1alpha1.AerospikeClusterSpec) error {
+	podSpec := rack.PodSpec
+
+	if podSpec.Image != "" {
+		if err := validateImagePinned(podSpec.Image); err != nil {
+			return fmt.Errorf("Invalid image for rack %d: %v", rack.ID, err)
+		}
+		version, err := utils.GetAerospikeVersion(&aerospikev1alpha1.AerospikeClusterSpec{Image: podSpec.Image})
+		if err != nil {
+			return fmt.Errorf("Invalid image for rack %d: %v", rack.ID, err)
+		}
+		val, err := asconfig.CompareVersions(version, baseVersion)
+		if err != nil {
+			return fmt.Errorf("Failed to check image version for rack %d: %v", rack.ID, err)
+		}
+		if val < 0 {
+			return fmt.Errorf("Image version %s not supported for rack %d. Base version %s", version, rack.ID, baseVersion)
+		}
+	}
+
+	if oldSpec != nil {
+		if err := validateRackUpgrade(spec, rack, oldSpec); err != nil {
+			return fmt.Errorf("Invalid image for rack %d: %v", rack.ID, err)
+		}
+	}
+
+	if res := podSpec.Resources; res != nil && res.Limits != nil && res.Requests != nil &&
+		((res.Limits.Cpu().Cmp(*res.Requests.Cpu()) < 0) ||
+			(res.Limits.Memory().Cmp(*res.Requests.Memory()) < 0)) {
+		return fmt.Errorf("Resource.Limits cannot be less than Resource.Requests for rack %d. Resources %v", rack.ID, res)
+	}
+
+	for key := range utils.LabelsForAerospikeClusterRack("", rack.ID) {
+		if _, ok := podSpec.Labels[key]; ok {
+			return fmt.Errorf("Label %s of rack %d is set by the operator and cannot be overridden", key, rack.ID)
+		}
+	}
+	return nil
+}
+
+// validateServices validates the client facing Services and that the loadBalancer network type has a per pod Service to advertise.
+func validateServices(services *aerospikev1alpha1.AerospikeServicesSpec, networkPolicy aerospikev1alpha1.AerospikeNetworkPolicy) error {
+	if services != nil && services.ClusterService != nil {
+		clusterService := services.ClusterService
+		if clusterService.Type != "" && clusterService.Type != corev1.ServiceTypeClusterIP && clusterService.Type != corev1.ServiceTypeLoadBalancer {
+			return fmt.Errorf("Invalid cluster service type %s, only ClusterIP and LoadBalancer are supported", clusterService.Type)
+		}
+		if clusterService.Type != corev1.ServiceTypeLoadBalancer && len(clusterService.LoadBalancerSourceRanges) != 0 {
+			return fmt.Errorf("Cluster service loadBalancerSourceRanges need type LoadBalancer")
+		}
+	}
+
+	networkTypes := []aerospikev1alpha1.AerospikeNetworkType{networkPolicy.AccessType, networkPolicy.AlternateAccessType, networkPolicy.TLSAccessType, networkPolicy.TLSAlternateAccessType}
+	for _, networkType := range networkTypes {
+		if networkType == aerospikev1alpha1.AerospikeNetworkTypeLoadBalancer && (services == nil || services.PodService == nil) {
+			return fmt.Errorf("Network type %s needs services.podService", networkType)
+		}
+	}
+	return nil
+}
+
+// validatePodPlacement validates the preferred anti-affinity topology keys and the cluster anti-affinity mode.
+func validatePodPlacement(placement *aerospikev1alpha1.AerospikePodPlacementSpec) error {
+	if placement == nil {
+		return nil
+	}
+
+	topologyKeys := map[string]bool{}
+	for _, topologyKey := range placement.PreferredAntiAffinityTopologyKeys {
+		if topologyKey == "" {
+			return fmt.Errorf("Preferred anti-affinity topology key cannot be empty")
+		}
+		if topologyKeys[topologyKey] {
+			return fmt.Errorf("Preferred anti-affinity topology key %s is repeated", topologyKey)
+		}
+		topologyKeys[topologyKey] = true
+	}
+
+	if clusterAntiAffinity := placement.ClusterAntiAffinity; clusterAntiAffinity != nil && clusterAntiAffinity.Mode == aerospikev1alpha1.AerospikeAntiAffinityNone {
+		return fmt.Errorf("Cluster anti-affinity mode can be Required or Preferred, remove clusterAntiAffinity to disable it")
+	}
+	return nil
+}
+
+// validateUpgradeStrategy validates that the canary soaks for a positive time, so that the health gates are checked after it.
+func validateUpgradeStrategy(strategy *aerospikev1alpha1.AerospikeUpgradeStrategy) error {
+	if strategy == nil || strategy.Canary == nil {
+		return nil
+	}
+	if strategy.Canary.SoakSeconds <= 0 {
+		return fmt.Errorf("Canary soakSeconds has to be positive: %d", strategy.Canary.SoakSeconds)
+	}
+	return nil
+}
+
+// validateUDFModules validates that the UDF module ConfigMaps are named and not repeated.
+func validateUDFModules(sources []aerospikev1alpha1.AerospikeUDFModuleSource) error {
+	configMaps := map[string]bool{}
+	for _, source := range sources {
+		if source.ConfigMapName == "" {
+			return fmt.Errorf("UDF module ConfigMap name cannot be empty")
+		}
+		if configMaps[source.ConfigMapName] {
+			return fmt.Errorf("Duplicate UDF module ConfigMap %s", source.ConfigMapName)
+		}
+		configMaps[source.ConfigMapName] = true
+	}
+	return nil
+}
+
+// validateImagePinned validates that the image has a version tag or a digest.
+func validateImagePinned(image string) error {
+	ref := utils.ParseImageReference(image)
+
+	if ref.Digest == "" && (ref.Tag == "" || strings.ToLower(ref.Tag) == "latest") {
+		return fmt.Errorf("Image version is mandatory for image: %v", image)
+	}
+
+	return nil
+}
+
+// validateRackUpgrade validates the upgrade from the image of the rack in oldSpec to the new rack image.
+// The rack image falls back to the cluster image, racks not in oldSpec are created with the new image.
+func validateRackUpgrade(spec *aerospikev1alpha1.AerospikeClusterSpec, rack aerospikev1alpha1.Rack, oldSpec *aerospikev1alpha1.AerospikeClusterSpec) error {
+	var oldRack *aerospikev1alpha1.Rack
+	for i := range oldSpec.RackConfig.Racks {
+		if oldSpec.RackConfig.Racks[i].ID == rack.ID {
+			oldRack = &oldSpec.RackConfig.Racks[i]
+			break
+		}
+	}
+	if oldRack == nil {
+		return nil
+	}
+
+	oldImage := oldRack.PodSpec.Image
+	if oldImage == "" {
+		oldImage = oldSpec.Image
+	}
+	image := rack.PodSpec.Image
+	if image == "" {
+		image = spec.Image
+	}
+	if oldImage == "" || oldImage == image {
+		return nil
+	}
+
+	oldVersion, err := utils.GetAerospikeVersion(&aerospikev1alpha1.AerospikeClusterSpec{Image: oldImage})
+	if err != nil {
+		return err
+	}
+	version, err := utils.GetAerospikeVersion(&aerospikev1alpha1.AerospikeClusterSpec{Image: image})
+	if err != nil {
+		return err
+	}
+	return validateUpgrade(oldVersion, version, image, spec.UpgradeStrategy)
+}
 
-	if version == "" || strings.ToLower(version) == "latest" {
-		return "", fmt.Errorf("Image version is mandatory for image: %v", imageStr)
+// validateUpgrade validates the version change from oldVersion to newVersion of image, directly or through the hop images.
+func validateUpgrade(oldVersion, newVersion, image string, strategy *aerospikev1alpha1.AerospikeUpgradeStrategy) error {
+	if err := deployment.IsValidUpgrade(oldVersion, newVersion); err != nil {
+		// Versions that cannot be skipped are upgraded to one at a time
+		if hopErr := validateUpgradeHops(oldVersion, newVersion, image, strategy); hopErr != nil {
+			return fmt.Errorf("Failed to start upgrade: %v: %v", err, hopErr)
+		}
+	}
+	return nil
+}
+
+// validateUpgradeHops validates the version change from oldVersion to newVersion of image can be done through the hop images.
+func validateUpgradeHops(oldVersion, newVersion, image string, strategy *aerospikev1alpha1.AerospikeUpgradeStrategy) error {
+	hops, err := utils.GetUpgradeHops(oldVersion, newVersion)
+	if err != nil {
+		return err
 	}
 
-	return version, nil
+	var hopImages []string
+	if strategy != nil {
+		hopImages = strategy.HopImages
+	}
+
+	fromVersion := oldVersion
+	for _, hop := range hops {
+		hopImage, version := utils.GetHopImage(image, hopImages, hop)
+		if err := deployment.IsValidUpgrade(fromVersion, version); err != nil {
+			return fmt.Errorf("Invalid hop image %s: %v", hopImage, err)
+		}
+		fromVersion = version
+	}
+	return deployment.IsValidUpgrade(fromVersion, newVersion)
 }
 
 // isInMemoryNamespace returns true if this nameapce config uses memory for storage.
@@ -404,9 +597,44 @@ func isInMemoryNamespace(namespaceConf map[string]interface{}) bool {
 	return !ok || storage == "memory"
 }
 
-// isEnterprise indicates if aerospike image is enterprise
-func isEnterprise(image string) bool {
-	return strings.Contains(strings.ToLower(image), "enterprise")
+// validateCommunityEdition validates that only the features available in community edition are used.
+func validateCommunityEdition(spec *aerospikev1alpha1.AerospikeClusterSpec) error {
+	if int(spec.Size) > maxCommunityClusterSz {
+		return fmt.Errorf("Community edition cluster size cannot be more than %d", maxCommunityClusterSz)
+	}
+
+	if spec.AerospikeAccessControl != nil {
+		return fmt.Errorf("aerospikeAccessControl is not supported in community edition")
+	}
+
+	// Rack aware namespaces need rack-id config which is enterprise only
+	if len(spec.RackConfig.Namespaces) != 0 {
+		return fmt.Errorf("Rack aware namespaces are not supported in community edition, rackConfig.namespaces %v", spec.RackConfig.Namespaces)
+	}
+
+	configs := []aerospikev1alpha1.Values{spec.AerospikeConfig}
+	for _, rack := range spec.RackConfig.Racks {
+		configs = append(configs, rack.AerospikeConfig)
+	}
+
+	for _, config := range configs {
+		enabled, err := utils.IsSecurityEnabled(config)
+		if err != nil {
+			return err
+		}
+		if enabled {
+			return fmt.Errorf("Security is not supported in community edition")
+		}
+
+		if utils.IsTLS(config) {
+			return fmt.Errorf("TLS is not supported in community edition")
+		}
+
+		if utils.IsStrongConsistencyEnabled(config) {
+			return fmt.Errorf("Strong consistency is not supported in community edition")
+		}
+	}
+	return nil
 }
 
 // isSecretNeeded indicates if aerospikeConfig needs secret
@@ -454,12 +682,46 @@ func (s *ClusterValidatingAdmissionWebhook) validatePodSpec() error {
 			return fmt.Errorf("Cannot use reserved sidecar name: %v", sidecar.Name)
 		}
 
-		_, err := getImageVersion(sidecar.Image)
+		if err := validateImagePinned(sidecar.Image); err != nil {
+			return err
+		}
+	}
 
-		if err != nil {
+	podSpec := s.obj.Spec.PodSpec
+	if podSpec.TerminationGracePeriodSeconds != nil && *podSpec.TerminationGracePeriodSeconds < 0 {
+		return fmt.Errorf("TerminationGracePeriodSeconds cannot be negative: %d", *podSpec.TerminationGracePeriodSeconds)
+	}
+	if preStop := podSpec.PreStop; preStop == nil || !preStop.Disabled {
+		drainSeconds := int32(utils.DefaultPreStopDrainSeconds)
+		if preStop != nil && preStop.DrainSeconds != 0 {
+			drainSeconds = preStop.DrainSeconds
+		}
+		if drainSeconds < 0 {
+			return fmt.Errorf("PreStop drainSeconds cannot be negative: %d", drainSeconds)
+		}
+		// The server needs time to stop after the hook
+		if podSpec.TerminationGracePeriodSeconds != nil && int64(drainSeconds) >= *podSpec.TerminationGracePeriodSeconds {
+			return fmt.Errorf("PreStop drainSeconds %d should be less than terminationGracePeriodSeconds %d", drainSeconds, *podSpec.TerminationGracePeriodSeconds)
+		}
+	}
+	if probes := podSpec.Probes; probes != nil {
+		if err := validateProbe("readiness", probes.Readiness); err != nil {
+			return err
+		}
+		if err := validateProbe("liveness", probes.Liveness); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+func validateProbe(name string, probe *aerospikev1alpha1.AerospikeProbeSpec) error {
+	if probe == nil {
+		return nil
+	}
+	if probe.InitialDelaySeconds < 0 || probe.PeriodSeconds < 0 || probe.TimeoutSeconds < 0 || probe.FailureThreshold < 0 {
+		return fmt.Errorf("Probe %s settings cannot be negative: %+v", name, *probe)
+	}
+	return nil
+}
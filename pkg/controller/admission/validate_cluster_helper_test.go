@@ -0,0 +1,95 @@
+package admission
+
+import (
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
+)
+
+func TestValidateUDFModules(t *testing.T) {
+	moduleTests := []struct {
+		configMaps []string
+		valid      bool
+	}{
+		{nil, true},
+		{[]string{"udf-1", "udf-2"}, true},
+		{[]string{""}, false},
+		{[]string{"udf-1", "udf-1"}, false},
+	}
+
+	for _, tt := range moduleTests {
+		var sources []aerospikev1alpha1.AerospikeUDFModuleSource
+		for _, name := range tt.configMaps {
+			sources = append(sources, aerospikev1alpha1.AerospikeUDFModuleSource{ConfigMapName: name})
+		}
+		if err := validateUDFModules(sources); (err == nil) != tt.valid {
+			t.Errorf("validateUDFModules(%v) = %v, want valid %v", tt.configMaps, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateUpgradeStrategy(t *testing.T) {
+	strategyTests := []struct {
+		strategy *aerospikev1alpha1.AerospikeUpgradeStrategy
+		valid    bool
+	}{
+		{nil, true},
+		{&aerospikev1alpha1.AerospikeUpgradeStrategy{}, true},
+		{&aerospikev1alpha1.AerospikeUpgradeStrategy{Canary: &aerospikev1alpha1.AerospikeCanarySpec{SoakSeconds: 300}}, true},
+		{&aerospikev1alpha1.AerospikeUpgradeStrategy{Canary: &aerospikev1alpha1.AerospikeCanarySpec{}}, false},
+		{&aerospikev1alpha1.AerospikeUpgradeStrategy{Canary: &aerospikev1alpha1.AerospikeCanarySpec{SoakSeconds: -1}}, false},
+	}
+
+	for _, tt := range strategyTests {
+		if err := validateUpgradeStrategy(tt.strategy); (err == nil) != tt.valid {
+			t.Errorf("validateUpgradeStrategy(%+v) = %v, want valid %v", tt.strategy, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateRackUpgrade(t *testing.T) {
+	if err := configschema.Init(""); err != nil {
+		t.Fatalf("configschema.Init() error = %v", err)
+	}
+
+	spec := func(image, rackImage string, hopImages ...string) *aerospikev1alpha1.AerospikeClusterSpec {
+		rack := aerospikev1alpha1.Rack{ID: 1}
+		rack.PodSpec.Image = rackImage
+		return &aerospikev1alpha1.AerospikeClusterSpec{
+			Image:           image,
+			RackConfig:      aerospikev1alpha1.RackConfig{Racks: []aerospikev1alpha1.Rack{rack}},
+			UpgradeStrategy: &aerospikev1alpha1.AerospikeUpgradeStrategy{HopImages: hopImages},
+		}
+	}
+
+	upgradeTests := []struct {
+		name    string
+		oldSpec *aerospikev1alpha1.AerospikeClusterSpec
+		spec    *aerospikev1alpha1.AerospikeClusterSpec
+		valid   bool
+	}{
+		{"rack image not changed", spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.8.0.5"),
+			spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.8.0.5"), true},
+		{"rack image upgrade", spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.9.0.11"),
+			spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:5.2.0.7"), true},
+		{"rack image jumps a version", spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.8.0.5"),
+			spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:5.2.0.7"), true},
+		{"rack image falls back to the cluster image", spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.8.0.5"),
+			spec("aerospike/aerospike-server-enterprise:5.2.0.7", ""), true},
+		{"rack image from an unsupported version", spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:2.0.0.1"),
+			spec("aerospike/aerospike-server-enterprise:5.2.0.7", ""), false},
+		{"rack image jumps a version through a hop image", spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.8.0.5"),
+			spec("aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:5.2.0.7", "aerospike/aerospike-server-enterprise:4.9.0.11"), true},
+		{"new rack", spec("aerospike/aerospike-server-enterprise:4.8.0.5", ""),
+			&aerospikev1alpha1.AerospikeClusterSpec{Image: "aerospike/aerospike-server-enterprise:4.8.0.5",
+				RackConfig: aerospikev1alpha1.RackConfig{Racks: []aerospikev1alpha1.Rack{{ID: 2}}}}, true},
+	}
+
+	for _, tt := range upgradeTests {
+		rack := tt.spec.RackConfig.Racks[0]
+		if err := validateRackUpgrade(tt.spec, rack, tt.oldSpec); (err == nil) != tt.valid {
+			t.Errorf("validateRackUpgrade(%s) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
@@ -62,6 +62,8 @@ func (r *ReconcileAerospikeCluster) waitForNodeSafeStopReady(aeroCluster *aerosp
 	}
 
 	// Wait for migration to finish
+	migrationTimeout := getMigrationTimeout(aeroCluster)
+	start := time.Now()
 	for {
 		logger.Info("Waiting for migrations to be zero")
 		time.Sleep(time.Second * 2)
@@ -73,6 +75,9 @@ func (r *ReconcileAerospikeCluster) waitForNodeSafeStopReady(aeroCluster *aerosp
 		if isStable {
 			break
 		}
+		if time.Since(start) > migrationTimeout {
+			return r.setTimeoutCondition(aeroCluster, migrationTimeoutReason, fmt.Sprintf("Migrations did not finish in %v, pod %s is not stopped", migrationTimeout, pod.Name))
+		}
 	}
 	time.Sleep(time.Second * 10)
 
@@ -250,6 +255,22 @@ func getServiceTLSName(aeroCluster *aerospikev1alpha1.AerospikeCluster) string {
 	return ""
 }
 
+// getServiceTLSConfig returns the network tls config named by the service tls-name, or nil if the service has no TLS.
+func getServiceTLSConfig(aeroCluster *aerospikev1alpha1.AerospikeCluster) map[string]interface{} {
+	tlsName := getServiceTLSName(aeroCluster)
+	if tlsName == "" {
+		return nil
+	}
+	networkConf, _ := aeroCluster.Spec.AerospikeConfig["network"].(map[string]interface{})
+	tlsConfList, _ := networkConf["tls"].([]interface{})
+	for _, tlsConfInt := range tlsConfList {
+		if tlsConf, ok := tlsConfInt.(map[string]interface{}); ok && tlsConf["name"] == tlsName {
+			return tlsConf
+		}
+	}
+	return nil
+}
+
 func getFQDNForPod(aeroCluster *aerospikev1alpha1.AerospikeCluster, host string) string {
 	return fmt.Sprintf("%s.%s.%s", host, aeroCluster.Name, aeroCluster.Namespace)
 }
@@ -25,6 +25,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -59,7 +60,7 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileAerospikeCluster{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileAerospikeCluster{client: mgr.GetClient(), scheme: mgr.GetScheme(), kubeConfig: mgr.GetConfig()}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -74,8 +75,17 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	err = c.Watch(
 		&source.Kind{Type: &aerospikev1alpha1.AerospikeCluster{}},
 		&handler.EnqueueRequestForObject{},
-		// Skip where cluster object generation is not changed
-		predicate.GenerationChangedPredicate{})
+		// Skip where cluster object generation is not changed.
+		// Operator annotations are not part of the spec, watch them too e.g. to compute the plan on request.
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				if e.MetaOld == nil || e.MetaNew == nil {
+					return false
+				}
+				return e.MetaNew.GetGeneration() != e.MetaOld.GetGeneration() ||
+					!reflect.DeepEqual(getOperatorAnnotations(e.MetaOld.GetAnnotations()), getOperatorAnnotations(e.MetaNew.GetAnnotations()))
+			},
+		})
 	if err != nil {
 		return err
 	}
@@ -102,9 +112,79 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for operation annotations on the cluster pods and pods becoming unschedulable, and requeue the AerospikeCluster
+	err = c.Watch(
+		&source.Kind{Type: &corev1.Pod{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+				clusterName, ok := obj.Meta.GetLabels()[utils.ClusterNameLabel]
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: clusterName, Namespace: obj.Meta.GetNamespace()}}}
+			}),
+		}, predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				if e.MetaOld == nil || e.MetaNew == nil {
+					return false
+				}
+				if oldPod, ok := e.ObjectOld.(*corev1.Pod); ok {
+					if newPod, ok := e.ObjectNew.(*corev1.Pod); ok && !isPodUnschedulable(oldPod) && isPodUnschedulable(newPod) {
+						return true
+					}
+				}
+				return !reflect.DeepEqual(getOperatorAnnotations(e.MetaOld.GetAnnotations()), getOperatorAnnotations(e.MetaNew.GetAnnotations()))
+			},
+		})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the UDF module ConfigMaps and requeue the AerospikeClusters using them
+	err = c.Watch(
+		&source.Kind{Type: &corev1.ConfigMap{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+				return getUDFConfigMapRequests(mgr.GetClient(), obj)
+			}),
+		}, predicate.Funcs{
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// operatorAnnotationPrefix is the prefix of the annotations that drive the operator.
+const operatorAnnotationPrefix = "aerospike.com/"
+
+// getOperatorAnnotations returns the annotations with the operator annotation prefix.
+func getOperatorAnnotations(annotations map[string]string) map[string]string {
+	operatorAnnotations := map[string]string{}
+	for key, value := range annotations {
+		if strings.HasPrefix(key, operatorAnnotationPrefix) {
+			operatorAnnotations[key] = value
+		}
+	}
+	return operatorAnnotations
+}
+
 var pkglog = log.New(log.Ctx{"module": "controller_aerospikecluster"})
 
 // blank assignment to verify that ReconcileAerospikeCluster implements reconcile.Reconciler
@@ -116,6 +196,8 @@ type ReconcileAerospikeCluster struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *k8sRuntime.Scheme
+	// kubeConfig is used to exec into the pods
+	kubeConfig *rest.Config
 }
 
 // RackState contains the rack configuration and rack size.
@@ -188,24 +270,162 @@ func (r *ReconcileAerospikeCluster) Reconcile(request reconcile.Request) (reconc
 		}
 	}
 
+	if err := r.reconcilePausedCondition(aeroCluster); err != nil {
+		logger.Error("Failed to update paused condition", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Only publish the plan of the pending actions if paused or a plan is requested
+	if isPlanMode(aeroCluster) {
+		if err := r.reconcilePlan(aeroCluster); err != nil {
+			logger.Error("Failed to reconcile plan", log.Ctx{"err": err})
+			return reconcile.Result{}, err
+		}
+		// Keep the observed status up to date
+		if err := r.reconcileServerVersion(aeroCluster); err != nil {
+			logger.Error("Failed to verify Aerospike server version", log.Ctx{"err": err})
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: pausedRequeueInterval}, nil
+	}
+	if err := r.clearPlan(aeroCluster); err != nil {
+		logger.Error("Failed to clear plan", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Pod operations requested by annotation
+	if err := r.reconcilePodOperations(aeroCluster); err != nil {
+		logger.Error("Failed to run pod operations", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Pods stuck on lost nodes block the rest of the reconcile, recover them first.
+	// Pods waiting for the recovery timeout are requeued after the rest of the reconcile.
+	lostNodeWait, err := r.reconcileLostNodeRecovery(aeroCluster)
+	if err != nil {
+		logger.Error("Failed to recover pods stuck on lost nodes", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Disruptive actions wait for a maintenance window
+	if res, err := r.reconcileMaintenanceWindow(aeroCluster); err != nil || res != nil {
+		if err != nil {
+			logger.Error("Failed to reconcile maintenance window", log.Ctx{"err": err})
+			return reconcile.Result{}, err
+		}
+		return *res, nil
+	}
+
+	// Versions that cannot be skipped are upgraded to one at a time
+	isHop, err := r.applyUpgradePlan(aeroCluster)
+	if err != nil {
+		logger.Error("Failed to apply upgrade plan", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Hold the upgrade if the upgrade strategy needs to wait
+	if res, err := r.reconcileUpgradeStrategy(aeroCluster); err != nil || res != nil {
+		if err != nil {
+			logger.Error("Failed to reconcile upgrade strategy", log.Ctx{"err": err})
+			return reconcile.Result{}, err
+		}
+		return *res, nil
+	}
+
 	// Reconcile all racks
 	if err := r.ReconcileRacks(aeroCluster); err != nil {
 		return reconcile.Result{}, err
 	}
 
+	if err := r.completeUpgrade(aeroCluster); err != nil {
+		logger.Error("Failed to update upgrade status", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	if !isHop {
+		if err := r.completeUpgradePlan(aeroCluster); err != nil {
+			logger.Error("Failed to update upgrade plan", log.Ctx{"err": err})
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Evacuate the listed pods and the pods on the evacuated nodes
+	if err := r.reconcileEvacuation(aeroCluster); err != nil {
+		logger.Error("Failed to evacuate pods", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Move the pods off the nodes not matching their rack placement
+	if err := r.reconcileRackRelocation(aeroCluster); err != nil {
+		logger.Error("Failed to relocate pods", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Set the roster of strong consistency namespaces to the current nodes
+	if err := r.reconcileRoster(aeroCluster); err != nil {
+		logger.Error("Failed to reconcile roster", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
 	// Setup access control.
 	if err := r.reconcileAccessControl(aeroCluster); err != nil {
 		logger.Error("Failed to reconcile access control", log.Ctx{"err": err})
 		return reconcile.Result{}, err
 	}
 
+	// Create, update and drop the secondary indexes in the spec.
+	indexBuilding, err := r.reconcileSecondaryIndexes(aeroCluster)
+	if err != nil {
+		logger.Error("Failed to reconcile secondary indexes", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Register the UDF modules of the spec ConfigMaps.
+	if err := r.reconcileUDFModules(aeroCluster); err != nil {
+		logger.Error("Failed to reconcile UDF modules", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// Verify the server version running on pods.
+	if err := r.reconcileServerVersion(aeroCluster); err != nil {
+		logger.Error("Failed to verify Aerospike server version", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	// The pods got ready, clear a timeout of an earlier reconcile
+	if err := r.clearTimeoutCondition(aeroCluster); err != nil {
+		logger.Error("Failed to clear timeout condition", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
 	// Update the AerospikeCluster status.
 	if err := r.updateStatus(aeroCluster); err != nil {
 		logger.Error("Failed to update AerospikeCluster status", log.Ctx{"err": err})
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	if isHop {
+		// Status changes do not trigger a reconcile, requeue for the next step of the upgrade plan
+		logger.Info("Upgraded to intermediate version", log.Ctx{"image": aeroCluster.Spec.Image})
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Check the nodes for config drift, requeues for the next check
+	res, err := r.reconcileConfigDrift(aeroCluster)
+	if err != nil {
+		logger.Error("Failed to check config drift", log.Ctx{"err": err})
+		return reconcile.Result{}, err
+	}
+
+	if indexBuilding && (res.RequeueAfter == 0 || res.RequeueAfter > secondaryIndexRequeueInterval) {
+		// Status changes do not trigger a reconcile, requeue to update the index build progress
+		res.RequeueAfter = secondaryIndexRequeueInterval
+	}
+	if lostNodeWait != 0 && (res.RequeueAfter == 0 || res.RequeueAfter > lostNodeWait) {
+		// Recover the pods stuck on lost nodes once their timeout passes
+		res.RequeueAfter = lostNodeWait
+	}
+	return res, nil
 }
 
 func (r *ReconcileAerospikeCluster) getResourceVersion(stsName types.NamespacedName) string {
@@ -222,6 +442,12 @@ func (r *ReconcileAerospikeCluster) ReconcileRacks(aeroCluster *aerospikev1alpha
 	var scaledDownRackSTSList []appsv1.StatefulSet
 	var scaledDownRackList []RackState
 
+	// The pods restarted with the loadBalancer network type need their Service
+	if err := r.reconcileServices(aeroCluster); err != nil {
+		logger.Error("Failed to reconcile services", log.Ctx{"err": err})
+		return err
+	}
+
 	rackStateList := getNewRackStateList(aeroCluster)
 	for _, state := range rackStateList {
 		found := &appsv1.StatefulSet{}
@@ -265,6 +491,12 @@ func (r *ReconcileAerospikeCluster) ReconcileRacks(aeroCluster *aerospikev1alpha
 		}
 	}
 
+	// Update the budgets for the added and removed racks
+	if err := r.reconcilePodDisruptionBudgets(aeroCluster); err != nil {
+		logger.Error("Failed to reconcile PodDisruptionBudgets", log.Ctx{"err": err})
+		return err
+	}
+
 	return nil
 }
 
@@ -337,60 +569,71 @@ func (r *ReconcileAerospikeCluster) deleteRacks(aeroCluster *aerospikev1alpha1.A
 }
 
 func (r *ReconcileAerospikeCluster) needsRollingRestart(aeroCluster *aerospikev1alpha1.AerospikeCluster, rackState RackState, logger log.Logger) bool {
-	// Never set to false later on.
-	// True value not optimized so that we check and log all changes that have hapened.
-	needsRollingRestart := false
+	// All reasons are logged so that we know all changes that have hapened.
+	reasons := getRollingRestartReasons(aeroCluster, rackState)
+	for _, reason := range reasons {
+		logger.Info("Need rolling restart", log.Ctx{"reason": reason})
+	}
+	return len(reasons) != 0
+}
+
+// getRollingRestartReasons returns the spec changes that need a rolling restart of the rack.
+func getRollingRestartReasons(aeroCluster *aerospikev1alpha1.AerospikeCluster, rackState RackState) []string {
+	var reasons []string
 
 	// Aerospike config nil in status indicates that AerospikeCluster object is created but status is not successfully updated even once
-	if aeroCluster.Status.AerospikeConfig != nil {
-		// Check if rack specific spec has changed.
-		for _, statusRack := range aeroCluster.Status.RackConfig.Racks {
-			if rackState.Rack.ID == statusRack.ID {
-				if !reflect.DeepEqual(rackState.Rack.AerospikeConfig, statusRack.AerospikeConfig) {
-					needsRollingRestart = true
-					logger.Info("Rack AerospikeConfig changed. Need rolling restart", log.Ctx{"oldRackConfig": statusRack, "newRackConfig": rackState.Rack})
-				}
+	if aeroCluster.Status.AerospikeConfig == nil {
+		return nil
+	}
 
-				if statusRack.Storage.NeedsRollingRestart(rackState.Rack.Storage) {
-					needsRollingRestart = true
-					logger.Info("Rack storage changed. Need rolling restart")
-				}
-				break
+	// Check if rack specific spec has changed.
+	for _, statusRack := range aeroCluster.Status.RackConfig.Racks {
+		if rackState.Rack.ID == statusRack.ID {
+			if !reflect.DeepEqual(rackState.Rack.AerospikeConfig, statusRack.AerospikeConfig) {
+				reasons = append(reasons, "Rack AerospikeConfig changed")
 			}
-		}
 
-		// Check is global spec has changed.
+			if statusRack.Storage.NeedsRollingRestart(rackState.Rack.Storage) {
+				reasons = append(reasons, "Rack storage changed")
+			}
 
-		// Network policy.
-		if !reflect.DeepEqual(aeroCluster.Spec.AerospikeNetworkPolicy, aeroCluster.Status.AerospikeNetworkPolicy) {
-			needsRollingRestart = true
-			logger.Info("Aerospike network policy changed. Need rolling restart")
+			// The rack image is applied by the upgrade
+			rackPodSpec, statusRackPodSpec := rackState.Rack.PodSpec, statusRack.PodSpec
+			rackPodSpec.Image, statusRackPodSpec.Image = "", ""
+			if !reflect.DeepEqual(rackPodSpec, statusRackPodSpec) {
+				reasons = append(reasons, "Rack pod spec changed")
+			}
+			break
 		}
+	}
 
-		// Pod spec.
-		if !reflect.DeepEqual(aeroCluster.Spec.PodSpec, aeroCluster.Status.PodSpec) {
-			needsRollingRestart = true
-			logger.Info("Aerospike pod spec changed. Need rolling restart")
-		}
+	// Check is global spec has changed.
 
-		// Secrets
-		if !reflect.DeepEqual(aeroCluster.Spec.AerospikeConfigSecret, aeroCluster.Status.AerospikeConfigSecret) {
-			// Secret (having config info like tls, feature-key-file) is updated, need rolling restart
-			needsRollingRestart = true
-			logger.Info("Aerospike config secret changed. Need rolling restart")
-		}
+	// Network policy.
+	if !reflect.DeepEqual(aeroCluster.Spec.AerospikeNetworkPolicy, aeroCluster.Status.AerospikeNetworkPolicy) {
+		reasons = append(reasons, "Aerospike network policy changed")
+	}
 
-		// Resources.
-		if aeroCluster.Spec.Resources != nil || aeroCluster.Status.Resources != nil {
-			if isClusterResourceUpdated(aeroCluster) {
-				// Resource are updated, need rolling restart
-				needsRollingRestart = true
-				logger.Info("Resources changed. Need rolling restart")
-			}
+	// Pod spec.
+	if !reflect.DeepEqual(aeroCluster.Spec.PodSpec, aeroCluster.Status.PodSpec) {
+		reasons = append(reasons, "Aerospike pod spec changed")
+	}
+
+	// Secrets
+	if !reflect.DeepEqual(aeroCluster.Spec.AerospikeConfigSecret, aeroCluster.Status.AerospikeConfigSecret) {
+		// Secret (having config info like tls, feature-key-file) is updated, need rolling restart
+		reasons = append(reasons, "Aerospike config secret changed")
+	}
+
+	// Resources.
+	if aeroCluster.Spec.Resources != nil || aeroCluster.Status.Resources != nil {
+		if isClusterResourceUpdated(aeroCluster) {
+			// Resource are updated, need rolling restart
+			reasons = append(reasons, "Resources changed")
 		}
 	}
 
-	return needsRollingRestart
+	return reasons
 }
 
 func (r *ReconcileAerospikeCluster) reconcileRack(aeroCluster *aerospikev1alpha1.AerospikeCluster, found *appsv1.StatefulSet, rackState RackState) error {
@@ -426,9 +669,15 @@ func (r *ReconcileAerospikeCluster) reconcileRack(aeroCluster *aerospikev1alpha1
 	}
 
 	if upgradeNeeded {
-		found, err = r.upgradeRack(aeroCluster, found, aeroCluster.Spec.Image, rackState)
+		found, err = r.upgradeRack(aeroCluster, found, aeroCluster.Spec.Image, rackState, nil)
 		if err != nil {
 			logger.Error("Failed to update StatefulSet image", log.Ctx{"err": err})
+			if canRollbackUpgrade(aeroCluster) {
+				if _, rollbackErr := r.rollbackUpgrade(aeroCluster, err.Error()); rollbackErr != nil {
+					return rollbackErr
+				}
+				return fmt.Errorf("Upgrade failed and rolled back: %v", err)
+			}
 			return err
 		}
 	} else if needsRollingRestart {
@@ -509,7 +758,7 @@ func (r *ReconcileAerospikeCluster) scaleUpRack(aeroCluster *aerospikev1alpha1.A
 		return found, fmt.Errorf("Failed scale up pre-check: %v", err)
 	}
 
-	if aeroCluster.Spec.MultiPodPerHost {
+	if needsServiceForPod(aeroCluster) {
 		// Create services for each pod
 		for _, podName := range newPodNames {
 			if err := r.createServiceForPod(aeroCluster, podName, aeroCluster.Namespace); err != nil {
@@ -523,7 +772,7 @@ func (r *ReconcileAerospikeCluster) scaleUpRack(aeroCluster *aerospikev1alpha1.A
 		return found, fmt.Errorf("Failed to update StatefulSet pods: %v", err)
 	}
 
-	if err := r.waitForStatefulSetToBeReady(found); err != nil {
+	if err := r.waitForStatefulSetToBeReady(aeroCluster, found); err != nil {
 		return found, fmt.Errorf("Failed to wait for statefulset to be ready: %v", err)
 	}
 
@@ -531,7 +780,8 @@ func (r *ReconcileAerospikeCluster) scaleUpRack(aeroCluster *aerospikev1alpha1.A
 	return r.getStatefulSet(aeroCluster, rackState)
 }
 
-func (r *ReconcileAerospikeCluster) upgradeRack(aeroCluster *aerospikev1alpha1.AerospikeCluster, found *appsv1.StatefulSet, desiredImage string, rackState RackState) (*appsv1.StatefulSet, error) {
+// upgradeRack upgrades the rack pods to the desired images. If podNames is not nil only the given pods are upgraded.
+func (r *ReconcileAerospikeCluster) upgradeRack(aeroCluster *aerospikev1alpha1.AerospikeCluster, found *appsv1.StatefulSet, desiredImage string, rackState RackState, podNames []string) (*appsv1.StatefulSet, error) {
 	logger := pkglog.New(log.Ctx{"AerospikeClusterSTS": getNamespacedNameForStatefulSet(aeroCluster, rackState.Rack.ID)})
 
 	// List the pods for this aeroCluster's statefulset
@@ -546,7 +796,7 @@ func (r *ReconcileAerospikeCluster) upgradeRack(aeroCluster *aerospikev1alpha1.A
 	// Repeat the above process.
 	needsUpdate := false
 	for i, container := range found.Spec.Template.Spec.Containers {
-		desiredImage, err := utils.GetDesiredImage(aeroCluster, container.Name)
+		desiredImage, err := utils.GetDesiredImage(aeroCluster, rackState.Rack.ID, container.Name)
 
 		if err != nil {
 			// Maybe a deleted sidecar.
@@ -568,11 +818,15 @@ func (r *ReconcileAerospikeCluster) upgradeRack(aeroCluster *aerospikev1alpha1.A
 	}
 
 	for _, p := range podList {
+		if podNames != nil && !utils.ContainsString(podNames, p.Name) {
+			continue
+		}
+
 		needsDeletion := false
 		// Also check if statefulSet is in stable condition
 		// Check for all containers. Spec.Containers doesn't include init container
 		for _, ps := range p.Spec.Containers {
-			desiredImage, err := utils.GetDesiredImage(aeroCluster, ps.Name)
+			desiredImage, err := utils.GetDesiredImage(aeroCluster, rackState.Rack.ID, ps.Name)
 
 			if err != nil {
 				// Maybe a deleted sidecar.
@@ -666,7 +920,12 @@ func (r *ReconcileAerospikeCluster) rollingRestartRack(aeroCluster *aerospikev1a
 
 	updateStatefulSetPodSpec(aeroCluster, found)
 
-	updateStatefulSetAerospikeServerContainerResources(aeroCluster, found)
+	ls := utils.LabelsForAerospikeClusterRack(aeroCluster.Name, rackState.Rack.ID)
+	updateStatefulSetRackPodSpec(found, ls, rackState)
+
+	updateStatefulSetAffinity(aeroCluster, found, ls, rackState)
+
+	updateStatefulSetAerospikeServerContainerResources(aeroCluster, found, rackState)
 
 	updateStatefulSetSecretInfo(aeroCluster, found)
 
@@ -731,37 +990,10 @@ func (r *ReconcileAerospikeCluster) rollingRestartRack(aeroCluster *aerospikev1a
 		logger.Debug("Pod deleted", log.Ctx{"podName": pFound.Name})
 
 		// Wait for pod to come up
-		var started bool
-		for i := 0; i < 20; i++ {
-			logger.Debug("Waiting for pod to be ready after delete", log.Ctx{"podName": pFound.Name, "status": pFound.Status.Phase, "DeletionTimestamp": pFound.DeletionTimestamp})
-
-			pod := &corev1.Pod{}
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: pFound.Name, Namespace: pFound.Namespace}, pod)
-			if err != nil {
-				logger.Error("Failed to get pod", log.Ctx{"err": err})
-				time.Sleep(time.Second * 5)
-				continue
-			}
-
-			if err := utils.CheckPodFailed(pod); err != nil {
-				return found, err
-			}
-
-			if !utils.IsPodRunningAndReady(pod) {
-				logger.Debug("Waiting for pod to be ready", log.Ctx{"podName": pod.Name, "status": pod.Status.Phase, "DeletionTimestamp": pod.DeletionTimestamp})
-				time.Sleep(time.Second * 5)
-				continue
-			}
-
-			logger.Info("Pod is restarted", log.Ctx{"podName": pod.Name})
-			started = true
-			break
+		if err := r.waitForPodReady(aeroCluster, types.NamespacedName{Name: pFound.Name, Namespace: pFound.Namespace}); err != nil {
+			return found, err
 		}
-
-		if !started {
-			logger.Error("Pos is not running or ready. Pod might also be terminating", log.Ctx{"podName": pod.Name, "status": pod.Status.Phase, "DeletionTimestamp": pod.DeletionTimestamp})
-		}
-
+		logger.Info("Pod is restarted", log.Ctx{"podName": pFound.Name})
 	}
 	// return a fresh copy
 	return r.getStatefulSet(aeroCluster, rackState)
@@ -794,8 +1026,13 @@ func (r *ReconcileAerospikeCluster) scaleDownRack(aeroCluster *aerospikev1alpha1
 
 		removedPods = append(removedPods, pod)
 
+		// Removing a node with unavailable partitions can cause dead partitions in strong consistency namespaces
+		if err := r.checkSCPartitionsAvailable(aeroCluster); err != nil {
+			return found, err
+		}
+
 		// Ignore safe stop check on pod not in running state.
-		if utils.IsPodRunningAndReady(pod) {
+		if utils.IsPodRunning(pod) {
 			if err := r.waitForNodeSafeStopReady(aeroCluster, pod); err != nil {
 				// The pod is running and is unsafe to terminate.
 				return found, err
@@ -810,7 +1047,7 @@ func (r *ReconcileAerospikeCluster) scaleDownRack(aeroCluster *aerospikev1alpha1
 		}
 
 		// Wait for pods to get terminated
-		if err := r.waitForStatefulSetToBeReady(found); err != nil {
+		if err := r.waitForStatefulSetToBeReady(aeroCluster, found); err != nil {
 			return found, fmt.Errorf("Failed to wait for statefulset to be ready: %v", err)
 		}
 
@@ -827,6 +1064,11 @@ func (r *ReconcileAerospikeCluster) scaleDownRack(aeroCluster *aerospikev1alpha1
 		}
 
 		logger.Info("Pod Removed", log.Ctx{"podName": podName})
+
+		// Drop the removed node from the roster before removing the next one
+		if err := r.reconcileRoster(aeroCluster); err != nil {
+			return found, err
+		}
 	}
 
 	newPodList, err := r.getRackPodList(aeroCluster, rackState.Rack.ID)
@@ -849,7 +1091,7 @@ func (r *ReconcileAerospikeCluster) scaleDownRack(aeroCluster *aerospikev1alpha1
 		r.alumniReset(aeroCluster, &np)
 	}
 
-	if aeroCluster.Spec.MultiPodPerHost {
+	if needsServiceForPod(aeroCluster) {
 		// Remove service for pod
 		for _, rp := range removedPods {
 			// TODO: make it more roboust, what if it fails
@@ -866,6 +1108,11 @@ func (r *ReconcileAerospikeCluster) scaleDownRack(aeroCluster *aerospikev1alpha1
 func (r *ReconcileAerospikeCluster) reconcileAccessControl(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
 	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
 
+	if !utils.IsEnterprise(&aeroCluster.Spec) {
+		logger.Debug("Access control is not supported in community edition, skipping")
+		return nil
+	}
+
 	enabled, err := utils.IsSecurityEnabled(aeroCluster.Spec.AerospikeConfig)
 	if err != nil {
 		return fmt.Errorf("Failed to get cluster security status: %v", err)
@@ -876,9 +1123,23 @@ func (r *ReconcileAerospikeCluster) reconcileAccessControl(aeroCluster *aerospik
 	}
 
 	// Create client
+	aeroClient, err := r.newAerospikeClient(aeroCluster)
+	if err != nil {
+		return err
+	}
+
+	defer aeroClient.Close()
+
+	pp := r.getPasswordProvider(aeroCluster)
+	err = accessControl.ReconcileAccessControl(&aeroCluster.Spec, &aeroCluster.Status.AerospikeClusterSpec, aeroClient, pp, logger)
+	return err
+}
+
+// newAerospikeClient creates a client connected to all the cluster nodes. The caller should close it.
+func (r *ReconcileAerospikeCluster) newAerospikeClient(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*as.Client, error) {
 	conns, err := r.newAllHostConn(aeroCluster)
 	if err != nil {
-		return fmt.Errorf("Failed to get host info: %v", err)
+		return nil, fmt.Errorf("Failed to get host info: %v", err)
 	}
 	var hosts []*as.Host
 	for _, conn := range conns {
@@ -893,14 +1154,100 @@ func (r *ReconcileAerospikeCluster) reconcileAccessControl(aeroCluster *aerospik
 	aeroClient, err := as.NewClientWithPolicyAndHost(clientPolicy, hosts...)
 
 	if err != nil {
-		return fmt.Errorf("Failed to create aerospike cluster client: %v", err)
+		return nil, fmt.Errorf("Failed to create aerospike cluster client: %v", err)
 	}
+	return aeroClient, nil
+}
 
-	defer aeroClient.Close()
+// reconcileServerVersion verifies the Aerospike server version running on the cluster pods, records it in the pod status
+// and sets the VersionMismatch condition if some pods are not running the desired version.
+func (r *ReconcileAerospikeCluster) reconcileServerVersion(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
 
-	pp := r.getPasswordProvider(aeroCluster)
-	err = accessControl.ReconcileAccessControl(&aeroCluster.Spec, &aeroCluster.Status.AerospikeClusterSpec, aeroClient, pp, logger)
-	return err
+	desiredVersion, err := utils.GetAerospikeVersion(&aeroCluster.Spec)
+	if err != nil {
+		return err
+	}
+
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	patches := []jsonpatch.JsonPatchOperation{}
+	var mismatchedPods []string
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+
+		podStatus, ok := aeroCluster.Status.Pods[pod.Name]
+		if !ok || !utils.IsPodRunningAndReady(pod) {
+			// Pod status not yet added by the pod, verify in next reconcile.
+			continue
+		}
+
+		version, err := r.getAerospikeServerVersionFromPod(aeroCluster, pod)
+		if err != nil {
+			logger.Warn("Failed to get Aerospike server version from pod", log.Ctx{"podName": pod.Name, "err": err})
+			continue
+		}
+
+		if podStatus.AerospikeVersion != version {
+			patches = append(patches, jsonpatch.JsonPatchOperation{
+				Operation: "add",
+				Path:      "/status/pods/" + pod.Name + "/aerospikeVersion",
+				Value:     version,
+			})
+		}
+
+		podDesiredVersion, err := getPodDesiredVersion(aeroCluster, pod, desiredVersion)
+		if err != nil {
+			return err
+		}
+		if !utils.IsVersionMatch(podDesiredVersion, version) {
+			mismatchedPods = append(mismatchedPods, pod.Name+":"+version)
+		}
+	}
+
+	if len(patches) != 0 {
+		jsonpatchJSON, err := json.Marshal(patches)
+		if err != nil {
+			return fmt.Errorf("Error marshalling json patch: %v", err)
+		}
+
+		constantPatch := client.ConstantPatch(types.JSONPatchType, jsonpatchJSON)
+		if err := r.client.Status().Patch(context.TODO(), aeroCluster, constantPatch, client.FieldOwner(patchFieldOwner)); err != nil {
+			return fmt.Errorf("Error updating pod version status: %v", err)
+		}
+	}
+
+	condition := aerospikev1alpha1.AerospikeClusterCondition{
+		Type:   aerospikev1alpha1.AerospikeClusterVersionMismatch,
+		Status: corev1.ConditionFalse,
+		Reason: "VersionMatched",
+	}
+	if len(mismatchedPods) != 0 {
+		logger.Warn("Aerospike server version running on pods is not the desired version", log.Ctx{"version": desiredVersion, "pods": mismatchedPods})
+
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "VersionMismatch"
+		condition.Message = fmt.Sprintf("Desired version %s, pods running other versions %v", desiredVersion, mismatchedPods)
+	}
+
+	return r.setStatusCondition(aeroCluster, condition)
+}
+
+// getPodDesiredVersion returns the version of the rack image of the pod, the cluster version for the racks without an image.
+func getPodDesiredVersion(aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod, clusterVersion string) (string, error) {
+	rackID, err := getRackIDFromPodName(pod.Name)
+	if err != nil {
+		return "", err
+	}
+	image := utils.GetRackImage(aeroCluster, *rackID)
+	if image == aeroCluster.Spec.Image {
+		return clusterVersion, nil
+	}
+	return utils.GetAerospikeVersion(&aerospikev1alpha1.AerospikeClusterSpec{Image: image})
 }
 
 func (r *ReconcileAerospikeCluster) updateStatus(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
@@ -1034,6 +1381,38 @@ func (r *ReconcileAerospikeCluster) patchStatus(oldAeroCluster, newAeroCluster *
 	return lib.DeepCopy(&oldAeroCluster.Status, &newAeroCluster.Status)
 }
 
+// patchStatusFields applies mutate to the latest status of the cluster and patches the changes.
+// Pod status is not patched here.
+func (r *ReconcileAerospikeCluster) patchStatusFields(aeroCluster *aerospikev1alpha1.AerospikeCluster, mutate func(status *aerospikev1alpha1.AerospikeClusterStatus)) error {
+	// Get the object twice instead of copying, deep copy shares maps and slices.
+	oldAeroCluster := &aerospikev1alpha1.AerospikeCluster{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: aeroCluster.Name, Namespace: aeroCluster.Namespace}, oldAeroCluster); err != nil {
+		return err
+	}
+
+	newAeroCluster := &aerospikev1alpha1.AerospikeCluster{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: aeroCluster.Name, Namespace: aeroCluster.Namespace}, newAeroCluster); err != nil {
+		return err
+	}
+
+	mutate(&newAeroCluster.Status)
+
+	if err := r.patchStatus(oldAeroCluster, newAeroCluster); err != nil {
+		return err
+	}
+
+	// Keep the in memory object in sync.
+	mutate(&aeroCluster.Status)
+	return nil
+}
+
+// setStatusCondition adds or updates the condition in the cluster status.
+func (r *ReconcileAerospikeCluster) setStatusCondition(aeroCluster *aerospikev1alpha1.AerospikeCluster, condition aerospikev1alpha1.AerospikeClusterCondition) error {
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.Conditions = setClusterCondition(status.Conditions, condition)
+	})
+}
+
 // removePodStatus removes podNames from the cluster's pod status.
 // Assumes the pods are not running so that the no concurrent update to this pod status is possbile.
 func (r *ReconcileAerospikeCluster) removePodStatus(aeroCluster *aerospikev1alpha1.AerospikeCluster, podNames []string) error {
@@ -0,0 +1,277 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configmap"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// driftIgnoredParams are set per pod by the init container or reported differently by the server.
+var driftIgnoredParams = map[string]bool{
+	"name":    true,
+	"node-id": true,
+	"rack-id": true,
+}
+
+// reconcileConfigDrift checks the running config of the nodes against the rendered config once the drift check interval has elapsed.
+// It returns the result to requeue the next check.
+func (r *ReconcileAerospikeCluster) reconcileConfigDrift(aeroCluster *aerospikev1alpha1.AerospikeCluster) (reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	policy := aeroCluster.Spec.ConfigDriftPolicy
+	if policy == nil || policy.IntervalSeconds <= 0 {
+		if aeroCluster.Status.ConfigDrift == nil {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+			status.ConfigDrift = nil
+		})
+	}
+
+	interval := time.Duration(policy.IntervalSeconds) * time.Second
+	if drift := aeroCluster.Status.ConfigDrift; drift != nil {
+		if elapsed := time.Since(drift.LastCheckTime.Time); elapsed < interval {
+			return reconcile.Result{RequeueAfter: interval - elapsed}, nil
+		}
+	}
+
+	podDrifts, err := r.detectConfigDrift(aeroCluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(podDrifts) != 0 {
+		logger.Warn("Config drift detected", log.Ctx{"pods": podDrifts})
+		if err := r.remediateConfigDrift(aeroCluster, podDrifts); err != nil {
+			logger.Error("Failed to remediate config drift", log.Ctx{"err": err})
+		}
+	}
+
+	if err := r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.ConfigDrift = &aerospikev1alpha1.AerospikeConfigDriftStatus{
+			LastCheckTime: metav1.Now(),
+			Pods:          podDrifts,
+		}
+	}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("Failed to update config drift status: %v", err)
+	}
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// detectConfigDrift compares the service and namespace config of the running and ready pods with the config rendered for their rack.
+func (r *ReconcileAerospikeCluster) detectConfigDrift(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]aerospikev1alpha1.AerospikePodConfigDrift, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	version, err := utils.GetAerospikeVersion(&aeroCluster.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var podDrifts []aerospikev1alpha1.AerospikePodConfigDrift
+	for _, rack := range aeroCluster.Spec.RackConfig.Racks {
+		confData, err := configmap.CreateConfigMapData(aeroCluster, rack)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to render config of rack %d: %v", rack.ID, err)
+		}
+		expected, err := configschema.ParseConfFile(confData[configmap.AerospikeTemplateConfFileName], version)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse rendered config of rack %d: %v", rack.ID, err)
+		}
+
+		podList, err := r.getOrderedRackPodList(aeroCluster, rack.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list pods: %v", err)
+		}
+		for _, pod := range podList {
+			if utils.IsTerminating(&pod) || !utils.IsPodRunningAndReady(&pod) {
+				continue
+			}
+
+			diffs, err := r.getPodConfigDiffs(aeroCluster, &pod, expected)
+			if err != nil {
+				// Pod may be restarting, check it in the next round
+				logger.Warn("Failed to get running config of pod", log.Ctx{"podName": pod.Name, "err": err})
+				continue
+			}
+			if len(diffs) != 0 {
+				podDrifts = append(podDrifts, aerospikev1alpha1.AerospikePodConfigDrift{PodName: pod.Name, Diffs: diffs})
+			}
+		}
+	}
+	return podDrifts, nil
+}
+
+// getPodConfigDiffs returns the service and namespace params with a running value different from the expected config.
+func (r *ReconcileAerospikeCluster) getPodConfigDiffs(aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod, expected map[string]interface{}) ([]aerospikev1alpha1.AerospikeConfigDiff, error) {
+	asConn, err := r.newAsConn(aeroCluster, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceCmd := "get-config:context=service"
+	cmds := []string{serviceCmd}
+
+	namespaces, _ := expected["namespaces"].([]interface{})
+	for _, ns := range namespaces {
+		if nsConf, ok := ns.(map[string]interface{}); ok {
+			cmds = append(cmds, fmt.Sprintf("get-config:context=namespace;id=%v", nsConf["name"]))
+		}
+	}
+
+	res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), asConn, cmds...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get config from %s: %v", pod.Name, err)
+	}
+
+	service, _ := expected["service"].(map[string]interface{})
+	diffs, err := compareRunningConfig("service", "", service, res[serviceCmd])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, ns := range namespaces {
+		nsConf, ok := ns.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nsDiffs, err := compareRunningConfig("namespace", fmt.Sprintf("%v", nsConf["name"]), nsConf, res[cmds[i+1]])
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, nsDiffs...)
+	}
+	return diffs, nil
+}
+
+// compareRunningConfig compares the scalar params of the expected context config with the get-config output.
+// Params not reported by the server are skipped.
+func compareRunningConfig(contextName, id string, expected map[string]interface{}, running string) ([]aerospikev1alpha1.AerospikeConfigDiff, error) {
+	actual, err := parseInfoIntoMap(running, ";", "=")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %s config: %v", contextName, err)
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []aerospikev1alpha1.AerospikeConfigDiff
+	for _, name := range names {
+		if driftIgnoredParams[name] {
+			continue
+		}
+
+		switch expected[name].(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+
+		actualValue, ok := actual[name]
+		if !ok {
+			continue
+		}
+
+		expectedStr := fmt.Sprintf("%v", expected[name])
+		actualStr := fmt.Sprintf("%v", actualValue)
+		if !strings.EqualFold(expectedStr, actualStr) {
+			diffs = append(diffs, aerospikev1alpha1.AerospikeConfigDiff{Context: contextName, ID: id, Name: name, Expected: expectedStr, Actual: actualStr})
+		}
+	}
+	return diffs, nil
+}
+
+// remediateConfigDrift re-applies the drifted dynamic params or restarts a drifted pod as per the config drift policy.
+func (r *ReconcileAerospikeCluster) remediateConfigDrift(aeroCluster *aerospikev1alpha1.AerospikeCluster, podDrifts []aerospikev1alpha1.AerospikePodConfigDrift) error {
+	switch aeroCluster.Spec.ConfigDriftPolicy.Remediation {
+	case aerospikev1alpha1.AerospikeConfigDriftRemediationSetConfig:
+		for _, podDrift := range podDrifts {
+			if err := r.setDriftedConfig(aeroCluster, podDrift); err != nil {
+				return err
+			}
+		}
+
+	case aerospikev1alpha1.AerospikeConfigDriftRemediationRestart:
+		// Restarts are disruptive, wait for a maintenance window
+		if open, _, err := utils.GetMaintenanceWindowState(aeroCluster.Spec.MaintenanceWindows, time.Now()); err != nil || !open {
+			return err
+		}
+		// Restart one pod per check to keep the cluster available
+		return r.restartDriftedPod(aeroCluster, podDrifts[0].PodName)
+	}
+	return nil
+}
+
+// setDriftedConfig sets the expected value of the drifted dynamic params on the pod. Static params need a restart and are left as is.
+func (r *ReconcileAerospikeCluster) setDriftedConfig(aeroCluster *aerospikev1alpha1.AerospikeCluster, podDrift aerospikev1alpha1.AerospikePodConfigDrift) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster), "podName": podDrift.PodName})
+
+	version, err := utils.GetAerospikeVersion(&aeroCluster.Spec)
+	if err != nil {
+		return err
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: podDrift.PodName, Namespace: aeroCluster.Namespace}, pod); err != nil {
+		return fmt.Errorf("Failed to get pod %s: %v", podDrift.PodName, err)
+	}
+	asConn, err := r.newAsConn(aeroCluster, pod)
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range podDrift.Diffs {
+		if !configschema.IsDynamicParam(version, diff.Context, diff.Name) {
+			logger.Info("Drifted param is not dynamic, skipping set-config", log.Ctx{"context": diff.Context, "name": diff.Name})
+			continue
+		}
+
+		cmd := fmt.Sprintf("set-config:context=%s;%s=%s", diff.Context, diff.Name, diff.Expected)
+		if diff.ID != "" {
+			cmd = fmt.Sprintf("set-config:context=%s;id=%s;%s=%s", diff.Context, diff.ID, diff.Name, diff.Expected)
+		}
+
+		res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), asConn, cmd)
+		if err != nil {
+			return fmt.Errorf("Failed to run %s on pod %s: %v", cmd, podDrift.PodName, err)
+		}
+		if !strings.EqualFold(strings.TrimSpace(res[cmd]), "ok") {
+			return fmt.Errorf("Failed to run %s on pod %s: %s", cmd, podDrift.PodName, res[cmd])
+		}
+		logger.Info("Re-applied drifted config", log.Ctx{"cmd": cmd})
+	}
+	return nil
+}
+
+// restartDriftedPod deletes the pod once it is safe to stop, the pod is recreated with the rendered config.
+func (r *ReconcileAerospikeCluster) restartDriftedPod(aeroCluster *aerospikev1alpha1.AerospikeCluster, podName string) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster), "podName": podName})
+
+	pod := &corev1.Pod{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: aeroCluster.Namespace}, pod); err != nil {
+		return fmt.Errorf("Failed to get pod %s: %v", podName, err)
+	}
+
+	if err := r.waitForNodeSafeStopReady(aeroCluster, pod); err != nil {
+		return err
+	}
+
+	logger.Info("Restarting pod with drifted config")
+	if err := r.client.Delete(context.TODO(), pod); err != nil {
+		return fmt.Errorf("Failed to delete pod %s: %v", podName, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+)
+
+func TestCompareRunningConfig(t *testing.T) {
+	driftTests := []struct {
+		name     string
+		expected map[string]interface{}
+		running  string
+		want     []aerospikev1alpha1.AerospikeConfigDiff
+	}{
+		{"no drift", map[string]interface{}{"proto-fd-max": 15000, "migrate-threads": 1}, "proto-fd-max=15000;migrate-threads=1", nil},
+		{"drift", map[string]interface{}{"proto-fd-max": 15000, "migrate-threads": 1}, "proto-fd-max=20000;migrate-threads=1",
+			[]aerospikev1alpha1.AerospikeConfigDiff{{Context: "service", Name: "proto-fd-max", Expected: "15000", Actual: "20000"}}},
+		{"drifts sorted by name", map[string]interface{}{"proto-fd-max": 15000, "migrate-threads": 1}, "proto-fd-max=20000;migrate-threads=2",
+			[]aerospikev1alpha1.AerospikeConfigDiff{
+				{Context: "service", Name: "migrate-threads", Expected: "1", Actual: "2"},
+				{Context: "service", Name: "proto-fd-max", Expected: "15000", Actual: "20000"},
+			}},
+		{"ignored params", map[string]interface{}{"node-id": "a1", "rack-id": 1, "name": "test"}, "node-id=bb9;rack-id=2;name=other", nil},
+		{"nested values", map[string]interface{}{"storage-engine": map[string]interface{}{"type": "memory"}, "address": []interface{}{"any"}},
+			"storage-engine=device;address=10.0.0.1", nil},
+		{"params not reported", map[string]interface{}{"feature-key-file": "/etc/aerospike/features.conf"}, "proto-fd-max=15000", nil},
+		{"case insensitive", map[string]interface{}{"enable-benchmarks-read": true, "conflict-resolution-policy": "Generation"},
+			"enable-benchmarks-read=TRUE;conflict-resolution-policy=generation", nil},
+		{"no running config", map[string]interface{}{"proto-fd-max": 15000}, "", nil},
+	}
+
+	for _, tt := range driftTests {
+		got, err := compareRunningConfig("service", "", tt.expected, tt.running)
+		if err != nil {
+			t.Errorf("compareRunningConfig(%s) error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("compareRunningConfig(%s) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := compareRunningConfig("service", "", map[string]interface{}{"proto-fd-max": 15000}, "proto-fd-max"); err == nil {
+		t.Errorf("compareRunningConfig(malformed) error = nil, want error")
+	}
+}
@@ -66,7 +66,7 @@ func (r *ReconcileAerospikeCluster) createStatefulSet(aeroCluster *aerospikev1al
 
 	logger.Info("Create statefulset for AerospikeCluster", log.Ctx{"size": replicas})
 
-	if aeroCluster.Spec.MultiPodPerHost {
+	if needsServiceForPod(aeroCluster) {
 		// Create services for all statefulset pods
 		for i := 0; i < rackState.Size; i++ {
 			// Statefulset name created from cr name
@@ -88,11 +88,11 @@ func (r *ReconcileAerospikeCluster) createStatefulSet(aeroCluster *aerospikev1al
 		newEnvVar("MY_HOST_IP", "status.hostIP"),
 		newEnvVarStatic("MY_POD_TLS_NAME", getServiceTLSName(aeroCluster)),
 		newEnvVarStatic("MY_POD_CLUSTER_NAME", aeroCluster.Name),
-		newEnvVarStatic("MY_POD_IMAGE", aeroCluster.Spec.Image),
+		newEnvVarStatic("MY_POD_IMAGE", utils.GetRackImage(aeroCluster, rackState.Rack.ID)),
 	}
 
 	if rackState.Rack.ID != utils.DefaultRackID {
-		envVarList = append(envVarList, newEnvVarStatic("MY_POD_RACK_ID", string(rackState.Rack.ID)))
+		envVarList = append(envVarList, newEnvVarStatic("MY_POD_RACK_ID", strconv.Itoa(rackState.Rack.ID)))
 	}
 
 	if name := getServiceTLSName(aeroCluster); name != "" {
@@ -122,10 +122,9 @@ func (r *ReconcileAerospikeCluster) createStatefulSet(aeroCluster *aerospikev1al
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: aeroClusterServiceAccountName,
-					//TerminationGracePeriodSeconds: &int64(30),
 					InitContainers: []corev1.Container{{
 						Name:  "aerospike-init",
-						Image: "aerospike/aerospike-kubernetes-init:0.0.12",
+						Image: initContainerImage,
 						// Change to PullAlways for image testing.
 						ImagePullPolicy: corev1.PullIfNotPresent,
 						VolumeMounts: []corev1.VolumeMount{
@@ -154,7 +153,7 @@ func (r *ReconcileAerospikeCluster) createStatefulSet(aeroCluster *aerospikev1al
 
 					Containers: []corev1.Container{{
 						Name:            "aerospike-server",
-						Image:           aeroCluster.Spec.Image,
+						Image:           utils.GetRackImage(aeroCluster, rackState.Rack.ID),
 						ImagePullPolicy: corev1.PullIfNotPresent,
 						Ports:           ports,
 						Env:             envVarList,
@@ -192,7 +191,9 @@ func (r *ReconcileAerospikeCluster) createStatefulSet(aeroCluster *aerospikev1al
 
 	updateStatefulSetPodSpec(aeroCluster, st)
 
-	updateStatefulSetAerospikeServerContainerResources(aeroCluster, st)
+	updateStatefulSetRackPodSpec(st, ls, rackState)
+
+	updateStatefulSetAerospikeServerContainerResources(aeroCluster, st, rackState)
 	// TODO: Add validation. device, file, both should not exist in same storage class
 	if err := updateStatefulSetStorage(aeroCluster, st, rackState); err != nil {
 		return nil, err
@@ -211,7 +212,7 @@ func (r *ReconcileAerospikeCluster) createStatefulSet(aeroCluster *aerospikev1al
 	}
 	logger.Info("Created new StatefulSet", log.Ctx{"StatefulSet.Namespace": st.Namespace, "StatefulSet.Name": st.Name})
 
-	if err := r.waitForStatefulSetToBeReady(st); err != nil {
+	if err := r.waitForStatefulSetToBeReady(aeroCluster, st); err != nil {
 		return st, fmt.Errorf("Failed to wait for statefulset to be ready: %v", err)
 	}
 
@@ -229,51 +230,17 @@ func (r *ReconcileAerospikeCluster) deleteStatefulSet(aeroCluster *aerospikev1al
 	return r.client.Delete(context.TODO(), st)
 }
 
-func (r *ReconcileAerospikeCluster) waitForStatefulSetToBeReady(st *appsv1.StatefulSet) error {
+func (r *ReconcileAerospikeCluster) waitForStatefulSetToBeReady(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet) error {
 	logger := pkglog.New(log.Ctx{"AerospikeCluster statefulset": types.NamespacedName{Name: st.Name, Namespace: st.Namespace}})
 
-	const podStatusMaxRetry = 18
-	const podStatusRetryInterval = time.Second * 10
-
-	logger.Info("Waiting for statefulset to be ready", log.Ctx{"WaitTimePerPod": podStatusRetryInterval * time.Duration(podStatusMaxRetry)})
+	logger.Info("Waiting for statefulset to be ready")
 
 	var podIndex int32
 	for podIndex = 0; podIndex < *st.Spec.Replicas; podIndex++ {
 		podName := getStatefulSetPodName(st.Name, podIndex)
-
-		var isReady bool
-		pod := &corev1.Pod{}
-
-		// Wait for 10 sec to pod to get started
-		for i := 0; i < 5; i++ {
-			time.Sleep(time.Second * 2)
-			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: st.Namespace}, pod); err == nil {
-				break
-			}
-		}
-		// Wait for pod to get ready
-		for i := 0; i < podStatusMaxRetry; i++ {
-			time.Sleep(podStatusRetryInterval)
-
-			logger.Debug("Check statefulSet pod running and ready", log.Ctx{"pod": podName})
-
-			pod := &corev1.Pod{}
-			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: st.Namespace}, pod); err != nil {
-				return fmt.Errorf("Failed to get statefulSet pod %s: %v", podName, err)
-			}
-			if err := utils.CheckPodFailed(pod); err != nil {
-				return fmt.Errorf("StatefulSet pod %s failed: %v", podName, err)
-			}
-			if utils.IsPodRunningAndReady(pod) {
-				isReady = true
-				logger.Info("Pod is running and ready", log.Ctx{"pod": podName})
-				break
-			}
-		}
-		if !isReady {
-			statusErr := fmt.Errorf("StatefulSet pod is not ready. Status: %v", pod.Status.Conditions)
-			logger.Error("Statefulset Not ready", log.Ctx{"err": statusErr})
-			return statusErr
+		if err := r.waitForPodReady(aeroCluster, types.NamespacedName{Name: podName, Namespace: st.Namespace}); err != nil {
+			logger.Error("Statefulset Not ready", log.Ctx{"err": err})
+			return err
 		}
 	}
 
@@ -477,8 +444,21 @@ func (r *ReconcileAerospikeCluster) createServiceForPod(aeroCluster *aerospikev1
 		return nil
 	}
 
-	// NodePort will be allocated automatically
-	service = &corev1.Service{
+	service = newServiceForPod(aeroCluster, pName, pNamespace)
+	// Set AerospikeCluster instance as the owner and controller.
+	// It is created before Pod, so Pod cannot be the owner
+	controllerutil.SetControllerReference(aeroCluster, service, r.scheme)
+
+	if err := r.client.Create(context.TODO(), service, createOption); err != nil {
+		return fmt.Errorf("Failed to create new service for pod %s: %v", pName, err)
+	}
+	return nil
+}
+
+// newServiceForPod returns the Service of the pod. It is a NodePort Service mapping the ports of pods sharing a host,
+// or a LoadBalancer Service if the pod service is enabled. The node ports are allocated automatically for both.
+func newServiceForPod(aeroCluster *aerospikev1alpha1.AerospikeCluster, pName, pNamespace string) *corev1.Service {
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pName,
 			Namespace: pNamespace,
@@ -488,35 +468,25 @@ func (r *ReconcileAerospikeCluster) createServiceForPod(aeroCluster *aerospikev1
 			Selector: map[string]string{
 				"statefulset.kubernetes.io/pod-name": pName,
 			},
-			Ports: []corev1.ServicePort{
-				{
-					Name: "info",
-					Port: utils.ServicePort,
-				},
-			},
+			Ports:                 getClientServicePorts(aeroCluster),
 			ExternalTrafficPolicy: "Local",
 		},
 	}
-	if name := getServiceTLSName(aeroCluster); name != "" {
-		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
-			Name: "tls",
-			Port: utils.ServiceTLSPort,
-		})
-	}
-	// Set AerospikeCluster instance as the owner and controller.
-	// It is created before Pod, so Pod cannot be the owner
-	controllerutil.SetControllerReference(aeroCluster, service, r.scheme)
-
-	if err := r.client.Create(context.TODO(), service, createOption); err != nil {
-		return fmt.Errorf("Failed to create new service for pod %s: %v", pName, err)
+	if podService := getPodServiceSpec(aeroCluster); podService != nil {
+		service.Annotations = getServiceAnnotations(podService.Annotations)
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+		service.Spec.LoadBalancerSourceRanges = podService.LoadBalancerSourceRanges
 	}
-	return nil
+	return service
 }
 
 func (r *ReconcileAerospikeCluster) deleteServiceForPod(pName, pNamespace string) error {
 	service := &corev1.Service{}
 
 	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pName, Namespace: pNamespace}, service); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
 		return fmt.Errorf("Failed to get service for pod %s: %v", pName, err)
 	}
 	if err := r.client.Delete(context.TODO(), service); err != nil {
@@ -897,42 +867,36 @@ func updateStatefulSetStorage(aeroCluster *aerospikev1alpha1.AerospikeCluster, s
 }
 
 func updateStatefulSetAffinity(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet, labels map[string]string, rackState RackState) {
-	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
-
-	affinity := &corev1.Affinity{}
-
-	// only enable in production, so it can be used in 1 node clusters while debugging (minikube)
-	if !aeroCluster.Spec.MultiPodPerHost {
-		logger.Info("Adding pod affinity rules for statefulset pod")
-		antiAffinity := &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-				{
-					LabelSelector: &metav1.LabelSelector{
-						MatchLabels: labels,
-					},
-					TopologyKey: "kubernetes.io/hostname",
-				},
-			},
+	affinity := &corev1.Affinity{
+		PodAntiAffinity: getPodAntiAffinity(aeroCluster, labels),
+	}
+
+	// The zone and region are matched with each of the topology label pairs in a separate term, the terms are ORed
+	var topologyExpressions [][]corev1.NodeSelectorRequirement
+	if rackState.Rack.Zone != "" || rackState.Rack.Region != "" {
+		for _, keys := range getTopologyKeys(aeroCluster) {
+			var expressions []corev1.NodeSelectorRequirement
+			if rackState.Rack.Zone != "" {
+				expressions = append(expressions, corev1.NodeSelectorRequirement{
+					Key:      keys.zone,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{rackState.Rack.Zone},
+				})
+			}
+			if rackState.Rack.Region != "" {
+				expressions = append(expressions, corev1.NodeSelectorRequirement{
+					Key:      keys.region,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{rackState.Rack.Region},
+				})
+			}
+			topologyExpressions = append(topologyExpressions, expressions)
 		}
-		affinity.PodAntiAffinity = antiAffinity
+	} else {
+		topologyExpressions = append(topologyExpressions, nil)
 	}
 
 	var matchExpressions []corev1.NodeSelectorRequirement
-
-	if rackState.Rack.Zone != "" {
-		matchExpressions = append(matchExpressions, corev1.NodeSelectorRequirement{
-			Key:      "failure-domain.beta.kubernetes.io/zone",
-			Operator: corev1.NodeSelectorOpIn,
-			Values:   []string{rackState.Rack.Zone},
-		})
-	}
-	if rackState.Rack.Region != "" {
-		matchExpressions = append(matchExpressions, corev1.NodeSelectorRequirement{
-			Key:      "failure-domain.beta.kubernetes.io/region",
-			Operator: corev1.NodeSelectorOpIn,
-			Values:   []string{rackState.Rack.Region},
-		})
-	}
 	if rackState.Rack.RackLabel != "" {
 		matchExpressions = append(matchExpressions, corev1.NodeSelectorRequirement{
 			Key:      "aerospike.com/rack-label",
@@ -949,22 +913,241 @@ func updateStatefulSetAffinity(aeroCluster *aerospikev1alpha1.AerospikeCluster,
 		})
 	}
 
-	if len(matchExpressions) != 0 {
-		nodeAffinity := &corev1.NodeAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-				NodeSelectorTerms: []corev1.NodeSelectorTerm{
-					{
-						MatchExpressions: matchExpressions,
-					},
-				},
-			},
+	for _, requirement := range rackState.Rack.NodeSelectorRequirements {
+		matchExpressions = append(matchExpressions, *requirement.DeepCopy())
+	}
+
+	// Keep the pods off the evacuated nodes. The hostname label can differ from the node name, so match the node name field
+	var matchFields []corev1.NodeSelectorRequirement
+	if nodes := getEvacuatedNodes(aeroCluster); len(nodes) != 0 {
+		matchFields = append(matchFields, corev1.NodeSelectorRequirement{
+			Key:      "metadata.name",
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   nodes,
+		})
+	}
+
+	if len(topologyExpressions[0]) != 0 || len(matchExpressions) != 0 || len(matchFields) != 0 {
+		var terms []corev1.NodeSelectorTerm
+		for _, expressions := range topologyExpressions {
+			terms = append(terms, corev1.NodeSelectorTerm{
+				MatchExpressions: append(append([]corev1.NodeSelectorRequirement{}, expressions...), matchExpressions...),
+				MatchFields:      append([]corev1.NodeSelectorRequirement{}, matchFields...),
+			})
+		}
+		affinity.NodeAffinity = &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
 		}
-		affinity.NodeAffinity = nodeAffinity
 	}
 
+	mergeRackAffinity(affinity, rackState.Rack.PodSpec.Affinity)
+
 	st.Spec.Template.Spec.Affinity = affinity
 }
 
+// getPodAntiAffinity returns the pod anti-affinity of the pod placement spec, or nil if there are no rules.
+func getPodAntiAffinity(aeroCluster *aerospikev1alpha1.AerospikeCluster, labels map[string]string) *corev1.PodAntiAffinity {
+	placement := aeroCluster.Spec.PodPlacement
+	if placement == nil {
+		placement = &aerospikev1alpha1.AerospikePodPlacementSpec{}
+	}
+	antiAffinity := &corev1.PodAntiAffinity{}
+
+	hostAntiAffinity := placement.HostAntiAffinity
+	if hostAntiAffinity == "" {
+		// only enable in production, so it can be used in 1 node clusters while debugging (minikube)
+		hostAntiAffinity = aerospikev1alpha1.AerospikeAntiAffinityRequired
+		if aeroCluster.Spec.MultiPodPerHost {
+			hostAntiAffinity = aerospikev1alpha1.AerospikeAntiAffinityNone
+		}
+	}
+	addPodAntiAffinityTerm(antiAffinity, hostAntiAffinity, corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: labels,
+		},
+		TopologyKey: "kubernetes.io/hostname",
+	})
+
+	// The scheduler prefers the domains with no pods of the rack, it does not bound the skew once every domain has one
+	for _, topologyKey := range placement.PreferredAntiAffinityTopologyKeys {
+		addPodAntiAffinityTerm(antiAffinity, aerospikev1alpha1.AerospikeAntiAffinityPreferred, corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			TopologyKey: topologyKey,
+		})
+	}
+
+	if clusterAntiAffinity := placement.ClusterAntiAffinity; clusterAntiAffinity != nil {
+		clusterRequirement := metav1.LabelSelectorRequirement{
+			Key:      utils.ClusterNameLabel,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   []string{aeroCluster.Name},
+		}
+		if len(clusterAntiAffinity.ClusterNames) != 0 {
+			clusterRequirement.Operator = metav1.LabelSelectorOpIn
+			clusterRequirement.Values = clusterAntiAffinity.ClusterNames
+		}
+
+		mode := clusterAntiAffinity.Mode
+		if mode == "" {
+			mode = aerospikev1alpha1.AerospikeAntiAffinityRequired
+		}
+		topologyKey := clusterAntiAffinity.TopologyKey
+		if topologyKey == "" {
+			topologyKey = "kubernetes.io/hostname"
+		}
+		addPodAntiAffinityTerm(antiAffinity, mode, corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels:      map[string]string{"app": "aerospike-cluster"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{clusterRequirement},
+			},
+			Namespaces:  clusterAntiAffinity.Namespaces,
+			TopologyKey: topologyKey,
+		})
+	}
+
+	if len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) == 0 && len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) == 0 {
+		return nil
+	}
+	return antiAffinity
+}
+
+// addPodAntiAffinityTerm adds the term as a required or preferred rule depending on the mode.
+func addPodAntiAffinityTerm(antiAffinity *corev1.PodAntiAffinity, mode aerospikev1alpha1.AerospikeAntiAffinityMode, term corev1.PodAffinityTerm) {
+	switch mode {
+	case aerospikev1alpha1.AerospikeAntiAffinityRequired:
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	case aerospikev1alpha1.AerospikeAntiAffinityPreferred:
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          100,
+			PodAffinityTerm: term,
+		})
+	}
+}
+
+const (
+	// defaultZoneTopologyKey is the well known node label with the zone of the node.
+	defaultZoneTopologyKey = "topology.kubernetes.io/zone"
+
+	// defaultRegionTopologyKey is the well known node label with the region of the node.
+	defaultRegionTopologyKey = "topology.kubernetes.io/region"
+
+	// legacyZoneTopologyKey is the deprecated zone label, the only one set on nodes before Kubernetes 1.17.
+	legacyZoneTopologyKey = "failure-domain.beta.kubernetes.io/zone"
+
+	// legacyRegionTopologyKey is the deprecated region label, the only one set on nodes before Kubernetes 1.17.
+	legacyRegionTopologyKey = "failure-domain.beta.kubernetes.io/region"
+)
+
+// topologyKeys are the node labels matched with the rack zone and region.
+type topologyKeys struct {
+	zone   string
+	region string
+}
+
+// getTopologyKeys returns the node labels matched with the rack zone and region. The default labels are followed by
+// the legacy labels, for the nodes which do not have the default labels.
+func getTopologyKeys(aeroCluster *aerospikev1alpha1.AerospikeCluster) []topologyKeys {
+	keys := topologyKeys{zone: getZoneTopologyKey(aeroCluster), region: getRegionTopologyKey(aeroCluster)}
+
+	legacyKeys := keys
+	if legacyKeys.zone == defaultZoneTopologyKey {
+		legacyKeys.zone = legacyZoneTopologyKey
+	}
+	if legacyKeys.region == defaultRegionTopologyKey {
+		legacyKeys.region = legacyRegionTopologyKey
+	}
+	if legacyKeys == keys {
+		return []topologyKeys{keys}
+	}
+	return []topologyKeys{keys, legacyKeys}
+}
+
+// getZoneTopologyKey returns the node label matched with the rack zone.
+func getZoneTopologyKey(aeroCluster *aerospikev1alpha1.AerospikeCluster) string {
+	if key := aeroCluster.Spec.RackConfig.ZoneTopologyKey; key != "" {
+		return key
+	}
+	return defaultZoneTopologyKey
+}
+
+// getRegionTopologyKey returns the node label matched with the rack region.
+func getRegionTopologyKey(aeroCluster *aerospikev1alpha1.AerospikeCluster) string {
+	if key := aeroCluster.Spec.RackConfig.RegionTopologyKey; key != "" {
+		return key
+	}
+	return defaultRegionTopologyKey
+}
+
+// mergeRackAffinity merges the affinity of the rack pod spec into the affinity set by the operator.
+// Each rack node selector term is ANDed with each operator node selector term, the other rules are added.
+func mergeRackAffinity(affinity, rackAffinity *corev1.Affinity) {
+	if rackAffinity == nil {
+		return
+	}
+	rackAffinity = rackAffinity.DeepCopy()
+
+	if rackNodeAffinity := rackAffinity.NodeAffinity; rackNodeAffinity != nil {
+		if affinity.NodeAffinity == nil {
+			affinity.NodeAffinity = &corev1.NodeAffinity{}
+		}
+		nodeAffinity := affinity.NodeAffinity
+
+		if rackNodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+			rackTerms := rackNodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+				// Terms are ORed, a node has to match a rack term and an operator term
+				var terms []corev1.NodeSelectorTerm
+				for _, rackTerm := range rackTerms {
+					for _, term := range nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+						terms = append(terms, corev1.NodeSelectorTerm{
+							MatchExpressions: append(append([]corev1.NodeSelectorRequirement{}, rackTerm.MatchExpressions...), term.MatchExpressions...),
+							MatchFields:      append(append([]corev1.NodeSelectorRequirement{}, rackTerm.MatchFields...), term.MatchFields...),
+						})
+					}
+				}
+				rackTerms = terms
+			}
+			nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{NodeSelectorTerms: rackTerms}
+		}
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, rackNodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+
+	if rackAffinity.PodAffinity != nil {
+		affinity.PodAffinity = rackAffinity.PodAffinity
+	}
+
+	if rackAntiAffinity := rackAffinity.PodAntiAffinity; rackAntiAffinity != nil {
+		if affinity.PodAntiAffinity == nil {
+			affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		antiAffinity := affinity.PodAntiAffinity
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, rackAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, rackAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+}
+
+// updateStatefulSetRackPodSpec sets the tolerations, node selector, labels and annotations of the rack pod spec on the StatefulSet pods.
+// Called while creating new cluster and also during rolling restart.
+func updateStatefulSetRackPodSpec(st *appsv1.StatefulSet, labels map[string]string, rackState RackState) {
+	podSpec := rackState.Rack.PodSpec.DeepCopy()
+
+	st.Spec.Template.Spec.Tolerations = podSpec.Tolerations
+	st.Spec.Template.Spec.NodeSelector = podSpec.NodeSelector
+
+	// The operator labels select the rack pods, they cannot be overridden
+	podLabels := map[string]string{}
+	for key, value := range podSpec.Labels {
+		podLabels[key] = value
+	}
+	for key, value := range labels {
+		podLabels[key] = value
+	}
+	st.Spec.Template.Labels = podLabels
+	st.Spec.Template.Annotations = podSpec.Annotations
+}
+
 // Called while creating new cluster and also during rolling restart
 func updateStatefulSetSecretInfo(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet) {
 	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
@@ -1046,6 +1229,212 @@ func updateStatefulSetPodSpec(aeroCluster *aerospikev1alpha1.AerospikeCluster, s
 		}
 	}
 	st.Spec.Template.Spec.Containers = st.Spec.Template.Spec.Containers[:j]
+
+	updateStatefulSetTermination(aeroCluster, st)
+
+	updateStatefulSetProbes(aeroCluster, st)
+
+	updateStatefulSetAdminCredentials(aeroCluster, st)
+}
+
+const (
+	// initContainerImage is the image of the init container. It installs the config into the config volume.
+	initContainerImage = "aerospike/aerospike-kubernetes-init:0.0.12"
+	// probeInitContainerImage is the image of the init container if the probes are enabled. It installs the probe as well.
+	// It is built from docker/init with docker/init/build.sh.
+	probeInitContainerImage = "aerospike/aerospike-kubernetes-init:0.0.13"
+
+	// probePath is the path of the probe binary installed into the config volume by the init container.
+	probePath = "/etc/aerospike/aerospike-probe"
+
+	// adminUserEnvVar and adminPasswordEnvVar are the credentials of the preStop hook and the probes on security enabled clusters.
+	adminUserEnvVar     = "AEROSPIKE_ADMIN_USER"
+	adminPasswordEnvVar = "AEROSPIKE_ADMIN_PASSWORD"
+)
+
+// preStopQuiesceScript quiesces the node and reclusters through all pods of the headless service, so that the clients move
+// their traffic to the other nodes, then waits for the traffic to drain. Errors are ignored, the pod is stopped anyway.
+// The nodes are reached on the TLS port with the files of the service tls config if TLS is enabled.
+const preStopQuiesceScript = `AUTH=""
+if [ -n "$` + adminPasswordEnvVar + `" ]; then AUTH="-U $` + adminUserEnvVar + ` -P $` + adminPasswordEnvVar + `"; fi
+TLS=""
+if [ "true" = "$MY_POD_TLS_ENABLED" ]; then TLS="--tls-enable --tls-name $MY_POD_TLS_NAME -p %[1]d%[2]s"; fi
+if asinfo $AUTH $TLS -v 'quiesce:' | grep -q ok; then
+  for host in $(getent ahostsv4 "$MY_POD_CLUSTER_NAME.$MY_POD_NAMESPACE.svc" | awk '{print $1}' | sort -u); do
+    asinfo $AUTH $TLS -h "$host" -v 'recluster:' > /dev/null 2>&1
+  done
+  sleep %[3]d
+fi
+exit 0`
+
+// serviceTLSFileOptions maps the tls config files to the asinfo options. The probe flags are the config keys.
+var serviceTLSFileOptions = []struct {
+	confKey string
+	option  string
+}{
+	{"ca-file", "--tls-cafile"},
+	{"ca-path", "--tls-capath"},
+	{"cert-file", "--tls-certfile"},
+	{"key-file", "--tls-keyfile"},
+}
+
+// getPreStopTLSOptions returns the asinfo options of the CA and of the client certificate of the service tls config.
+// The server certificate is the client certificate, as for the operator.
+func getPreStopTLSOptions(aeroCluster *aerospikev1alpha1.AerospikeCluster) string {
+	tlsConf := getServiceTLSConfig(aeroCluster)
+	options := ""
+	for _, fileOption := range serviceTLSFileOptions {
+		if path, ok := tlsConf[fileOption.confKey].(string); ok && path != "" {
+			options += " " + fileOption.option + " " + path
+		}
+	}
+	return options
+}
+
+// updateStatefulSetTermination sets the termination grace period and the preStop quiesce hook of the Aerospike server container.
+func updateStatefulSetTermination(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet) {
+	podSpec := aeroCluster.Spec.PodSpec
+	st.Spec.Template.Spec.TerminationGracePeriodSeconds = podSpec.TerminationGracePeriodSeconds
+
+	container := &st.Spec.Template.Spec.Containers[0]
+	container.Lifecycle = nil
+	if isPreStopDisabled(aeroCluster) {
+		return
+	}
+
+	drainSeconds := int32(utils.DefaultPreStopDrainSeconds)
+	if podSpec.PreStop != nil && podSpec.PreStop.DrainSeconds > 0 {
+		drainSeconds = podSpec.PreStop.DrainSeconds
+	}
+	container.Lifecycle = &corev1.Lifecycle{
+		PreStop: &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", fmt.Sprintf(preStopQuiesceScript, utils.ServiceTLSPort, getPreStopTLSOptions(aeroCluster), drainSeconds)},
+			},
+		},
+	}
+}
+
+// updateStatefulSetProbes sets the readiness and liveness probes of the Aerospike server container if they are enabled.
+// The init container image is updated as well, only the probe init container image installs the probe.
+func updateStatefulSetProbes(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet) {
+	st.Spec.Template.Spec.InitContainers[0].Image = initContainerImage
+
+	container := &st.Spec.Template.Spec.Containers[0]
+	container.ReadinessProbe = nil
+	container.LivenessProbe = nil
+	if !isProbesEnabled(aeroCluster) {
+		return
+	}
+	st.Spec.Template.Spec.InitContainers[0].Image = probeInitContainerImage
+
+	args := []string{fmt.Sprintf("-port=%d", utils.ServicePort)}
+	if tlsName := getServiceTLSName(aeroCluster); tlsName != "" {
+		// The files of the tls config are mounted into the server container, the probe runs in it
+		args = []string{fmt.Sprintf("-port=%d", utils.ServiceTLSPort), "-tls-name=" + tlsName}
+		tlsConf := getServiceTLSConfig(aeroCluster)
+		for _, fileOption := range serviceTLSFileOptions {
+			if path, ok := tlsConf[fileOption.confKey].(string); ok && path != "" {
+				args = append(args, fmt.Sprintf("-tls-%s=%s", fileOption.confKey, path))
+			}
+		}
+	}
+
+	probes := aeroCluster.Spec.PodSpec.Probes
+	if probes == nil {
+		probes = &aerospikev1alpha1.AerospikeProbesSpec{}
+	}
+	container.ReadinessProbe = newProbe("readiness", args, probes.Readiness, aerospikev1alpha1.AerospikeProbeSpec{
+		PeriodSeconds:    10,
+		TimeoutSeconds:   5,
+		FailureThreshold: 3,
+	})
+	container.LivenessProbe = newProbe("liveness", args, probes.Liveness, aerospikev1alpha1.AerospikeProbeSpec{
+		PeriodSeconds:    30,
+		TimeoutSeconds:   10,
+		FailureThreshold: 5,
+	})
+}
+
+// newProbe returns an exec probe running the probe binary in the given mode. The unset fields of the spec get the defaults.
+func newProbe(mode string, args []string, spec *aerospikev1alpha1.AerospikeProbeSpec, defaults aerospikev1alpha1.AerospikeProbeSpec) *corev1.Probe {
+	if spec != nil {
+		if spec.InitialDelaySeconds > 0 {
+			defaults.InitialDelaySeconds = spec.InitialDelaySeconds
+		}
+		if spec.PeriodSeconds > 0 {
+			defaults.PeriodSeconds = spec.PeriodSeconds
+		}
+		if spec.TimeoutSeconds > 0 {
+			defaults.TimeoutSeconds = spec.TimeoutSeconds
+		}
+		if spec.FailureThreshold > 0 {
+			defaults.FailureThreshold = spec.FailureThreshold
+		}
+	}
+
+	command := append([]string{probePath, "-mode=" + mode, fmt.Sprintf("-timeout=%ds", defaults.TimeoutSeconds)}, args...)
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: command,
+			},
+		},
+		InitialDelaySeconds: defaults.InitialDelaySeconds,
+		PeriodSeconds:       defaults.PeriodSeconds,
+		TimeoutSeconds:      defaults.TimeoutSeconds,
+		FailureThreshold:    defaults.FailureThreshold,
+	}
+}
+
+// updateStatefulSetAdminCredentials sets the admin credentials used by the preStop hook and the probes on security enabled clusters.
+func updateStatefulSetAdminCredentials(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet) {
+	container := &st.Spec.Template.Spec.Containers[0]
+
+	var env []corev1.EnvVar
+	for _, envVar := range container.Env {
+		if envVar.Name != adminUserEnvVar && envVar.Name != adminPasswordEnvVar {
+			env = append(env, envVar)
+		}
+	}
+	container.Env = env
+
+	if isPreStopDisabled(aeroCluster) && !isProbesEnabled(aeroCluster) {
+		return
+	}
+
+	if secretName := getAdminSecretName(aeroCluster); secretName != "" {
+		container.Env = append(container.Env, newEnvVarStatic(adminUserEnvVar, "admin"), corev1.EnvVar{
+			Name: adminPasswordEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  "password",
+				},
+			},
+		})
+	}
+}
+
+func isPreStopDisabled(aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	return aeroCluster.Spec.PodSpec.PreStop != nil && aeroCluster.Spec.PodSpec.PreStop.Disabled
+}
+
+func isProbesEnabled(aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	return aeroCluster.Spec.PodSpec.Probes != nil && aeroCluster.Spec.PodSpec.Probes.Enabled
+}
+
+// getAdminSecretName returns the password secret of the admin user, or an empty string if security is not enabled.
+func getAdminSecretName(aeroCluster *aerospikev1alpha1.AerospikeCluster) string {
+	if enabled, err := utils.IsSecurityEnabled(aeroCluster.Spec.AerospikeConfig); err != nil || !enabled || aeroCluster.Spec.AerospikeAccessControl == nil {
+		return ""
+	}
+	for _, user := range aeroCluster.Spec.AerospikeAccessControl.Users {
+		if user.Name == "admin" {
+			return user.SecretName
+		}
+	}
+	return ""
 }
 
 // Called while creating new cluster and also during rolling restart.
@@ -1150,7 +1539,12 @@ func removeConfigMapVolumeMount(containers []corev1.Container, volume corev1.Vol
 	}
 }
 
-func updateStatefulSetAerospikeServerContainerResources(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet) {
+func updateStatefulSetAerospikeServerContainerResources(aeroCluster *aerospikev1alpha1.AerospikeCluster, st *appsv1.StatefulSet, rackState RackState) {
+	// Rack resources are already merged with the global resources
+	if resources := rackState.Rack.PodSpec.Resources; resources != nil {
+		st.Spec.Template.Spec.Containers[0].Resources = *resources
+		return
+	}
 	st.Spec.Template.Spec.Containers[0].Resources = *aeroCluster.Spec.Resources
 	// st.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
 	// 	Requests: corev1.ResourceList{
@@ -1346,25 +1740,8 @@ func getNamespacedNameForConfigMap(aeroCluster *aerospikev1alpha1.AerospikeClust
 	}
 }
 
-func splitRacks(nodes, racks int) []int {
-	nodesPerRack, extraNodes := nodes/racks, nodes%racks
-
-	// Distributing nodes in given racks
-	var topology []int
-
-	for rackIdx := 0; rackIdx < racks; rackIdx++ {
-		nodesForThisRack := nodesPerRack
-		if rackIdx < extraNodes {
-			nodesForThisRack++
-		}
-		topology = append(topology, nodesForThisRack)
-	}
-
-	return topology
-}
-
 func getNewRackStateList(aeroCluster *aerospikev1alpha1.AerospikeCluster) []RackState {
-	topology := splitRacks(int(aeroCluster.Spec.Size), len(aeroCluster.Spec.RackConfig.Racks))
+	topology := utils.SplitRacks(int(aeroCluster.Spec.Size), aeroCluster.Spec.RackConfig.Racks)
 	var rackStateList []RackState
 	for idx, rack := range aeroCluster.Spec.RackConfig.Racks {
 		rackStateList = append(rackStateList, RackState{
@@ -1393,3 +1770,41 @@ func getHashForPVCPath(path string) (string, error) {
 	res := hash.Sum(digest)
 	return hex.EncodeToString(res), nil
 }
+
+// setClusterCondition returns conditions with condition added or updated.
+// LastTransitionTime is updated only if the condition status changes.
+func setClusterCondition(conditions []aerospikev1alpha1.AerospikeClusterCondition, condition aerospikev1alpha1.AerospikeClusterCondition) []aerospikev1alpha1.AerospikeClusterCondition {
+	newConditions := make([]aerospikev1alpha1.AerospikeClusterCondition, 0, len(conditions)+1)
+	found := false
+
+	for _, c := range conditions {
+		if c.Type != condition.Type {
+			newConditions = append(newConditions, c)
+			continue
+		}
+
+		found = true
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		} else {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		newConditions = append(newConditions, condition)
+	}
+
+	if !found {
+		condition.LastTransitionTime = metav1.Now()
+		newConditions = append(newConditions, condition)
+	}
+	return newConditions
+}
+
+// getClusterCondition returns the condition of conditionType, nil if not found.
+func getClusterCondition(conditions []aerospikev1alpha1.AerospikeClusterCondition, conditionType aerospikev1alpha1.AerospikeClusterConditionType) *aerospikev1alpha1.AerospikeClusterCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
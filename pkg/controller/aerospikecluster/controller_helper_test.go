@@ -0,0 +1,329 @@
+package aerospikecluster
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateStatefulSetAffinity(t *testing.T) {
+	aeroCluster := newPlanTestCluster()
+	aeroCluster.Spec.Evacuation = &aerospikev1alpha1.AerospikeEvacuationSpec{Nodes: []string{"node-1"}}
+
+	rack := aeroCluster.Spec.RackConfig.Racks[0]
+	rack.Zone = "us-east-1a"
+	rack.PodSpec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}}},
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}}},
+		}},
+	}}
+
+	st := &appsv1.StatefulSet{}
+	updateStatefulSetAffinity(aeroCluster, st, map[string]string{"app": "aerospike-cluster"}, RackState{Rack: rack, Size: 2})
+
+	// The zone is matched with the default or the legacy label, each rack term is ANDed with both operator terms
+	zone := corev1.NodeSelectorRequirement{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}}
+	legacyZone := corev1.NodeSelectorRequirement{Key: "failure-domain.beta.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}}
+	poolA := corev1.NodeSelectorRequirement{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}
+	poolB := corev1.NodeSelectorRequirement{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}
+	evacuated := corev1.NodeSelectorRequirement{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node-1"}}
+	want := []corev1.NodeSelectorTerm{
+		{MatchExpressions: []corev1.NodeSelectorRequirement{poolA, zone}, MatchFields: []corev1.NodeSelectorRequirement{evacuated}},
+		{MatchExpressions: []corev1.NodeSelectorRequirement{poolA, legacyZone}, MatchFields: []corev1.NodeSelectorRequirement{evacuated}},
+		{MatchExpressions: []corev1.NodeSelectorRequirement{poolB, zone}, MatchFields: []corev1.NodeSelectorRequirement{evacuated}},
+		{MatchExpressions: []corev1.NodeSelectorRequirement{poolB, legacyZone}, MatchFields: []corev1.NodeSelectorRequirement{evacuated}},
+	}
+	if got := st.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms; !reflect.DeepEqual(got, want) {
+		t.Errorf("updateStatefulSetAffinity() node selector terms = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateStatefulSetAffinityTopologyKeys(t *testing.T) {
+	zone := func(key string) corev1.NodeSelectorRequirement {
+		return corev1.NodeSelectorRequirement{Key: key, Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}}
+	}
+	region := func(key string) corev1.NodeSelectorRequirement {
+		return corev1.NodeSelectorRequirement{Key: key, Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1"}}
+	}
+	rackLabel := corev1.NodeSelectorRequirement{Key: "aerospike.com/rack-label", Operator: corev1.NodeSelectorOpIn, Values: []string{"r1"}}
+
+	keyTests := []struct {
+		name      string
+		zoneKey   string
+		regionKey string
+		want      [][]corev1.NodeSelectorRequirement
+	}{
+		{"default keys", "", "", [][]corev1.NodeSelectorRequirement{
+			{zone("topology.kubernetes.io/zone"), region("topology.kubernetes.io/region"), rackLabel},
+			{zone("failure-domain.beta.kubernetes.io/zone"), region("failure-domain.beta.kubernetes.io/region"), rackLabel},
+		}},
+		{"custom zone key", "example.com/zone", "", [][]corev1.NodeSelectorRequirement{
+			{zone("example.com/zone"), region("topology.kubernetes.io/region"), rackLabel},
+			{zone("example.com/zone"), region("failure-domain.beta.kubernetes.io/region"), rackLabel},
+		}},
+		{"custom keys", "example.com/zone", "example.com/region", [][]corev1.NodeSelectorRequirement{
+			{zone("example.com/zone"), region("example.com/region"), rackLabel},
+		}},
+	}
+
+	for _, tt := range keyTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.RackConfig.ZoneTopologyKey = tt.zoneKey
+		aeroCluster.Spec.RackConfig.RegionTopologyKey = tt.regionKey
+
+		rack := aeroCluster.Spec.RackConfig.Racks[0]
+		rack.Zone = "us-east-1a"
+		rack.Region = "us-east-1"
+		rack.RackLabel = "r1"
+
+		st := &appsv1.StatefulSet{}
+		updateStatefulSetAffinity(aeroCluster, st, map[string]string{"app": "aerospike-cluster"}, RackState{Rack: rack, Size: 2})
+
+		var got [][]corev1.NodeSelectorRequirement
+		for _, term := range st.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			got = append(got, term.MatchExpressions)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("updateStatefulSetAffinity(%s) node selector terms = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetPodAntiAffinity(t *testing.T) {
+	labels := map[string]string{"app": "aerospike-cluster", "aerospike.com/cr": "aerocluster"}
+
+	antiAffinityTests := []struct {
+		name            string
+		multiPodPerHost bool
+		placement       *aerospikev1alpha1.AerospikePodPlacementSpec
+		wantRequired    []string
+		wantPreferred   []string
+	}{
+		{"default", false, nil, []string{"kubernetes.io/hostname"}, nil},
+		{"multi pod per host", true, nil, nil, nil},
+		{"preferred host", true, &aerospikev1alpha1.AerospikePodPlacementSpec{HostAntiAffinity: aerospikev1alpha1.AerospikeAntiAffinityPreferred}, nil, []string{"kubernetes.io/hostname"}},
+		{"preferred zone", false, &aerospikev1alpha1.AerospikePodPlacementSpec{
+			PreferredAntiAffinityTopologyKeys: []string{"topology.kubernetes.io/zone"},
+		}, []string{"kubernetes.io/hostname"}, []string{"topology.kubernetes.io/zone"}},
+		{"cluster", true, &aerospikev1alpha1.AerospikePodPlacementSpec{
+			ClusterAntiAffinity: &aerospikev1alpha1.AerospikeClusterAntiAffinity{},
+		}, []string{"kubernetes.io/hostname"}, nil},
+		{"preferred cluster on zone", false, &aerospikev1alpha1.AerospikePodPlacementSpec{
+			ClusterAntiAffinity: &aerospikev1alpha1.AerospikeClusterAntiAffinity{Mode: aerospikev1alpha1.AerospikeAntiAffinityPreferred, TopologyKey: "topology.kubernetes.io/zone"},
+		}, []string{"kubernetes.io/hostname"}, []string{"topology.kubernetes.io/zone"}},
+	}
+
+	for _, tt := range antiAffinityTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.MultiPodPerHost = tt.multiPodPerHost
+		aeroCluster.Spec.PodPlacement = tt.placement
+
+		antiAffinity := getPodAntiAffinity(aeroCluster, labels)
+		if antiAffinity == nil {
+			if tt.wantRequired != nil || tt.wantPreferred != nil {
+				t.Errorf("getPodAntiAffinity(%s) = nil, want required %v, preferred %v", tt.name, tt.wantRequired, tt.wantPreferred)
+			}
+			continue
+		}
+
+		var required, preferred []string
+		for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			required = append(required, term.TopologyKey)
+		}
+		for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			preferred = append(preferred, term.PodAffinityTerm.TopologyKey)
+		}
+		if !reflect.DeepEqual(required, tt.wantRequired) || !reflect.DeepEqual(preferred, tt.wantPreferred) {
+			t.Errorf("getPodAntiAffinity(%s) = required %v, preferred %v, want required %v, preferred %v", tt.name, required, preferred, tt.wantRequired, tt.wantPreferred)
+		}
+	}
+}
+
+func TestGetPodAntiAffinityClusterSelector(t *testing.T) {
+	selectorTests := []struct {
+		clusterNames []string
+		want         metav1.LabelSelectorRequirement
+	}{
+		{nil, metav1.LabelSelectorRequirement{Key: "aerospike.com/cr", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"aerocluster"}}},
+		{[]string{"other"}, metav1.LabelSelectorRequirement{Key: "aerospike.com/cr", Operator: metav1.LabelSelectorOpIn, Values: []string{"other"}}},
+	}
+
+	for _, tt := range selectorTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.MultiPodPerHost = true
+		aeroCluster.Spec.PodPlacement = &aerospikev1alpha1.AerospikePodPlacementSpec{
+			ClusterAntiAffinity: &aerospikev1alpha1.AerospikeClusterAntiAffinity{ClusterNames: tt.clusterNames, Namespaces: []string{"aerospike"}},
+		}
+
+		terms := getPodAntiAffinity(aeroCluster, nil).RequiredDuringSchedulingIgnoredDuringExecution
+		want := corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels:      map[string]string{"app": "aerospike-cluster"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{tt.want},
+			},
+			Namespaces:  []string{"aerospike"},
+			TopologyKey: "kubernetes.io/hostname",
+		}
+		if len(terms) != 1 || !reflect.DeepEqual(terms[0], want) {
+			t.Errorf("getPodAntiAffinity(%v) = %v, want %v", tt.clusterNames, terms, want)
+		}
+	}
+}
+
+func TestUpdateStatefulSetTermination(t *testing.T) {
+	gracePeriod := int64(60)
+
+	tlsConfig := aerospikev1alpha1.Values{
+		"network": map[string]interface{}{
+			"service": map[string]interface{}{"tls-name": "aerospike-a-0.test-runner"},
+			"tls": []interface{}{
+				map[string]interface{}{"name": "other", "ca-file": "/etc/aerospike/secret/other.pem"},
+				map[string]interface{}{
+					"name":      "aerospike-a-0.test-runner",
+					"ca-file":   "/etc/aerospike/secret/cacert.pem",
+					"cert-file": "/etc/aerospike/secret/svc_cluster_chain.pem",
+					"key-file":  "/etc/aerospike/secret/svc_key.pem",
+				},
+			},
+		},
+	}
+	tlsOptions := []string{
+		`TLS="--tls-enable --tls-name $MY_POD_TLS_NAME -p 4333 --tls-cafile /etc/aerospike/secret/cacert.pem --tls-certfile /etc/aerospike/secret/svc_cluster_chain.pem --tls-keyfile /etc/aerospike/secret/svc_key.pem"`,
+		"asinfo $AUTH $TLS -v 'quiesce:'",
+		`asinfo $AUTH $TLS -h "$host"`,
+	}
+
+	terminationTests := []struct {
+		name      string
+		preStop   *aerospikev1alpha1.AerospikePreStopSpec
+		config    aerospikev1alpha1.Values
+		wantSleep string
+		wantTLS   []string
+	}{
+		{"default", nil, nil, "sleep 15\n", []string{`TLS="--tls-enable --tls-name $MY_POD_TLS_NAME -p 4333"`}},
+		{"drain seconds", &aerospikev1alpha1.AerospikePreStopSpec{DrainSeconds: 30}, nil, "sleep 30\n", nil},
+		{"disabled", &aerospikev1alpha1.AerospikePreStopSpec{Disabled: true}, nil, "", nil},
+		{"tls", nil, tlsConfig, "sleep 15\n", tlsOptions},
+	}
+
+	for _, tt := range terminationTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.PodSpec.PreStop = tt.preStop
+		if tt.config != nil {
+			aeroCluster.Spec.AerospikeConfig = tt.config
+		}
+		aeroCluster.Spec.PodSpec.TerminationGracePeriodSeconds = &gracePeriod
+		st := &appsv1.StatefulSet{}
+		st.Spec.Template.Spec.Containers = []corev1.Container{{Name: "aerospike-server"}}
+
+		updateStatefulSetTermination(aeroCluster, st)
+
+		if got := st.Spec.Template.Spec.TerminationGracePeriodSeconds; got == nil || *got != gracePeriod {
+			t.Errorf("updateStatefulSetTermination(%s) terminationGracePeriodSeconds = %v, want %d", tt.name, got, gracePeriod)
+		}
+		lifecycle := st.Spec.Template.Spec.Containers[0].Lifecycle
+		if tt.wantSleep == "" {
+			if lifecycle != nil {
+				t.Errorf("updateStatefulSetTermination(%s) lifecycle = %v, want nil", tt.name, lifecycle)
+			}
+			continue
+		}
+
+		command := lifecycle.PreStop.Exec.Command
+		if len(command) != 3 || command[0] != "/bin/sh" || command[1] != "-c" {
+			t.Fatalf("updateStatefulSetTermination(%s) preStop command = %v, want /bin/sh -c script", tt.name, command)
+		}
+		script := command[2]
+		if strings.Contains(script, "%!") {
+			t.Errorf("updateStatefulSetTermination(%s) preStop script has formatting errors: %s", tt.name, script)
+		}
+		wants := append([]string{tt.wantSleep, "$" + adminUserEnvVar, "$" + adminPasswordEnvVar, `if [ "true" = "$MY_POD_TLS_ENABLED" ]`,
+			"quiesce:", "recluster:", "exit 0"}, tt.wantTLS...)
+		for _, want := range wants {
+			if !strings.Contains(script, want) {
+				t.Errorf("updateStatefulSetTermination(%s) preStop script does not contain %q: %s", tt.name, want, script)
+			}
+		}
+		if sh, err := exec.LookPath("sh"); err == nil {
+			if out, err := exec.Command(sh, "-n", "-c", script).CombinedOutput(); err != nil {
+				t.Errorf("updateStatefulSetTermination(%s) preStop script is not valid: %v: %s", tt.name, err, out)
+			}
+		}
+	}
+}
+
+func TestUpdateStatefulSetProbes(t *testing.T) {
+	tlsConfig := aerospikev1alpha1.Values{
+		"network": map[string]interface{}{
+			"service": map[string]interface{}{"tls-name": "aerospike-a-0.test-runner"},
+			"tls": []interface{}{
+				map[string]interface{}{
+					"name":      "aerospike-a-0.test-runner",
+					"ca-file":   "/etc/aerospike/secret/cacert.pem",
+					"cert-file": "/etc/aerospike/secret/svc_cluster_chain.pem",
+					"key-file":  "/etc/aerospike/secret/svc_key.pem",
+				},
+			},
+		},
+	}
+
+	probeTests := []struct {
+		name      string
+		probes    *aerospikev1alpha1.AerospikeProbesSpec
+		config    aerospikev1alpha1.Values
+		wantImage string
+		wantArgs  []string
+	}{
+		{"default", nil, nil, initContainerImage, nil},
+		{"not enabled", &aerospikev1alpha1.AerospikeProbesSpec{}, nil, initContainerImage, nil},
+		{"enabled", &aerospikev1alpha1.AerospikeProbesSpec{Enabled: true}, nil, probeInitContainerImage, []string{"-port=3000"}},
+		{"tls", &aerospikev1alpha1.AerospikeProbesSpec{Enabled: true}, tlsConfig, probeInitContainerImage, []string{"-port=4333",
+			"-tls-name=aerospike-a-0.test-runner", "-tls-ca-file=/etc/aerospike/secret/cacert.pem",
+			"-tls-cert-file=/etc/aerospike/secret/svc_cluster_chain.pem", "-tls-key-file=/etc/aerospike/secret/svc_key.pem"}},
+	}
+
+	for _, tt := range probeTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.PodSpec.Probes = tt.probes
+		if tt.config != nil {
+			aeroCluster.Spec.AerospikeConfig = tt.config
+		}
+		st := &appsv1.StatefulSet{}
+		st.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "aerospike-init"}}
+		st.Spec.Template.Spec.Containers = []corev1.Container{{Name: "aerospike-server"}}
+
+		updateStatefulSetProbes(aeroCluster, st)
+
+		if got := st.Spec.Template.Spec.InitContainers[0].Image; got != tt.wantImage {
+			t.Errorf("updateStatefulSetProbes(%s) init image = %s, want %s", tt.name, got, tt.wantImage)
+		}
+		container := st.Spec.Template.Spec.Containers[0]
+		if tt.wantArgs == nil {
+			if container.ReadinessProbe != nil || container.LivenessProbe != nil {
+				t.Errorf("updateStatefulSetProbes(%s) added probes, want none", tt.name)
+			}
+			continue
+		}
+		for mode, probe := range map[string]*corev1.Probe{"readiness": container.ReadinessProbe, "liveness": container.LivenessProbe} {
+			if probe == nil {
+				t.Errorf("updateStatefulSetProbes(%s) %s probe = nil", tt.name, mode)
+				continue
+			}
+			command := probe.Exec.Command
+			if len(command) < 3 || command[0] != probePath || command[1] != "-mode="+mode {
+				t.Errorf("updateStatefulSetProbes(%s) %s probe command = %v", tt.name, mode, command)
+				continue
+			}
+			if got := command[3:]; !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("updateStatefulSetProbes(%s) %s probe args = %v, want %v", tt.name, mode, got, tt.wantArgs)
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// reconcileEvacuation keeps the rack pods off the evacuated nodes and evacuates the pending pods one at a time.
+// An evacuated pod is drained and re-created with new volumes, the data is migrated back from the other nodes.
+func (r *ReconcileAerospikeCluster) reconcileEvacuation(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	if err := r.updateRacksAffinity(aeroCluster); err != nil {
+		return err
+	}
+
+	pods, err := r.getPendingEvacuationPods(aeroCluster)
+	if err != nil {
+		return err
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		logger.Info("Evacuating pod", log.Ctx{"podName": pod.Name, "nodeName": pod.Spec.NodeName})
+
+		if utils.IsPodRunning(pod) {
+			if err := r.waitForNodeSafeStopReady(aeroCluster, pod); err != nil {
+				return err
+			}
+		}
+
+		if err := r.replacePodPVCs(aeroCluster, pod); err != nil {
+			return fmt.Errorf("Failed to evacuate pod %s: %v", pod.Name, err)
+		}
+
+		// Record the listed pods as evacuated so that they are not evacuated again
+		if containsString(aeroCluster.Spec.Evacuation.Pods, pod.Name) {
+			if err := r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+				if status.Evacuation == nil {
+					status.Evacuation = &aerospikev1alpha1.AerospikeEvacuationSpec{}
+				}
+				if !containsString(status.Evacuation.Pods, pod.Name) {
+					status.Evacuation.Pods = append(status.Evacuation.Pods, pod.Name)
+				}
+			}); err != nil {
+				return err
+			}
+		}
+		logger.Info("Evacuated pod", log.Ctx{"podName": pod.Name})
+	}
+	return nil
+}
+
+// updateRacksAffinity updates the affinity of the rack StatefulSets with the rack placement and the evacuated nodes.
+// StatefulSets use the OnDelete update strategy, running pods are not restarted.
+func (r *ReconcileAerospikeCluster) updateRacksAffinity(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	for _, state := range getNewRackStateList(aeroCluster) {
+		found := &appsv1.StatefulSet{}
+		if err := r.client.Get(context.TODO(), getNamespacedNameForStatefulSet(aeroCluster, state.Rack.ID), found); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		oldAffinity := found.Spec.Template.Spec.Affinity
+		updateStatefulSetAffinity(aeroCluster, found, utils.LabelsForAerospikeClusterRack(aeroCluster.Name, state.Rack.ID), state)
+		if reflect.DeepEqual(oldAffinity, found.Spec.Template.Spec.Affinity) {
+			continue
+		}
+
+		if err := r.client.Update(context.TODO(), found, updateOption); err != nil {
+			return fmt.Errorf("Failed to update affinity of StatefulSet %s: %v", found.Name, err)
+		}
+	}
+	return nil
+}
+
+// getPendingEvacuationPods returns the listed pods not evacuated yet and the pods running on the evacuated nodes.
+func (r *ReconcileAerospikeCluster) getPendingEvacuationPods(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]corev1.Pod, error) {
+	evacuation := aeroCluster.Spec.Evacuation
+	if evacuation == nil {
+		return nil, nil
+	}
+
+	var evacuatedPods []string
+	if aeroCluster.Status.Evacuation != nil {
+		evacuatedPods = aeroCluster.Status.Evacuation.Pods
+	}
+
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if utils.IsTerminating(&pod) {
+			continue
+		}
+		if containsString(evacuation.Pods, pod.Name) && !containsString(evacuatedPods, pod.Name) {
+			pods = append(pods, pod)
+		} else if pod.Spec.NodeName != "" && containsString(evacuation.Nodes, pod.Spec.NodeName) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// getEvacuatedNodes returns the Kubernetes nodes to keep the pods off.
+func getEvacuatedNodes(aeroCluster *aerospikev1alpha1.AerospikeCluster) []string {
+	if aeroCluster.Spec.Evacuation == nil {
+		return nil
+	}
+	return aeroCluster.Spec.Evacuation.Nodes
+}
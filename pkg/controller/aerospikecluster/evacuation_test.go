@@ -0,0 +1,59 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetPendingEvacuationPods(t *testing.T) {
+	pod := func(name, nodeName string) k8sRuntime.Object {
+		pod := newUpgradeTestPod(name, testTargetImage)
+		pod.Spec.NodeName = nodeName
+		return pod
+	}
+	objs := []k8sRuntime.Object{
+		pod("aerocluster-1-0", "node-1"),
+		pod("aerocluster-1-1", "node-2"),
+		pod("aerocluster-2-0", "node-2"),
+		pod("aerocluster-2-1", "node-3"),
+	}
+
+	evacuationTests := []struct {
+		name          string
+		evacuation    *aerospikev1alpha1.AerospikeEvacuationSpec
+		evacuatedPods []string
+		want          []string
+	}{
+		{"no evacuation", nil, nil, nil},
+		{"pods", &aerospikev1alpha1.AerospikeEvacuationSpec{Pods: []string{"aerocluster-1-1", "aerocluster-2-1"}}, nil, []string{"aerocluster-1-1", "aerocluster-2-1"}},
+		{"pods evacuated", &aerospikev1alpha1.AerospikeEvacuationSpec{Pods: []string{"aerocluster-1-1", "aerocluster-2-1"}}, []string{"aerocluster-2-1"}, []string{"aerocluster-1-1"}},
+		{"nodes", &aerospikev1alpha1.AerospikeEvacuationSpec{Nodes: []string{"node-2"}}, nil, []string{"aerocluster-1-1", "aerocluster-2-0"}},
+	}
+
+	for _, tt := range evacuationTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.Evacuation = tt.evacuation
+		if tt.evacuatedPods != nil {
+			aeroCluster.Status.Evacuation = &aerospikev1alpha1.AerospikeEvacuationSpec{Pods: tt.evacuatedPods}
+		}
+		r := &ReconcileAerospikeCluster{client: newTestClient(t, objs...)}
+
+		pods, err := r.getPendingEvacuationPods(aeroCluster)
+		if err != nil {
+			t.Errorf("getPendingEvacuationPods(%s) error = %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, pod := range pods {
+			got = append(got, pod.Name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getPendingEvacuationPods(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
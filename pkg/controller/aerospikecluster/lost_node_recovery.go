@@ -0,0 +1,206 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultLostNodeRecoveryTimeout is the time a pod is stuck on a lost node before it is recovered if not given.
+const defaultLostNodeRecoveryTimeout = 10 * time.Minute
+
+// reconcileLostNodeRecovery re-creates the pods stuck on lost nodes with new volumes once the recovery timeout has passed.
+// The recovered pods are initialized again and the data is migrated back from the other nodes.
+// It returns the time to requeue after for the recovery timeout of a stuck pod to pass, or 0 if no pod waits for its timeout.
+func (r *ReconcileAerospikeCluster) reconcileLostNodeRecovery(aeroCluster *aerospikev1alpha1.AerospikeCluster) (time.Duration, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	policy := aeroCluster.Spec.LostNodeRecovery
+	if policy == nil {
+		return 0, nil
+	}
+	timeout := defaultLostNodeRecoveryTimeout
+	if policy.TimeoutSeconds > 0 {
+		timeout = time.Duration(policy.TimeoutSeconds) * time.Second
+	}
+
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	var wait time.Duration
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		lostNode, stuckSince, err := r.getLostNodeOfPod(pod)
+		if err != nil {
+			return 0, err
+		}
+		if lostNode == "" {
+			continue
+		}
+
+		if elapsed := time.Since(stuckSince); elapsed < timeout {
+			logger.Warn("Pod is stuck on lost node, waiting for recovery timeout", log.Ctx{"podName": pod.Name, "node": lostNode, "wait": timeout - elapsed})
+			if wait == 0 || timeout-elapsed < wait {
+				wait = timeout - elapsed
+			}
+			continue
+		}
+
+		if err := r.recoverLostNodePod(aeroCluster, pod, lostNode); err != nil {
+			return 0, err
+		}
+	}
+	return wait, nil
+}
+
+// recoverLostNodePod replaces the PVCs of the pod stuck on the lost node so that it is scheduled on another node.
+// The result is recorded in the pod operations status.
+func (r *ReconcileAerospikeCluster) recoverLostNodePod(aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod, lostNode string) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster), "podName": pod.Name})
+	logger.Warn("Recovering pod stuck on lost node", log.Ctx{"node": lostNode})
+
+	operation := aerospikev1alpha1.AerospikePodOperation{
+		PodName:   pod.Name,
+		Type:      aerospikev1alpha1.AerospikePodOperationRecoverLostNode,
+		Phase:     aerospikev1alpha1.AerospikePodOperationInProgress,
+		StartTime: metav1.Now(),
+		Message:   fmt.Sprintf("Node %s is lost", lostNode),
+	}
+	if err := r.setPodOperationStatus(aeroCluster, operation); err != nil {
+		return err
+	}
+
+	// The pod is not scheduled, it is not part of the Aerospike cluster and need not be quiesced
+	err := r.replacePodPVCs(aeroCluster, pod)
+
+	completionTime := metav1.Now()
+	operation.CompletionTime = &completionTime
+	operation.Phase = aerospikev1alpha1.AerospikePodOperationCompleted
+	if err != nil {
+		operation.Phase = aerospikev1alpha1.AerospikePodOperationFailed
+		operation.Message = fmt.Sprintf("Node %s is lost: %v", lostNode, err)
+	}
+	if statusErr := r.setPodOperationStatus(aeroCluster, operation); statusErr != nil {
+		return statusErr
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to recover pod %s stuck on lost node %s: %v", pod.Name, lostNode, err)
+	}
+	logger.Info("Recovered pod stuck on lost node", log.Ctx{"node": lostNode})
+	return nil
+}
+
+// getLostNodeOfPod returns the lost node a PV of the unschedulable pod has node affinity to and the time the pod is unschedulable since.
+// It returns an empty node name if the pod is not stuck on a lost node.
+func (r *ReconcileAerospikeCluster) getLostNodeOfPod(pod *corev1.Pod) (string, time.Time, error) {
+	if pod.Status.Phase != corev1.PodPending || utils.IsTerminating(pod) {
+		return "", time.Time{}, nil
+	}
+
+	condition := getPodUnschedulableCondition(pod)
+	if condition == nil {
+		return "", time.Time{}, nil
+	}
+	unschedulableSince := condition.LastTransitionTime.Time
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: volume.PersistentVolumeClaim.ClaimName, Namespace: pod.Namespace}, pvc); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return "", time.Time{}, fmt.Errorf("Failed to get PVC %s: %v", volume.PersistentVolumeClaim.ClaimName, err)
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+
+		pv := &corev1.PersistentVolume{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return "", time.Time{}, fmt.Errorf("Failed to get PV %s: %v", pvc.Spec.VolumeName, err)
+		}
+
+		for _, hostname := range getPVHostnames(pv) {
+			lost, err := r.isNodeLost(hostname)
+			if err != nil {
+				return "", time.Time{}, err
+			}
+			if lost {
+				return hostname, unschedulableSince, nil
+			}
+		}
+	}
+	return "", time.Time{}, nil
+}
+
+// isPodUnschedulable returns true if the scheduler could not schedule the pod.
+func isPodUnschedulable(pod *corev1.Pod) bool {
+	return getPodUnschedulableCondition(pod) != nil
+}
+
+func getPodUnschedulableCondition(pod *corev1.Pod) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		condition := &pod.Status.Conditions[i]
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			return condition
+		}
+	}
+	return nil
+}
+
+// isNodeLost returns true if the node with the hostname is deleted or not ready.
+func (r *ReconcileAerospikeCluster) isNodeLost(hostname string) (bool, error) {
+	nodeList := &corev1.NodeList{}
+	labelSelector := labels.SelectorFromSet(map[string]string{"kubernetes.io/hostname": hostname})
+	if err := r.client.List(context.TODO(), nodeList, &client.ListOptions{LabelSelector: labelSelector}); err != nil {
+		return false, fmt.Errorf("Failed to list nodes: %v", err)
+	}
+	if len(nodeList.Items) == 0 {
+		return true, nil
+	}
+
+	for _, node := range nodeList.Items {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status != corev1.ConditionTrue {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// getPVHostnames returns the hostnames the PV has required node affinity to, e.g. the node of a local PV.
+func getPVHostnames(pv *corev1.PersistentVolume) []string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return nil
+	}
+
+	var hostnames []string
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == "kubernetes.io/hostname" && expression.Operator == corev1.NodeSelectorOpIn {
+				hostnames = append(hostnames, expression.Values...)
+			}
+		}
+	}
+	return hostnames
+}
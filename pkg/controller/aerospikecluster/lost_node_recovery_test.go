@@ -0,0 +1,180 @@
+package aerospikecluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetPVHostnames(t *testing.T) {
+	requirement := func(key string, operator corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorRequirement {
+		return corev1.NodeSelectorRequirement{Key: key, Operator: operator, Values: values}
+	}
+	pv := func(terms ...corev1.NodeSelectorTerm) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{NodeSelectorTerms: terms}},
+		}}
+	}
+
+	hostnameTests := []struct {
+		name string
+		pv   *corev1.PersistentVolume
+		want []string
+	}{
+		{"no node affinity", &corev1.PersistentVolume{}, nil},
+		{"no required node affinity", &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{NodeAffinity: &corev1.VolumeNodeAffinity{}}}, nil},
+		{"local PV", pv(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			requirement("kubernetes.io/hostname", corev1.NodeSelectorOpIn, "node-1"),
+		}}), []string{"node-1"}},
+		{"terms", pv(
+			corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{requirement("kubernetes.io/hostname", corev1.NodeSelectorOpIn, "node-1", "node-2")}},
+			corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{requirement("kubernetes.io/hostname", corev1.NodeSelectorOpIn, "node-3")}},
+		), []string{"node-1", "node-2", "node-3"}},
+		{"hostname not in", pv(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			requirement("kubernetes.io/hostname", corev1.NodeSelectorOpNotIn, "node-1"),
+		}}), nil},
+		{"other keys", pv(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			requirement("failure-domain.beta.kubernetes.io/zone", corev1.NodeSelectorOpIn, "us-west-2a"),
+			requirement("kubernetes.io/hostname", corev1.NodeSelectorOpExists),
+		}}), nil},
+	}
+
+	for _, tt := range hostnameTests {
+		if got := getPVHostnames(tt.pv); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getPVHostnames(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetPodUnschedulableCondition(t *testing.T) {
+	unschedulable := corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable}
+
+	conditionTests := []struct {
+		name       string
+		conditions []corev1.PodCondition
+		want       bool
+	}{
+		{"no conditions", nil, false},
+		{"unschedulable", []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}, unschedulable}, true},
+		{"scheduled", []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}}, false},
+		{"not scheduled yet", []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse}}, false},
+	}
+
+	for _, tt := range conditionTests {
+		pod := &corev1.Pod{Status: corev1.PodStatus{Conditions: tt.conditions}}
+		got := getPodUnschedulableCondition(pod)
+		if (got != nil) != tt.want || (got != nil && *got != unschedulable) {
+			t.Errorf("getPodUnschedulableCondition(%s) = %v, want unschedulable %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReconcileLostNodeRecovery(t *testing.T) {
+	// stuckPod returns a pod of the cluster unschedulable since stuckFor ago with a local PV on node-1
+	stuckPod := func(name string, stuckFor time.Duration) []k8sRuntime.Object {
+		pod := newUpgradeTestPod(name, testTargetImage)
+		pod.Spec.Volumes = []corev1.Volume{{Name: "data", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-" + name},
+		}}}
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             corev1.PodReasonUnschedulable,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-stuckFor)),
+			}},
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-" + name, Namespace: "aerospike"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-" + name},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + name},
+			Spec: corev1.PersistentVolumeSpec{NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "kubernetes.io/hostname", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}},
+				}}},
+			}}},
+		}
+		return []k8sRuntime.Object{pod, pvc, pv}
+	}
+	node := func(ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"kubernetes.io/hostname": "node-1"}},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	objects := func(objs ...[]k8sRuntime.Object) []k8sRuntime.Object {
+		var all []k8sRuntime.Object
+		for _, o := range objs {
+			all = append(all, o...)
+		}
+		return all
+	}
+
+	recoveryTests := []struct {
+		name          string
+		policy        *aerospikev1alpha1.AerospikeLostNodeRecoverySpec
+		objs          []k8sRuntime.Object
+		wantWait      time.Duration
+		wantRecovered []string
+	}{
+		{"disabled", nil, stuckPod("aerocluster-1-0", time.Minute), 0, nil},
+		{"node deleted", &aerospikev1alpha1.AerospikeLostNodeRecoverySpec{}, stuckPod("aerocluster-1-0", time.Minute), 9 * time.Minute, nil},
+		{"node not ready", &aerospikev1alpha1.AerospikeLostNodeRecoverySpec{},
+			objects(stuckPod("aerocluster-1-0", time.Minute), []k8sRuntime.Object{node(corev1.ConditionUnknown)}), 9 * time.Minute, nil},
+		{"node ready", &aerospikev1alpha1.AerospikeLostNodeRecoverySpec{},
+			objects(stuckPod("aerocluster-1-0", time.Minute), []k8sRuntime.Object{node(corev1.ConditionTrue)}), 0, nil},
+		{"shortest wait", &aerospikev1alpha1.AerospikeLostNodeRecoverySpec{TimeoutSeconds: 300},
+			objects(stuckPod("aerocluster-1-0", time.Minute), stuckPod("aerocluster-1-1", 3*time.Minute)), 2 * time.Minute, nil},
+		{"timed out", &aerospikev1alpha1.AerospikeLostNodeRecoverySpec{TimeoutSeconds: 120},
+			objects(stuckPod("aerocluster-1-0", time.Minute), stuckPod("aerocluster-1-1", 3*time.Minute)), 0, []string{"aerocluster-1-1"}},
+	}
+
+	for _, tt := range recoveryTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.LostNodeRecovery = tt.policy
+		// Recovery waits for the pod to be re-created by its StatefulSet, fail it at the PVC delete instead
+		r := &ReconcileAerospikeCluster{client: &pvcDeleteFailingClient{Client: newTestClient(t, append(tt.objs, aeroCluster.DeepCopy())...)}}
+
+		wait, err := r.reconcileLostNodeRecovery(aeroCluster)
+		if (err != nil) != (tt.wantRecovered != nil) {
+			t.Errorf("reconcileLostNodeRecovery(%s) error = %v, want error %v", tt.name, err, tt.wantRecovered != nil)
+		}
+		// The stuck time passed since the objects were created
+		if wait > tt.wantWait || wait < tt.wantWait-time.Minute/2 {
+			t.Errorf("reconcileLostNodeRecovery(%s) wait = %v, want %v", tt.name, wait, tt.wantWait)
+		}
+
+		var recovered []string
+		for _, operation := range aeroCluster.Status.PodOperations {
+			if operation.Type == aerospikev1alpha1.AerospikePodOperationRecoverLostNode {
+				recovered = append(recovered, operation.PodName)
+			}
+		}
+		if !reflect.DeepEqual(recovered, tt.wantRecovered) {
+			t.Errorf("reconcileLostNodeRecovery(%s) recovered = %v, want %v", tt.name, recovered, tt.wantRecovered)
+		}
+	}
+}
+
+// pvcDeleteFailingClient fails the PVC deletes.
+type pvcDeleteFailingClient struct {
+	client.Client
+}
+
+func (c *pvcDeleteFailingClient) Delete(ctx context.Context, obj k8sRuntime.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+		return errors.NewServiceUnavailable("unavailable")
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
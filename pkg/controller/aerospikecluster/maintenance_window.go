@@ -0,0 +1,167 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// pausedRequeueInterval is the interval to refresh the plan and the observed status of a paused cluster.
+const pausedRequeueInterval = time.Minute
+
+// disruptiveActions restart or remove pods. They are deferred till a maintenance window opens.
+var disruptiveActions = map[aerospikev1alpha1.AerospikePlannedActionType]bool{
+	aerospikev1alpha1.AerospikePlannedActionScaleDown:      true,
+	aerospikev1alpha1.AerospikePlannedActionUpgrade:        true,
+	aerospikev1alpha1.AerospikePlannedActionRollingRestart: true,
+	aerospikev1alpha1.AerospikePlannedActionDeleteRack:     true,
+	aerospikev1alpha1.AerospikePlannedActionEvacuate:       true,
+	aerospikev1alpha1.AerospikePlannedActionRelocate:       true,
+}
+
+// isPaused returns true if the cluster is paused by spec or annotation.
+func isPaused(aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	return aeroCluster.Spec.Paused || aeroCluster.Annotations[aerospikev1alpha1.PausedAnnotation] == "true"
+}
+
+// reconcilePausedCondition sets the Paused condition. The condition is added only once the cluster is paused.
+func (r *ReconcileAerospikeCluster) reconcilePausedCondition(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	paused := isPaused(aeroCluster)
+	if !paused && getClusterCondition(aeroCluster.Status.Conditions, aerospikev1alpha1.AerospikeClusterPaused) == nil {
+		return nil
+	}
+
+	condition := aerospikev1alpha1.AerospikeClusterCondition{
+		Type:   aerospikev1alpha1.AerospikeClusterPaused,
+		Status: corev1.ConditionFalse,
+		Reason: "Resumed",
+	}
+	if paused {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "Paused"
+		condition.Message = "Paused by spec.paused"
+		if !aeroCluster.Spec.Paused {
+			condition.Message = fmt.Sprintf("Paused by annotation %s", aerospikev1alpha1.PausedAnnotation)
+		}
+	}
+	return r.setStatusCondition(aeroCluster, condition)
+}
+
+// reconcileMaintenanceWindow defers the disruptive actions if no maintenance window is open and applies the other actions.
+// It returns nil result if the reconcile should continue, otherwise the result to requeue when the next window opens.
+func (r *ReconcileAerospikeCluster) reconcileMaintenanceWindow(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	open, wait, err := utils.GetMaintenanceWindowState(aeroCluster.Spec.MaintenanceWindows, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var actions, deferredActions []aerospikev1alpha1.AerospikePlannedAction
+	if !open {
+		if actions, err = r.computePlan(aeroCluster); err != nil {
+			return nil, fmt.Errorf("Failed to compute plan: %v", err)
+		}
+		for _, action := range actions {
+			if disruptiveActions[action.Type] {
+				deferredActions = append(deferredActions, action)
+			}
+		}
+	}
+
+	if err := r.setMaintenanceDeferredCondition(aeroCluster, deferredActions, wait); err != nil {
+		return nil, err
+	}
+	if len(deferredActions) == 0 {
+		return nil, nil
+	}
+
+	logger.Info("Disruptive actions deferred till the next maintenance window", log.Ctx{"actions": len(deferredActions), "wait": wait})
+
+	if err := r.applyNonDisruptiveActions(aeroCluster, actions); err != nil {
+		return nil, err
+	}
+
+	// Status spec is not updated, the deferred actions are still pending. Keep the observed status up to date.
+	if err := r.reconcileServerVersion(aeroCluster); err != nil {
+		return nil, err
+	}
+	return &reconcile.Result{RequeueAfter: wait}, nil
+}
+
+// applyNonDisruptiveActions applies the planned actions that do not restart or remove pods.
+func (r *ReconcileAerospikeCluster) applyNonDisruptiveActions(aeroCluster *aerospikev1alpha1.AerospikeCluster, actions []aerospikev1alpha1.AerospikePlannedAction) error {
+	for _, action := range actions {
+		switch action.Type {
+		case aerospikev1alpha1.AerospikePlannedActionCreateRack:
+			rackState, ok := getRackState(aeroCluster, *action.RackID)
+			if !ok {
+				continue
+			}
+			// Create statefulset with 0 size rack and then scaleUp in the next action
+			if _, err := r.createRack(aeroCluster, RackState{Rack: rackState.Rack, Size: 0}); err != nil {
+				return err
+			}
+
+		case aerospikev1alpha1.AerospikePlannedActionScaleUp:
+			rackState, ok := getRackState(aeroCluster, *action.RackID)
+			if !ok {
+				continue
+			}
+			found := &appsv1.StatefulSet{}
+			if err := r.client.Get(context.TODO(), getNamespacedNameForStatefulSet(aeroCluster, rackState.Rack.ID), found); err != nil {
+				return err
+			}
+			if _, err := r.scaleUpRack(aeroCluster, found, rackState); err != nil {
+				return fmt.Errorf("Failed to scaleUp rack %d: %v", rackState.Rack.ID, err)
+			}
+
+		case aerospikev1alpha1.AerospikePlannedActionUpdateAccessControl:
+			if err := r.reconcileAccessControl(aeroCluster); err != nil {
+				return fmt.Errorf("Failed to reconcile access control: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// setMaintenanceDeferredCondition sets the MaintenanceDeferred condition. The condition is added only once actions are deferred.
+func (r *ReconcileAerospikeCluster) setMaintenanceDeferredCondition(aeroCluster *aerospikev1alpha1.AerospikeCluster, deferredActions []aerospikev1alpha1.AerospikePlannedAction, wait time.Duration) error {
+	if len(deferredActions) == 0 && getClusterCondition(aeroCluster.Status.Conditions, aerospikev1alpha1.AerospikeClusterMaintenanceDeferred) == nil {
+		return nil
+	}
+
+	condition := aerospikev1alpha1.AerospikeClusterCondition{
+		Type:   aerospikev1alpha1.AerospikeClusterMaintenanceDeferred,
+		Status: corev1.ConditionFalse,
+		Reason: "NoDeferredActions",
+	}
+	if len(deferredActions) != 0 {
+		var deferred []string
+		for _, action := range deferredActions {
+			deferred = append(deferred, fmt.Sprintf("%s of rack %d", action.Type, *action.RackID))
+		}
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "OutsideMaintenanceWindow"
+		condition.Message = fmt.Sprintf("Deferred %s till %s", strings.Join(deferred, ", "), time.Now().Add(wait).UTC().Format(time.RFC3339))
+	}
+	return r.setStatusCondition(aeroCluster, condition)
+}
+
+// getRackState returns the desired state of the rack in the spec.
+func getRackState(aeroCluster *aerospikev1alpha1.AerospikeCluster, rackID int) (RackState, bool) {
+	for _, state := range getNewRackStateList(aeroCluster) {
+		if state.Rack.ID == rackID {
+			return state, true
+		}
+	}
+	return RackState{}, false
+}
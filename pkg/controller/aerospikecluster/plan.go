@@ -0,0 +1,342 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isPlanMode returns true if the spec changes should only be planned and not applied.
+func isPlanMode(aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	return isPaused(aeroCluster) || aeroCluster.Annotations[aerospikev1alpha1.PlanAnnotation] == "true"
+}
+
+// reconcilePlan computes the plan of the pending actions and publishes it in status. Nothing is applied to the cluster.
+func (r *ReconcileAerospikeCluster) reconcilePlan(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	actions, err := r.computePlan(aeroCluster)
+	if err != nil {
+		return fmt.Errorf("Failed to compute plan: %v", err)
+	}
+
+	logger.Info("Computed plan, spec changes are not applied", log.Ctx{"paused": isPaused(aeroCluster), "actions": len(actions)})
+
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.Plan = &aerospikev1alpha1.AerospikeReconcilePlan{
+			Generation:   aeroCluster.Generation,
+			ComputedTime: metav1.Now(),
+			Actions:      actions,
+		}
+	})
+}
+
+// clearPlan removes the plan from status once the spec changes are applied.
+func (r *ReconcileAerospikeCluster) clearPlan(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	if aeroCluster.Status.Plan == nil {
+		return nil
+	}
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.Plan = nil
+	})
+}
+
+// computePlan returns the actions ReconcileRacks and reconcileAccessControl would take to apply the spec, in the same order.
+func (r *ReconcileAerospikeCluster) computePlan(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]aerospikev1alpha1.AerospikePlannedAction, error) {
+	var actions []aerospikev1alpha1.AerospikePlannedAction
+	var scaledDownRackActions []aerospikev1alpha1.AerospikePlannedAction
+
+	rackStateList := getNewRackStateList(aeroCluster)
+	for _, state := range rackStateList {
+		found := &appsv1.StatefulSet{}
+		stsName := getNamespacedNameForStatefulSet(aeroCluster, state.Rack.ID)
+		if err := r.client.Get(context.TODO(), stsName, found); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+			actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionCreateRack, state.Rack.ID, nil, fmt.Sprintf("Create StatefulSet %s", stsName.Name)))
+			if state.Size != 0 {
+				actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionScaleUp, state.Rack.ID, getPodNames(stsName.Name, 0, int32(state.Size)), fmt.Sprintf("Scale up from 0 to %d pods", state.Size)))
+			}
+			continue
+		}
+
+		rackActions, err := r.planRack(aeroCluster, found, state)
+		if err != nil {
+			return nil, err
+		}
+
+		// Scaled down racks are reconciled after all other racks
+		if *found.Spec.Replicas > int32(state.Size) {
+			scaledDownRackActions = append(scaledDownRackActions, rackActions...)
+		} else {
+			actions = append(actions, rackActions...)
+		}
+	}
+	actions = append(actions, scaledDownRackActions...)
+
+	if len(aeroCluster.Status.RackConfig.Racks) != 0 {
+		deleteActions, err := r.planDeleteRacks(aeroCluster, rackStateList)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, deleteActions...)
+	}
+
+	evacuateActions, err := r.planEvacuation(aeroCluster, actions)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, evacuateActions...)
+
+	relocateActions, err := r.planRelocation(aeroCluster, actions)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, relocateActions...)
+
+	accessControlAction, err := planAccessControl(aeroCluster)
+	if err != nil {
+		return nil, err
+	}
+	if accessControlAction != nil {
+		actions = append(actions, *accessControlAction)
+	}
+
+	return actions, nil
+}
+
+// planRack returns the actions reconcileRack would take for an existing rack.
+func (r *ReconcileAerospikeCluster) planRack(aeroCluster *aerospikev1alpha1.AerospikeCluster, found *appsv1.StatefulSet, rackState RackState) ([]aerospikev1alpha1.AerospikePlannedAction, error) {
+	var actions []aerospikev1alpha1.AerospikePlannedAction
+
+	rackID := rackState.Rack.ID
+	currentSize := *found.Spec.Replicas
+	desiredSize := int32(rackState.Size)
+
+	removedPods := map[string]bool{}
+	if currentSize > desiredSize {
+		// Pods are removed from the end
+		var podNames []string
+		for i := currentSize - 1; i >= desiredSize; i-- {
+			podName := getStatefulSetPodName(found.Name, i)
+			podNames = append(podNames, podName)
+			removedPods[podName] = true
+		}
+		actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionScaleDown, rackID, podNames, fmt.Sprintf("Scale down from %d to %d pods", currentSize, desiredSize)))
+	}
+
+	podList, err := r.getOrderedRackPodList(aeroCluster, rackID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	var remainingPods, upgradePods []string
+	for i := range podList {
+		pod := &podList[i]
+		if removedPods[pod.Name] {
+			continue
+		}
+		remainingPods = append(remainingPods, pod.Name)
+		if !utils.IsPodOnDesiredImage(pod, aeroCluster) {
+			upgradePods = append(upgradePods, pod.Name)
+		}
+	}
+
+	if len(upgradePods) != 0 {
+		actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionUpgrade, rackID, upgradePods, fmt.Sprintf("Upgrade pods to image %s", aeroCluster.Spec.Image)))
+	} else if reasons := getRollingRestartReasons(aeroCluster, rackState); len(reasons) != 0 {
+		actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionRollingRestart, rackID, remainingPods, strings.Join(reasons, ", ")))
+	}
+
+	if currentSize < desiredSize {
+		actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionScaleUp, rackID, getPodNames(found.Name, currentSize, desiredSize), fmt.Sprintf("Scale up from %d to %d pods", currentSize, desiredSize)))
+	}
+
+	return actions, nil
+}
+
+// planDeleteRacks returns the actions deleteRacks would take for the racks removed from the spec.
+func (r *ReconcileAerospikeCluster) planDeleteRacks(aeroCluster *aerospikev1alpha1.AerospikeCluster, rackStateList []RackState) ([]aerospikev1alpha1.AerospikePlannedAction, error) {
+	var actions []aerospikev1alpha1.AerospikePlannedAction
+
+	for _, rack := range getOldRackList(aeroCluster) {
+		var rackFound bool
+		for _, newRack := range rackStateList {
+			if rack.ID == newRack.Rack.ID {
+				rackFound = true
+				break
+			}
+		}
+		if rackFound {
+			continue
+		}
+
+		found := &appsv1.StatefulSet{}
+		stsName := getNamespacedNameForStatefulSet(aeroCluster, rack.ID)
+		if err := r.client.Get(context.TODO(), stsName, found); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		actions = append(actions, newPlannedAction(aerospikev1alpha1.AerospikePlannedActionDeleteRack, rack.ID, getPodNames(found.Name, 0, *found.Spec.Replicas), fmt.Sprintf("Scale down and delete StatefulSet %s", found.Name)))
+	}
+	return actions, nil
+}
+
+// planEvacuation returns the actions reconcileEvacuation would take, one per rack. Pods removed by the earlier actions are skipped.
+func (r *ReconcileAerospikeCluster) planEvacuation(aeroCluster *aerospikev1alpha1.AerospikeCluster, actions []aerospikev1alpha1.AerospikePlannedAction) ([]aerospikev1alpha1.AerospikePlannedAction, error) {
+	pods, err := r.getPendingEvacuationPods(aeroCluster)
+	if err != nil {
+		return nil, err
+	}
+	return planPodReplacement(aerospikev1alpha1.AerospikePlannedActionEvacuate, pods, actions)
+}
+
+// planRelocation returns the actions reconcileRackRelocation would take, one per rack. Pods removed or evacuated by the earlier actions are skipped.
+func (r *ReconcileAerospikeCluster) planRelocation(aeroCluster *aerospikev1alpha1.AerospikeCluster, actions []aerospikev1alpha1.AerospikePlannedAction) ([]aerospikev1alpha1.AerospikePlannedAction, error) {
+	pods, err := r.getMisplacedPods(aeroCluster)
+	if err != nil {
+		return nil, err
+	}
+	return planPodReplacement(aerospikev1alpha1.AerospikePlannedActionRelocate, pods, actions)
+}
+
+// planPodReplacement groups the pods drained and re-created with new volumes into one action per rack.
+func planPodReplacement(actionType aerospikev1alpha1.AerospikePlannedActionType, pods []corev1.Pod, actions []aerospikev1alpha1.AerospikePlannedAction) ([]aerospikev1alpha1.AerospikePlannedAction, error) {
+	removedPods := map[string]bool{}
+	for _, action := range actions {
+		if action.Type == aerospikev1alpha1.AerospikePlannedActionScaleDown || action.Type == aerospikev1alpha1.AerospikePlannedActionDeleteRack ||
+			action.Type == aerospikev1alpha1.AerospikePlannedActionEvacuate {
+			for _, podName := range action.Pods {
+				removedPods[podName] = true
+			}
+		}
+	}
+
+	var rackIDs []int
+	rackPods := map[int][]string{}
+	for _, pod := range pods {
+		if removedPods[pod.Name] {
+			continue
+		}
+		rackID, err := getRackIDFromPodName(pod.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := rackPods[*rackID]; !ok {
+			rackIDs = append(rackIDs, *rackID)
+		}
+		rackPods[*rackID] = append(rackPods[*rackID], pod.Name)
+	}
+
+	var replaceActions []aerospikev1alpha1.AerospikePlannedAction
+	for _, rackID := range rackIDs {
+		replaceActions = append(replaceActions, newPlannedAction(actionType, rackID, rackPods[rackID], "Drain and re-create pods with new volumes"))
+	}
+	return replaceActions, nil
+}
+
+// planAccessControl returns the roles and users reconcileAccessControl would create, update or drop. It returns nil if there are no changes.
+func planAccessControl(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*aerospikev1alpha1.AerospikePlannedAction, error) {
+	if !utils.IsEnterprise(&aeroCluster.Spec) {
+		return nil, nil
+	}
+
+	enabled, err := utils.IsSecurityEnabled(aeroCluster.Spec.AerospikeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get cluster security status: %v", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	desiredRoles, desiredUsers := getAccessControlByName(aeroCluster.Spec.AerospikeAccessControl)
+	currentRoles, currentUsers := getAccessControlByName(aeroCluster.Status.AerospikeAccessControl)
+
+	// Roles are reconciled before users
+	changes := getAccessControlChanges("role", desiredRoles, currentRoles)
+	changes = append(changes, getAccessControlChanges("user", desiredUsers, currentUsers)...)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return &aerospikev1alpha1.AerospikePlannedAction{
+		Type:        aerospikev1alpha1.AerospikePlannedActionUpdateAccessControl,
+		Description: strings.Join(changes, ", "),
+	}, nil
+}
+
+// getAccessControlByName returns the roles and users of the access control spec keyed by name.
+func getAccessControlByName(accessControl *aerospikev1alpha1.AerospikeAccessControlSpec) (map[string]interface{}, map[string]interface{}) {
+	roles := map[string]interface{}{}
+	users := map[string]interface{}{}
+	if accessControl != nil {
+		for _, role := range accessControl.Roles {
+			roles[role.Name] = role
+		}
+		for _, user := range accessControl.Users {
+			users[user.Name] = user
+		}
+	}
+	return roles, users
+}
+
+// getAccessControlChanges returns the create, update and drop changes from current to desired e.g. "create role reader".
+func getAccessControlChanges(kind string, desired, current map[string]interface{}) []string {
+	var changes []string
+
+	for _, name := range sortedKeys(desired) {
+		currentSpec, ok := current[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("create %s %s", kind, name))
+		} else if !reflect.DeepEqual(desired[name], currentSpec) {
+			changes = append(changes, fmt.Sprintf("update %s %s", kind, name))
+		}
+	}
+
+	for _, name := range sortedKeys(current) {
+		if _, ok := desired[name]; !ok {
+			changes = append(changes, fmt.Sprintf("drop %s %s", kind, name))
+		}
+	}
+	return changes
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getPodNames returns the names of the StatefulSet pods with ordinals in [from, to).
+func getPodNames(statefulSetName string, from, to int32) []string {
+	var podNames []string
+	for i := from; i < to; i++ {
+		podNames = append(podNames, getStatefulSetPodName(statefulSetName, i))
+	}
+	return podNames
+}
+
+func newPlannedAction(actionType aerospikev1alpha1.AerospikePlannedActionType, rackID int, podNames []string, description string) aerospikev1alpha1.AerospikePlannedAction {
+	return aerospikev1alpha1.AerospikePlannedAction{
+		Type:        actionType,
+		RackID:      &rackID,
+		Pods:        podNames,
+		Description: description,
+	}
+}
@@ -0,0 +1,136 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPlanTestCluster() *aerospikev1alpha1.AerospikeCluster {
+	return &aerospikev1alpha1.AerospikeCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "aerocluster", Namespace: "aerospike"},
+		Spec:       newPlanTestSpec(),
+		Status:     aerospikev1alpha1.AerospikeClusterStatus{AerospikeClusterSpec: newPlanTestSpec()},
+	}
+}
+
+func newPlanTestSpec() aerospikev1alpha1.AerospikeClusterSpec {
+	return aerospikev1alpha1.AerospikeClusterSpec{
+		Image:           "aerospike/aerospike-server-enterprise:5.2.0.7",
+		AerospikeConfig: aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 15000}},
+		RackConfig: aerospikev1alpha1.RackConfig{
+			Racks: []aerospikev1alpha1.Rack{{
+				ID:              1,
+				AerospikeConfig: aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 15000}},
+			}},
+		},
+	}
+}
+
+func TestGetRollingRestartReasons(t *testing.T) {
+	reasonTests := []struct {
+		name   string
+		update func(aeroCluster *aerospikev1alpha1.AerospikeCluster)
+		want   []string
+	}{
+		{"no change", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {}, nil},
+		{"status not updated", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {
+			aeroCluster.Status.AerospikeConfig = nil
+			aeroCluster.Spec.PodSpec.TerminationGracePeriodSeconds = new(int64)
+		}, nil},
+		{"rack config", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {
+			aeroCluster.Spec.RackConfig.Racks[0].AerospikeConfig = aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 20000}}
+		}, []string{"Rack AerospikeConfig changed"}},
+		{"rack image is applied by the upgrade", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {
+			aeroCluster.Spec.RackConfig.Racks[0].PodSpec.Image = "aerospike/aerospike-server-enterprise:5.3.0.1"
+		}, nil},
+		{"rack pod spec", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {
+			aeroCluster.Spec.RackConfig.Racks[0].PodSpec.NodeSelector = map[string]string{"pool": "aerospike"}
+		}, []string{"Rack pod spec changed"}},
+		{"network policy and pod spec", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {
+			aeroCluster.Spec.AerospikeNetworkPolicy.AccessType = aerospikev1alpha1.AerospikeNetworkTypeHostExternal
+			aeroCluster.Spec.PodSpec.TerminationGracePeriodSeconds = new(int64)
+		}, []string{"Aerospike network policy changed", "Aerospike pod spec changed"}},
+		{"resources", func(aeroCluster *aerospikev1alpha1.AerospikeCluster) {
+			aeroCluster.Spec.Resources = &corev1.ResourceRequirements{}
+		}, []string{"Resources changed"}},
+	}
+
+	for _, tt := range reasonTests {
+		aeroCluster := newPlanTestCluster()
+		tt.update(aeroCluster)
+		rackState := RackState{Rack: aeroCluster.Spec.RackConfig.Racks[0], Size: 2}
+		if got := getRollingRestartReasons(aeroCluster, rackState); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getRollingRestartReasons(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanPodReplacement(t *testing.T) {
+	pod := func(name string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	actions := []aerospikev1alpha1.AerospikePlannedAction{
+		newPlannedAction(aerospikev1alpha1.AerospikePlannedActionScaleDown, 1, []string{"aerocluster-1-2"}, ""),
+		newPlannedAction(aerospikev1alpha1.AerospikePlannedActionRollingRestart, 2, []string{"aerocluster-2-0"}, ""),
+	}
+	pods := []corev1.Pod{pod("aerocluster-1-0"), pod("aerocluster-2-0"), pod("aerocluster-1-2"), pod("aerocluster-1-1")}
+
+	got, err := planPodReplacement(aerospikev1alpha1.AerospikePlannedActionEvacuate, pods, actions)
+	if err != nil {
+		t.Fatalf("planPodReplacement() error = %v", err)
+	}
+
+	// Pods removed by the scale down are skipped, the racks keep the order of their first pod
+	want := []aerospikev1alpha1.AerospikePlannedAction{
+		newPlannedAction(aerospikev1alpha1.AerospikePlannedActionEvacuate, 1, []string{"aerocluster-1-0", "aerocluster-1-1"}, "Drain and re-create pods with new volumes"),
+		newPlannedAction(aerospikev1alpha1.AerospikePlannedActionEvacuate, 2, []string{"aerocluster-2-0"}, "Drain and re-create pods with new volumes"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planPodReplacement() = %v, want %v", got, want)
+	}
+
+	if _, err := planPodReplacement(aerospikev1alpha1.AerospikePlannedActionEvacuate, []corev1.Pod{pod("aerocluster")}, nil); err == nil {
+		t.Errorf("planPodReplacement() accepted pod name without rack id")
+	}
+}
+
+func TestGetAccessControlChanges(t *testing.T) {
+	changeTests := []struct {
+		desired map[string]interface{}
+		current map[string]interface{}
+		want    []string
+	}{
+		{map[string]interface{}{"reader": "read"}, map[string]interface{}{"reader": "read"}, nil},
+		{map[string]interface{}{"reader": "read", "writer": "write"}, map[string]interface{}{"reader": "read"}, []string{"create role writer"}},
+		{map[string]interface{}{"reader": "read-write"}, map[string]interface{}{"reader": "read", "writer": "write"}, []string{"update role reader", "drop role writer"}},
+	}
+
+	for _, tt := range changeTests {
+		if got := getAccessControlChanges("role", tt.desired, tt.current); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getAccessControlChanges(%v, %v) = %v, want %v", tt.desired, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestGetPodNames(t *testing.T) {
+	nameTests := []struct {
+		from int32
+		to   int32
+		want []string
+	}{
+		{0, 2, []string{"aerocluster-1-0", "aerocluster-1-1"}},
+		{2, 3, []string{"aerocluster-1-2"}},
+		{2, 2, nil},
+	}
+
+	for _, tt := range nameTests {
+		if got := getPodNames("aerocluster-1", tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getPodNames(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// reconcilePodDisruptionBudgets creates or updates the PodDisruptionBudgets of the cluster or of each rack,
+// and deletes the budgets of removed racks or of the other scope. All budgets are deleted if disabled.
+func (r *ReconcileAerospikeCluster) reconcilePodDisruptionBudgets(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	desired := getDesiredPodDisruptionBudgets(aeroCluster)
+
+	pdbList := &policyv1beta1.PodDisruptionBudgetList{}
+	labelSelector := labels.SelectorFromSet(utils.LabelsForAerospikeCluster(aeroCluster.Name))
+	listOps := &client.ListOptions{Namespace: aeroCluster.Namespace, LabelSelector: labelSelector}
+	if err := r.client.List(context.TODO(), pdbList, listOps); err != nil {
+		return fmt.Errorf("Failed to list PodDisruptionBudgets: %v", err)
+	}
+
+	for i := range pdbList.Items {
+		found := &pdbList.Items[i]
+		pdb, ok := desired[found.Name]
+		if !ok {
+			logger.Info("Deleting PodDisruptionBudget", log.Ctx{"name": found.Name})
+			if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("Failed to delete PodDisruptionBudget %s: %v", found.Name, err)
+			}
+			continue
+		}
+		delete(desired, found.Name)
+
+		if reflect.DeepEqual(found.Spec.MaxUnavailable, pdb.Spec.MaxUnavailable) && reflect.DeepEqual(found.Spec.Selector, pdb.Spec.Selector) {
+			continue
+		}
+
+		logger.Info("Updating PodDisruptionBudget", log.Ctx{"name": found.Name, "maxUnavailable": pdb.Spec.MaxUnavailable.String()})
+		updated := found.DeepCopy()
+		updated.Spec.MaxUnavailable = pdb.Spec.MaxUnavailable
+		updated.Spec.Selector = pdb.Spec.Selector
+		err := r.client.Update(context.TODO(), updated, updateOption)
+		if err == nil {
+			continue
+		}
+		if !errors.IsInvalid(err) {
+			return fmt.Errorf("Failed to update PodDisruptionBudget %s: %v", found.Name, err)
+		}
+
+		// The spec of a budget cannot be updated before Kubernetes 1.15, so it is re-created.
+		// The pods have no budget until the create succeeds.
+		logger.Warn("Re-creating PodDisruptionBudget, the pods are unprotected until it is created", log.Ctx{"name": found.Name, "err": err})
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("Failed to delete PodDisruptionBudget %s: %v", found.Name, err)
+		}
+		if err := r.createPodDisruptionBudget(aeroCluster, pdb); err != nil {
+			logger.Error("PodDisruptionBudget deleted but not re-created, the pods are unprotected", log.Ctx{"name": found.Name, "err": err})
+			return err
+		}
+	}
+
+	for _, pdb := range desired {
+		logger.Info("Creating PodDisruptionBudget", log.Ctx{"name": pdb.Name, "maxUnavailable": pdb.Spec.MaxUnavailable.String()})
+		if err := r.createPodDisruptionBudget(aeroCluster, pdb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileAerospikeCluster) createPodDisruptionBudget(aeroCluster *aerospikev1alpha1.AerospikeCluster, pdb *policyv1beta1.PodDisruptionBudget) error {
+	// Set AerospikeCluster instance as the owner and controller
+	controllerutil.SetControllerReference(aeroCluster, pdb, r.scheme)
+
+	if err := r.client.Create(context.TODO(), pdb, createOption); err != nil {
+		return fmt.Errorf("Failed to create PodDisruptionBudget %s: %v", pdb.Name, err)
+	}
+	return nil
+}
+
+// getDesiredPodDisruptionBudgets returns the budgets of the spec by name.
+// A cluster budget has the cluster name and a rack budget has the name of the rack StatefulSet.
+func getDesiredPodDisruptionBudgets(aeroCluster *aerospikev1alpha1.AerospikeCluster) map[string]*policyv1beta1.PodDisruptionBudget {
+	spec := aeroCluster.Spec.PodDisruptionBudget
+	if spec == nil {
+		spec = &aerospikev1alpha1.AerospikePodDisruptionBudgetSpec{}
+	}
+	if spec.Disabled {
+		return nil
+	}
+
+	maxUnavailable := intstr.FromInt(getPodDisruptionBudgetMaxUnavailable(aeroCluster))
+
+	newBudget := func(name string, ls map[string]string) *policyv1beta1.PodDisruptionBudget {
+		return &policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: aeroCluster.Namespace,
+				Labels:    utils.LabelsForAerospikeCluster(aeroCluster.Name),
+			},
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &maxUnavailable,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: ls,
+				},
+			},
+		}
+	}
+
+	budgets := map[string]*policyv1beta1.PodDisruptionBudget{}
+	if getPodDisruptionBudgetScope(aeroCluster) == aerospikev1alpha1.AerospikePodDisruptionBudgetCluster {
+		budgets[aeroCluster.Name] = newBudget(aeroCluster.Name, utils.LabelsForAerospikeCluster(aeroCluster.Name))
+		return budgets
+	}
+
+	for _, rack := range aeroCluster.Spec.RackConfig.Racks {
+		name := getNamespacedNameForStatefulSet(aeroCluster, rack.ID).Name
+		budgets[name] = newBudget(name, utils.LabelsForAerospikeClusterRack(aeroCluster.Name, rack.ID))
+	}
+	return budgets
+}
+
+// getPodDisruptionBudgetScope returns the scope of the spec, or Cluster if not given.
+// Rack budgets are only created on request, they let each rack lose maxUnavailable pods at once which is only safe
+// if each rack holds a full copy of the data.
+func getPodDisruptionBudgetScope(aeroCluster *aerospikev1alpha1.AerospikeCluster) aerospikev1alpha1.AerospikePodDisruptionBudgetScope {
+	if spec := aeroCluster.Spec.PodDisruptionBudget; spec != nil && spec.Scope != "" {
+		return spec.Scope
+	}
+	return aerospikev1alpha1.AerospikePodDisruptionBudgetCluster
+}
+
+// getPodDisruptionBudgetMaxUnavailable returns the max unavailable pods of the spec, or one less than the lowest replication factor
+// so that an eviction never takes down all replicas of a partition. It is at least 1 so that the nodes can still be drained.
+func getPodDisruptionBudgetMaxUnavailable(aeroCluster *aerospikev1alpha1.AerospikeCluster) int {
+	if spec := aeroCluster.Spec.PodDisruptionBudget; spec != nil && spec.MaxUnavailable != nil {
+		return int(*spec.MaxUnavailable)
+	}
+
+	maxUnavailable := getMinReplicationFactor(aeroCluster) - 1
+	if maxUnavailable < 1 {
+		return 1
+	}
+	return maxUnavailable
+}
+
+// getMinReplicationFactor returns the lowest replication factor of the namespaces of the cluster and of the racks.
+func getMinReplicationFactor(aeroCluster *aerospikev1alpha1.AerospikeCluster) int {
+	minFactor := utils.GetMinReplicationFactor(aeroCluster.Spec.AerospikeConfig)
+	for _, rack := range aeroCluster.Spec.RackConfig.Racks {
+		if len(rack.AerospikeConfig) == 0 {
+			continue
+		}
+		if factor := utils.GetMinReplicationFactor(rack.AerospikeConfig); factor < minFactor {
+			minFactor = factor
+		}
+	}
+	return minFactor
+}
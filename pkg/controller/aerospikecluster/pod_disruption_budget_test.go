@@ -0,0 +1,170 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetDesiredPodDisruptionBudgets(t *testing.T) {
+	namespaces := func(factors ...int) aerospikev1alpha1.Values {
+		var confs []interface{}
+		for _, factor := range factors {
+			confs = append(confs, map[string]interface{}{"name": "test", "replication-factor": factor})
+		}
+		return aerospikev1alpha1.Values{"namespaces": confs}
+	}
+	maxUnavailable := func(value int32) *int32 {
+		return &value
+	}
+
+	budgetTests := []struct {
+		name               string
+		config             aerospikev1alpha1.Values
+		racks              int
+		spec               *aerospikev1alpha1.AerospikePodDisruptionBudgetSpec
+		wantNames          []string
+		wantMaxUnavailable int
+	}{
+		{"default", namespaces(2), 1, nil, []string{"aerocluster"}, 1},
+		{"default replication factor", nil, 1, nil, []string{"aerocluster"}, 1},
+		{"lowest replication factor", namespaces(3, 4), 1, nil, []string{"aerocluster"}, 2},
+		{"replication factor 1", namespaces(1), 1, nil, []string{"aerocluster"}, 1},
+		{"racks default to cluster", namespaces(3), 3, nil, []string{"aerocluster"}, 2},
+		{"rack scope", namespaces(3), 3, &aerospikev1alpha1.AerospikePodDisruptionBudgetSpec{Scope: aerospikev1alpha1.AerospikePodDisruptionBudgetRack},
+			[]string{"aerocluster-1", "aerocluster-2", "aerocluster-3"}, 2},
+		{"max unavailable", namespaces(3), 1, &aerospikev1alpha1.AerospikePodDisruptionBudgetSpec{MaxUnavailable: maxUnavailable(0)}, []string{"aerocluster"}, 0},
+		{"disabled", namespaces(3), 1, &aerospikev1alpha1.AerospikePodDisruptionBudgetSpec{Disabled: true}, nil, 0},
+	}
+
+	for _, tt := range budgetTests {
+		aeroCluster := newPlanTestCluster()
+		aeroCluster.Spec.AerospikeConfig = tt.config
+		aeroCluster.Spec.RackConfig.Racks = nil
+		for id := 1; id <= tt.racks; id++ {
+			aeroCluster.Spec.RackConfig.Racks = append(aeroCluster.Spec.RackConfig.Racks, aerospikev1alpha1.Rack{ID: id})
+		}
+		aeroCluster.Spec.PodDisruptionBudget = tt.spec
+
+		budgets := getDesiredPodDisruptionBudgets(aeroCluster)
+
+		var names []string
+		for name, pdb := range budgets {
+			names = append(names, name)
+			if got := pdb.Spec.MaxUnavailable.IntValue(); got != tt.wantMaxUnavailable {
+				t.Errorf("getDesiredPodDisruptionBudgets(%s) %s maxUnavailable = %d, want %d", tt.name, name, got, tt.wantMaxUnavailable)
+			}
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tt.wantNames) {
+			t.Errorf("getDesiredPodDisruptionBudgets(%s) = %v, want %v", tt.name, names, tt.wantNames)
+		}
+	}
+}
+
+func TestGetDesiredPodDisruptionBudgetsSelector(t *testing.T) {
+	aeroCluster := newPlanTestCluster()
+	if got, want := getDesiredPodDisruptionBudgets(aeroCluster)["aerocluster"].Spec.Selector.MatchLabels, utils.LabelsForAerospikeCluster("aerocluster"); !reflect.DeepEqual(got, want) {
+		t.Errorf("cluster budget selector = %v, want %v", got, want)
+	}
+
+	aeroCluster.Spec.PodDisruptionBudget = &aerospikev1alpha1.AerospikePodDisruptionBudgetSpec{Scope: aerospikev1alpha1.AerospikePodDisruptionBudgetRack}
+	if got, want := getDesiredPodDisruptionBudgets(aeroCluster)["aerocluster-1"].Spec.Selector.MatchLabels, utils.LabelsForAerospikeClusterRack("aerocluster", 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("rack budget selector = %v, want %v", got, want)
+	}
+}
+
+func TestReconcilePodDisruptionBudgets(t *testing.T) {
+	// Kubernetes before 1.15 rejects the spec updates of a budget
+	forbidden := errors.NewInvalid(schema.GroupKind{Group: "policy", Kind: "PodDisruptionBudget"}, "aerocluster",
+		field.ErrorList{field.Forbidden(field.NewPath("spec"), "updates to poddisruptionbudget spec are forbidden.")})
+
+	reconcileTests := []struct {
+		name        string
+		updateErr   error
+		createErr   error
+		wantErr     bool
+		wantUID     types.UID
+		wantDeleted bool
+	}{
+		{"updated in place", nil, nil, false, "pdb-uid", false},
+		{"re-created if the update is forbidden", forbidden, nil, false, "", false},
+		{"re-create failed", forbidden, fmt.Errorf("create failed"), true, "", true},
+		{"update failed", fmt.Errorf("update failed"), nil, true, "pdb-uid", false},
+	}
+
+	for _, tt := range reconcileTests {
+		aeroCluster := newPlanTestCluster()
+		want := getDesiredPodDisruptionBudgets(aeroCluster)["aerocluster"]
+
+		existing := want.DeepCopy()
+		existing.UID = "pdb-uid"
+		maxUnavailable := intstr.FromInt(want.Spec.MaxUnavailable.IntValue() + 1)
+		existing.Spec.MaxUnavailable = &maxUnavailable
+
+		c := &pdbWriteFailingClient{Client: newTestClient(t, existing), updateErr: tt.updateErr, createErr: tt.createErr}
+		r := &ReconcileAerospikeCluster{client: c, scheme: newTestScheme(t)}
+
+		err := r.reconcilePodDisruptionBudgets(aeroCluster)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("reconcilePodDisruptionBudgets(%s) error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+
+		found := &policyv1beta1.PodDisruptionBudget{}
+		err = c.Get(context.TODO(), types.NamespacedName{Name: "aerocluster", Namespace: "aerospike"}, found)
+		if tt.wantDeleted {
+			if !errors.IsNotFound(err) {
+				t.Errorf("reconcilePodDisruptionBudgets(%s) budget found, want deleted: %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("reconcilePodDisruptionBudgets(%s) budget not found: %v", tt.name, err)
+			continue
+		}
+		if found.UID != tt.wantUID {
+			t.Errorf("reconcilePodDisruptionBudgets(%s) uid = %q, want %q", tt.name, found.UID, tt.wantUID)
+		}
+		wantMaxUnavailable := want.Spec.MaxUnavailable
+		if tt.wantErr {
+			wantMaxUnavailable = existing.Spec.MaxUnavailable
+		}
+		if !reflect.DeepEqual(found.Spec.MaxUnavailable, wantMaxUnavailable) {
+			t.Errorf("reconcilePodDisruptionBudgets(%s) maxUnavailable = %v, want %v", tt.name, found.Spec.MaxUnavailable, wantMaxUnavailable)
+		}
+	}
+}
+
+// pdbWriteFailingClient fails the PodDisruptionBudget updates and creates with the given errors.
+type pdbWriteFailingClient struct {
+	client.Client
+	updateErr error
+	createErr error
+}
+
+func (c *pdbWriteFailingClient) Update(ctx context.Context, obj k8sRuntime.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(*policyv1beta1.PodDisruptionBudget); ok && c.updateErr != nil {
+		return c.updateErr
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *pdbWriteFailingClient) Create(ctx context.Context, obj k8sRuntime.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*policyv1beta1.PodDisruptionBudget); ok && c.createErr != nil {
+		return c.createErr
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
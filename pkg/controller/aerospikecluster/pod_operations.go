@@ -0,0 +1,430 @@
+package aerospikecluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/jsonpatch"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxPodOperationHistory is the number of pod operations kept in status.
+	maxPodOperationHistory = 10
+
+	podOperationMaxRetry      = 60
+	podOperationRetryInterval = time.Second * 5
+)
+
+// podOperationAnnotations are the pod operation annotations in the order the operations are run.
+var podOperationAnnotations = []struct {
+	annotation string
+	operation  aerospikev1alpha1.AerospikePodOperationType
+}{
+	{aerospikev1alpha1.RestartAnnotation, aerospikev1alpha1.AerospikePodOperationRestart},
+	{aerospikev1alpha1.ColdRestartAnnotation, aerospikev1alpha1.AerospikePodOperationColdRestart},
+	{aerospikev1alpha1.ReinitVolumesAnnotation, aerospikev1alpha1.AerospikePodOperationReinitVolumes},
+	{aerospikev1alpha1.ReplacePVCsAnnotation, aerospikev1alpha1.AerospikePodOperationReplacePVCs},
+}
+
+// podOperationRequest is a pod operation requested by annotation on the cluster, the pod or both.
+type podOperationRequest struct {
+	podName    string
+	annotation string
+	operation  aerospikev1alpha1.AerospikePodOperationType
+	onCluster  bool
+	onPod      bool
+}
+
+// reconcilePodOperations runs the pod operations requested by annotation, one pod at a time.
+// Completed and failed operations are recorded in status and their annotations are removed.
+func (r *ReconcileAerospikeCluster) reconcilePodOperations(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	requests, err := r.getPodOperationRequests(aeroCluster)
+	if err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		if err := r.runPodOperation(aeroCluster, request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPodOperationRequests returns the pod operations requested by annotation on the cluster and its pods.
+func (r *ReconcileAerospikeCluster) getPodOperationRequests(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]*podOperationRequest, error) {
+	var requests []*podOperationRequest
+	requestMap := map[string]*podOperationRequest{}
+
+	addRequest := func(podName, annotation string, operation aerospikev1alpha1.AerospikePodOperationType) *podOperationRequest {
+		key := annotation + "/" + podName
+		if request, ok := requestMap[key]; ok {
+			return request
+		}
+		request := &podOperationRequest{podName: podName, annotation: annotation, operation: operation}
+		requestMap[key] = request
+		requests = append(requests, request)
+		return request
+	}
+
+	for _, op := range podOperationAnnotations {
+		for _, podName := range splitPodNames(aeroCluster.Annotations[op.annotation]) {
+			addRequest(podName, op.annotation, op.operation).onCluster = true
+		}
+	}
+
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %v", err)
+	}
+	for _, pod := range podList.Items {
+		for _, op := range podOperationAnnotations {
+			if pod.Annotations[op.annotation] == "true" {
+				addRequest(pod.Name, op.annotation, op.operation).onPod = true
+			}
+		}
+	}
+	return requests, nil
+}
+
+// runPodOperation quiesces the pod, runs the operation and records the result in status.
+// The operation is retried in the next reconcile if the node is not safe to stop, otherwise it is not retried.
+func (r *ReconcileAerospikeCluster) runPodOperation(aeroCluster *aerospikev1alpha1.AerospikeCluster, request *podOperationRequest) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster), "podName": request.podName, "operation": request.operation})
+
+	operation := aerospikev1alpha1.AerospikePodOperation{
+		PodName:   request.podName,
+		Type:      request.operation,
+		Phase:     aerospikev1alpha1.AerospikePodOperationInProgress,
+		StartTime: metav1.Now(),
+	}
+
+	pod := &corev1.Pod{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: request.podName, Namespace: aeroCluster.Namespace}, pod)
+	if err == nil && pod.Labels[utils.ClusterNameLabel] != aeroCluster.Name {
+		err = fmt.Errorf("Pod %s does not belong to cluster %s", request.podName, aeroCluster.Name)
+	}
+
+	if err == nil && utils.IsPodRunning(pod) {
+		if err := r.waitForNodeSafeStopReady(aeroCluster, pod); err != nil {
+			operation.Message = fmt.Sprintf("Waiting for the node to be safe to stop: %v", err)
+			if statusErr := r.setPodOperationStatus(aeroCluster, operation); statusErr != nil {
+				return statusErr
+			}
+			return err
+		}
+	}
+
+	if err == nil {
+		if err = r.setPodOperationStatus(aeroCluster, operation); err != nil {
+			return err
+		}
+		logger.Info("Running pod operation")
+		err = r.executePodOperation(aeroCluster, pod, request.operation)
+	}
+
+	completionTime := metav1.Now()
+	operation.CompletionTime = &completionTime
+	operation.Phase = aerospikev1alpha1.AerospikePodOperationCompleted
+	if err != nil {
+		logger.Error("Pod operation failed", log.Ctx{"err": err})
+		operation.Phase = aerospikev1alpha1.AerospikePodOperationFailed
+		operation.Message = err.Error()
+	} else {
+		logger.Info("Pod operation completed")
+	}
+
+	if err := r.setPodOperationStatus(aeroCluster, operation); err != nil {
+		return err
+	}
+	return r.removePodOperationRequest(aeroCluster, request)
+}
+
+// executePodOperation runs the operation on the quiesced pod and waits for the pod to be ready again.
+func (r *ReconcileAerospikeCluster) executePodOperation(aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod, operation aerospikev1alpha1.AerospikePodOperationType) error {
+	isNewPod := func(p *corev1.Pod) bool {
+		return p.UID != pod.UID
+	}
+
+	switch operation {
+	case aerospikev1alpha1.AerospikePodOperationRestart:
+		// The container restarts in the same pod so the shared memory is kept
+		restartCount := getServerContainerRestartCount(pod)
+		if _, err := r.execInPod(pod, "aerospike-server", []string{"sh", "-c", "kill 1"}); err != nil {
+			return err
+		}
+		return r.waitForPodOperation(pod, func(p *corev1.Pod) bool {
+			return getServerContainerRestartCount(p) > restartCount
+		})
+
+	case aerospikev1alpha1.AerospikePodOperationColdRestart:
+		if err := r.deletePod(pod); err != nil {
+			return err
+		}
+		return r.waitForPodOperation(pod, isNewPod)
+
+	case aerospikev1alpha1.AerospikePodOperationReinitVolumes:
+		// The init container initializes the volumes not in the initialized volume paths
+		if err := r.clearInitializedVolumePaths(aeroCluster, pod.Name); err != nil {
+			return err
+		}
+		if err := r.deletePod(pod); err != nil {
+			return err
+		}
+		return r.waitForPodOperation(pod, isNewPod)
+
+	case aerospikev1alpha1.AerospikePodOperationReplacePVCs:
+		return r.replacePodPVCs(aeroCluster, pod)
+	}
+	return fmt.Errorf("Unknown pod operation %s", operation)
+}
+
+// replacePodPVCs deletes the pod and its PVCs. The StatefulSet recreates the pod with new PVCs.
+func (r *ReconcileAerospikeCluster) replacePodPVCs(aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster), "podName": pod.Name})
+
+	var pvcNames []string
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			pvcNames = append(pvcNames, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+
+	// PVCs are removed only after the pod using them is deleted
+	for _, pvcName := range pvcNames {
+		pvc := &corev1.PersistentVolumeClaim{}
+		pvc.Name = pvcName
+		pvc.Namespace = pod.Namespace
+		if err := r.client.Delete(context.TODO(), pvc); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("Failed to delete PVC %s: %v", pvcName, err)
+		}
+		logger.Info("PVC deleted", log.Ctx{"PVC": pvcName})
+	}
+
+	if err := r.clearInitializedVolumePaths(aeroCluster, pod.Name); err != nil {
+		return err
+	}
+	if err := r.deletePod(pod); err != nil {
+		return err
+	}
+
+	for _, pvcName := range pvcNames {
+		if err := r.waitForPVCDeleted(pvcName, pod.Namespace); err != nil {
+			return err
+		}
+	}
+
+	// The recreated pod is pending if it was created before the old PVCs were removed, delete it again to create new PVCs
+	newPod := &corev1.Pod{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, newPod); err == nil {
+		if newPod.UID != pod.UID && newPod.Status.Phase == corev1.PodPending {
+			logger.Info("Recreating pending pod to create new PVCs")
+			if err := r.deletePod(newPod); err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.waitForPodOperation(pod, func(p *corev1.Pod) bool {
+		return p.UID != pod.UID
+	})
+}
+
+func (r *ReconcileAerospikeCluster) waitForPVCDeleted(pvcName, namespace string) error {
+	for i := 0; i < podOperationMaxRetry; i++ {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvcName, Namespace: namespace}, pvc)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		time.Sleep(podOperationRetryInterval)
+	}
+	return fmt.Errorf("PVC %s is not deleted", pvcName)
+}
+
+// waitForPodOperation waits till the pod is restarted by the operation and is running and ready.
+func (r *ReconcileAerospikeCluster) waitForPodOperation(pod *corev1.Pod, isRestarted func(*corev1.Pod) bool) error {
+	for i := 0; i < podOperationMaxRetry; i++ {
+		time.Sleep(podOperationRetryInterval)
+
+		newPod := &corev1.Pod{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, newPod); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("Failed to get pod %s: %v", pod.Name, err)
+		}
+		if !isRestarted(newPod) {
+			continue
+		}
+		if err := utils.CheckPodFailed(newPod); err != nil {
+			return fmt.Errorf("Pod %s failed: %v", pod.Name, err)
+		}
+		if utils.IsPodRunningAndReady(newPod) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Pod %s is not running and ready after %v", pod.Name, podOperationRetryInterval*podOperationMaxRetry)
+}
+
+func (r *ReconcileAerospikeCluster) deletePod(pod *corev1.Pod) error {
+	if err := r.client.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete pod %s: %v", pod.Name, err)
+	}
+	return nil
+}
+
+// clearInitializedVolumePaths removes the initialized volume paths from the pod status so that the volumes are initialized again.
+func (r *ReconcileAerospikeCluster) clearInitializedVolumePaths(aeroCluster *aerospikev1alpha1.AerospikeCluster, podName string) error {
+	if _, ok := aeroCluster.Status.Pods[podName]; !ok {
+		return nil
+	}
+
+	patches := []jsonpatch.JsonPatchOperation{{
+		Operation: "replace",
+		Path:      "/status/pods/" + podName + "/initializedVolumePaths",
+		Value:     []string{},
+	}}
+	jsonpatchJSON, err := json.Marshal(patches)
+	if err != nil {
+		return fmt.Errorf("Error marshalling json patch: %v", err)
+	}
+
+	constantPatch := client.ConstantPatch(types.JSONPatchType, jsonpatchJSON)
+	if err := r.client.Status().Patch(context.TODO(), aeroCluster, constantPatch, client.FieldOwner(patchFieldOwner)); err != nil {
+		return fmt.Errorf("Error clearing initialized volumes of pod %s: %v", podName, err)
+	}
+	return nil
+}
+
+// setPodOperationStatus adds or updates the pod operation in status. Only the latest operations are kept.
+func (r *ReconcileAerospikeCluster) setPodOperationStatus(aeroCluster *aerospikev1alpha1.AerospikeCluster, operation aerospikev1alpha1.AerospikePodOperation) error {
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		operations := make([]aerospikev1alpha1.AerospikePodOperation, 0, len(status.PodOperations)+1)
+		for _, op := range status.PodOperations {
+			if op.PodName != operation.PodName || op.Type != operation.Type {
+				operations = append(operations, op)
+			}
+		}
+		operations = append(operations, operation)
+		if len(operations) > maxPodOperationHistory {
+			operations = operations[len(operations)-maxPodOperationHistory:]
+		}
+		status.PodOperations = operations
+	})
+}
+
+// removePodOperationRequest removes the pod from the operation annotation of the cluster and the pod.
+func (r *ReconcileAerospikeCluster) removePodOperationRequest(aeroCluster *aerospikev1alpha1.AerospikeCluster, request *podOperationRequest) error {
+	if request.onCluster {
+		latest := &aerospikev1alpha1.AerospikeCluster{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: aeroCluster.Name, Namespace: aeroCluster.Namespace}, latest); err != nil {
+			return err
+		}
+
+		var podNames []string
+		for _, podName := range splitPodNames(latest.Annotations[request.annotation]) {
+			if podName != request.podName {
+				podNames = append(podNames, podName)
+			}
+		}
+		var value interface{}
+		if len(podNames) != 0 {
+			value = strings.Join(podNames, ",")
+		}
+		if err := r.patchAnnotation(latest, request.annotation, value); err != nil {
+			return fmt.Errorf("Failed to remove pod %s from annotation %s: %v", request.podName, request.annotation, err)
+		}
+	}
+
+	if request.onPod {
+		pod := &corev1.Pod{}
+		pod.Name = request.podName
+		pod.Namespace = aeroCluster.Namespace
+		if err := r.patchAnnotation(pod, request.annotation, nil); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("Failed to remove annotation %s from pod %s: %v", request.annotation, request.podName, err)
+		}
+	}
+	return nil
+}
+
+// patchAnnotation sets the annotation of obj to value. The annotation is removed if value is nil.
+func (r *ReconcileAerospikeCluster) patchAnnotation(obj runtime.Object, key string, value interface{}) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{key: value},
+		},
+	}
+	patchJSON, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	return r.client.Patch(context.TODO(), obj, client.ConstantPatch(types.MergePatchType, patchJSON))
+}
+
+// execInPod runs the command in the container of the pod and returns the stdout.
+func (r *ReconcileAerospikeCluster) execInPod(pod *corev1.Pod, container string, command []string) (string, error) {
+	clientset, err := kubernetes.NewForConfig(r.kubeConfig)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create kubernetes client: %v", err)
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(r.kubeConfig, "POST", req.URL())
+	if err != nil {
+		return "", fmt.Errorf("Failed to exec in pod %s: %v", pod.Name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return "", fmt.Errorf("Failed to exec %v in pod %s: %v %s", command, pod.Name, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+func getServerContainerRestartCount(pod *corev1.Pod) int32 {
+	for _, ps := range pod.Status.ContainerStatuses {
+		if ps.Name == "aerospike-server" {
+			return ps.RestartCount
+		}
+	}
+	return 0
+}
+
+// splitPodNames returns the pod names of a comma separated annotation value.
+func splitPodNames(value string) []string {
+	var podNames []string
+	for _, podName := range strings.Split(value, ",") {
+		if podName = strings.TrimSpace(podName); podName != "" {
+			podNames = append(podNames, podName)
+		}
+	}
+	return podNames
+}
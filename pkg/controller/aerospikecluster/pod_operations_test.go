@@ -0,0 +1,124 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSplitPodNames(t *testing.T) {
+	nameTests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"aerocluster-1-0", []string{"aerocluster-1-0"}},
+		{" aerocluster-1-0, aerocluster-1-1 ,", []string{"aerocluster-1-0", "aerocluster-1-1"}},
+	}
+
+	for _, tt := range nameTests {
+		if got := splitPodNames(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPodNames(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetPodOperationRequests(t *testing.T) {
+	aeroCluster := &aerospikev1alpha1.AerospikeCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "aerocluster",
+			Namespace: "aerospike",
+			Annotations: map[string]string{
+				aerospikev1alpha1.RestartAnnotation:       "aerocluster-1-0,aerocluster-1-1",
+				aerospikev1alpha1.ReplacePVCsAnnotation:   "aerocluster-1-1",
+				aerospikev1alpha1.ReinitVolumesAnnotation: "",
+			},
+		},
+	}
+
+	pod := func(name string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "aerospike",
+			Labels:      utils.LabelsForAerospikeCluster("aerocluster"),
+			Annotations: annotations,
+		}}
+	}
+	r := &ReconcileAerospikeCluster{client: fake.NewFakeClient(
+		pod("aerocluster-1-0", map[string]string{aerospikev1alpha1.RestartAnnotation: "true", aerospikev1alpha1.ColdRestartAnnotation: "false"}),
+		pod("aerocluster-1-1", map[string]string{aerospikev1alpha1.ColdRestartAnnotation: "true"}),
+	)}
+
+	got, err := r.getPodOperationRequests(aeroCluster)
+	if err != nil {
+		t.Fatalf("getPodOperationRequests() error = %v", err)
+	}
+
+	// Requests on both the cluster and the pod are merged, the cluster requests come first in the operation order
+	want := []*podOperationRequest{
+		{"aerocluster-1-0", aerospikev1alpha1.RestartAnnotation, aerospikev1alpha1.AerospikePodOperationRestart, true, true},
+		{"aerocluster-1-1", aerospikev1alpha1.RestartAnnotation, aerospikev1alpha1.AerospikePodOperationRestart, true, false},
+		{"aerocluster-1-1", aerospikev1alpha1.ReplacePVCsAnnotation, aerospikev1alpha1.AerospikePodOperationReplacePVCs, true, false},
+		{"aerocluster-1-1", aerospikev1alpha1.ColdRestartAnnotation, aerospikev1alpha1.AerospikePodOperationColdRestart, false, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for _, request := range got {
+			t.Logf("got request %+v", *request)
+		}
+		t.Errorf("getPodOperationRequests() returned %d requests, want %d", len(got), len(want))
+	}
+}
+
+func TestRunPodOperationSafeStop(t *testing.T) {
+	safeStopTests := []struct {
+		name        string
+		phase       corev1.PodPhase
+		ready       corev1.ConditionStatus
+		wantMessage string
+	}{
+		// The readiness probe fails while the node is not in an integral cluster, the node may still serve
+		{"running not ready", corev1.PodRunning, corev1.ConditionFalse, "Waiting for the node to be safe to stop"},
+		{"pending", corev1.PodPending, corev1.ConditionFalse, "delete failed"},
+	}
+
+	for _, tt := range safeStopTests {
+		aeroCluster := newPlanTestCluster()
+		pod := newUpgradeTestPod("aerocluster-1-0", testTargetImage)
+		pod.Status.Phase = tt.phase
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: tt.ready}}
+
+		r := &ReconcileAerospikeCluster{client: &podDeleteFailingClient{Client: newTestClient(t, pod, aeroCluster.DeepCopy())}}
+		request := &podOperationRequest{podName: pod.Name, annotation: aerospikev1alpha1.ColdRestartAnnotation, operation: aerospikev1alpha1.AerospikePodOperationColdRestart}
+		r.runPodOperation(aeroCluster, request)
+
+		if len(aeroCluster.Status.PodOperations) != 1 {
+			t.Errorf("runPodOperation(%s) operations = %v, want 1", tt.name, aeroCluster.Status.PodOperations)
+			continue
+		}
+		if got := aeroCluster.Status.PodOperations[0].Message; !strings.Contains(got, tt.wantMessage) {
+			t.Errorf("runPodOperation(%s) message = %q, want %q", tt.name, got, tt.wantMessage)
+		}
+	}
+}
+
+// podDeleteFailingClient fails the pod deletes.
+type podDeleteFailingClient struct {
+	client.Client
+}
+
+func (c *podDeleteFailingClient) Delete(ctx context.Context, obj k8sRuntime.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*corev1.Pod); ok {
+		return fmt.Errorf("delete failed")
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
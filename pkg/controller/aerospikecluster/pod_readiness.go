@@ -0,0 +1,185 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultPodReadyTimeout  = time.Minute * 3
+	defaultColdStartTimeout = time.Hour * 4
+	defaultMigrationTimeout = time.Hour * 6
+
+	podReadyRetryInterval = time.Second * 5
+
+	podReadyTimeoutReason  = "PodReadyTimeout"
+	migrationTimeoutReason = "MigrationTimeout"
+)
+
+// waitForPodReady waits for the pod to be running and ready. The wait fails if the pod makes no progress for the pod ready timeout,
+// or is not ready within the cold start timeout, and sets the Degraded condition.
+func (r *ReconcileAerospikeCluster) waitForPodReady(aeroCluster *aerospikev1alpha1.AerospikeCluster, podName types.NamespacedName) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	podReadyTimeout, coldStartTimeout := getPodReadyTimeout(aeroCluster), getColdStartTimeout(aeroCluster)
+	logger.Info("Waiting for pod to be ready", log.Ctx{"podName": podName.Name, "podReadyTimeout": podReadyTimeout, "coldStartTimeout": coldStartTimeout})
+
+	progress := &podStartProgress{}
+	start := time.Now()
+	lastProgress := start
+	for {
+		time.Sleep(podReadyRetryInterval)
+
+		pod := &corev1.Pod{}
+		if err := r.client.Get(context.TODO(), podName, pod); err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("Failed to get pod %s: %v", podName.Name, err)
+			}
+			// The pod is being re-created
+			pod = nil
+		}
+
+		if pod != nil {
+			if err := utils.CheckPodFailed(pod); err != nil {
+				return fmt.Errorf("Pod %s failed: %v", podName.Name, err)
+			}
+			if utils.IsPodRunningAndReady(pod) {
+				logger.Info("Pod is running and ready", log.Ctx{"podName": podName.Name, "waitTime": time.Since(start).Round(time.Second)})
+				return nil
+			}
+			if progress.update(pod, func() (string, error) { return getPodStartStats(r, aeroCluster, pod) }) {
+				lastProgress = time.Now()
+				logger.Info("Pod is starting", log.Ctx{"podName": podName.Name, "progress": progress.String(), "waitTime": time.Since(start).Round(time.Second)})
+			}
+		}
+
+		if time.Since(lastProgress) > podReadyTimeout {
+			return r.setTimeoutCondition(aeroCluster, podReadyTimeoutReason, fmt.Sprintf("Pod %s made no progress to get ready in %v", podName.Name, podReadyTimeout))
+		}
+		if time.Since(start) > coldStartTimeout {
+			return r.setTimeoutCondition(aeroCluster, podReadyTimeoutReason, fmt.Sprintf("Pod %s is not ready in %v", podName.Name, coldStartTimeout))
+		}
+	}
+}
+
+// podStartProgress tracks the progress of a starting pod between polls.
+type podStartProgress struct {
+	coldStarting bool
+	stats        string
+}
+
+// update returns true if the pod made progress since the last poll. getStats returns the start stats of the server.
+// A server which does not answer info is cold starting, or unreachable, and makes no progress. A server answering info
+// makes progress when it starts answering, and while its cluster size or remaining migrations change, e.g. while it joins the cluster.
+func (p *podStartProgress) update(pod *corev1.Pod, getStats func() (string, error)) bool {
+	var status *corev1.ContainerStatus
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == pod.Spec.Containers[0].Name {
+			status = &pod.Status.ContainerStatuses[i]
+		}
+	}
+	if status == nil || status.State.Running == nil {
+		return false
+	}
+
+	stats, err := getStats()
+	if err != nil {
+		p.coldStarting = true
+		p.stats = ""
+		return false
+	}
+
+	changed := p.coldStarting || stats != p.stats
+	p.coldStarting = false
+	p.stats = stats
+	return changed
+}
+
+func (p *podStartProgress) String() string {
+	if p.coldStarting {
+		return "cold starting"
+	}
+	return p.stats
+}
+
+// getPodStartStats returns the cluster size and the remaining migrations of the node.
+func getPodStartStats(r *ReconcileAerospikeCluster, aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod) (string, error) {
+	asConn, err := r.newAsConn(aeroCluster, pod)
+	if err != nil {
+		return "", err
+	}
+	res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), asConn, "statistics")
+	if err != nil {
+		return "", err
+	}
+	stats, err := parseInfoIntoMap(res["statistics"], ";", "=")
+	if err != nil {
+		return "", err
+	}
+	clusterSize, err := getIntStat(stats, "cluster_size")
+	if err != nil {
+		return "", err
+	}
+	migrations, err := getIntStat(stats, "migrate_partitions_remaining")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cluster_size %d, migrate_partitions_remaining %d", clusterSize, migrations), nil
+}
+
+// setTimeoutCondition sets the Degraded condition for a timeout and returns the timeout error.
+func (r *ReconcileAerospikeCluster) setTimeoutCondition(aeroCluster *aerospikev1alpha1.AerospikeCluster, reason string, message string) error {
+	if err := r.setStatusCondition(aeroCluster, aerospikev1alpha1.AerospikeClusterCondition{
+		Type:    aerospikev1alpha1.AerospikeClusterDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	}); err != nil {
+		return fmt.Errorf("%s, failed to set Degraded condition: %v", message, err)
+	}
+	return fmt.Errorf("%s", message)
+}
+
+// clearTimeoutCondition clears the Degraded condition set by a timeout, once a reconcile has completed.
+func (r *ReconcileAerospikeCluster) clearTimeoutCondition(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	degraded := getClusterCondition(aeroCluster.Status.Conditions, aerospikev1alpha1.AerospikeClusterDegraded)
+	if degraded == nil || degraded.Status != corev1.ConditionTrue || (degraded.Reason != podReadyTimeoutReason && degraded.Reason != migrationTimeoutReason) {
+		return nil
+	}
+	return r.setStatusCondition(aeroCluster, aerospikev1alpha1.AerospikeClusterCondition{
+		Type:    aerospikev1alpha1.AerospikeClusterDegraded,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ReconcileCompleted",
+		Message: "All pods are ready",
+	})
+}
+
+func getPodReadyTimeout(aeroCluster *aerospikev1alpha1.AerospikeCluster) time.Duration {
+	if timeouts := aeroCluster.Spec.Timeouts; timeouts != nil && timeouts.PodReadySeconds > 0 {
+		return time.Duration(timeouts.PodReadySeconds) * time.Second
+	}
+	return defaultPodReadyTimeout
+}
+
+func getColdStartTimeout(aeroCluster *aerospikev1alpha1.AerospikeCluster) time.Duration {
+	if timeouts := aeroCluster.Spec.Timeouts; timeouts != nil && timeouts.ColdStartSeconds > 0 {
+		return time.Duration(timeouts.ColdStartSeconds) * time.Second
+	}
+	return defaultColdStartTimeout
+}
+
+func getMigrationTimeout(aeroCluster *aerospikev1alpha1.AerospikeCluster) time.Duration {
+	if timeouts := aeroCluster.Spec.Timeouts; timeouts != nil && timeouts.MigrationSeconds > 0 {
+		return time.Duration(timeouts.MigrationSeconds) * time.Second
+	}
+	return defaultMigrationTimeout
+}
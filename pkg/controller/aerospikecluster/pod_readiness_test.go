@@ -0,0 +1,54 @@
+package aerospikecluster
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodStartProgress(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "aerospike-server"}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "aerospike-server",
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}}},
+	}
+	waiting := pod.DeepCopy()
+	waiting.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}
+
+	stats := func(stats string) func() (string, error) {
+		return func() (string, error) { return stats, nil }
+	}
+	infoFailed := func() (string, error) { return "", fmt.Errorf("connection refused") }
+
+	// Polls of a single start, in order
+	progressTests := []struct {
+		name         string
+		pod          *corev1.Pod
+		getStats     func() (string, error)
+		want         bool
+		wantProgress string
+	}{
+		{"container waiting", waiting, stats("cluster_size 1, migrate_partitions_remaining 0"), false, ""},
+		{"info failed", pod, infoFailed, false, "cold starting"},
+		{"info failed again", pod, infoFailed, false, "cold starting"},
+		{"info answered", pod, stats("cluster_size 1, migrate_partitions_remaining 0"), true, "cluster_size 1, migrate_partitions_remaining 0"},
+		{"stats not changed", pod, stats("cluster_size 1, migrate_partitions_remaining 0"), false, "cluster_size 1, migrate_partitions_remaining 0"},
+		{"joined cluster", pod, stats("cluster_size 3, migrate_partitions_remaining 120"), true, "cluster_size 3, migrate_partitions_remaining 120"},
+		{"migrations", pod, stats("cluster_size 3, migrate_partitions_remaining 80"), true, "cluster_size 3, migrate_partitions_remaining 80"},
+		{"info failed after answering", pod, infoFailed, false, "cold starting"},
+		{"info answered after failing", pod, stats("cluster_size 3, migrate_partitions_remaining 80"), true, "cluster_size 3, migrate_partitions_remaining 80"},
+	}
+
+	progress := &podStartProgress{}
+	for _, tt := range progressTests {
+		if got := progress.update(tt.pod, tt.getStats); got != tt.want {
+			t.Errorf("update(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := progress.String(); got != tt.wantProgress {
+			t.Errorf("update(%s) progress = %q, want %q", tt.name, got, tt.wantProgress)
+		}
+	}
+}
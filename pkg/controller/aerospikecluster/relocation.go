@@ -0,0 +1,187 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileRackRelocation moves the pods running on nodes not matching the placement of their rack, one at a time.
+// A relocated pod is drained and re-created with new volumes, so that the volumes do not hold it on the old node.
+// The next pod is drained only after the migrations of the relocated pod are done.
+func (r *ReconcileAerospikeCluster) reconcileRackRelocation(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	pods, err := r.getMisplacedPods(aeroCluster)
+	if err != nil {
+		return err
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		logger.Info("Relocating pod not matching rack placement", log.Ctx{"podName": pod.Name, "nodeName": pod.Spec.NodeName})
+
+		if utils.IsPodRunning(pod) {
+			if err := r.waitForNodeSafeStopReady(aeroCluster, pod); err != nil {
+				return err
+			}
+		}
+
+		if err := r.replacePodPVCs(aeroCluster, pod); err != nil {
+			return fmt.Errorf("Failed to relocate pod %s: %v", pod.Name, err)
+		}
+		logger.Info("Relocated pod", log.Ctx{"podName": pod.Name})
+	}
+	return nil
+}
+
+// getMisplacedPods returns the scheduled pods whose node does not match the required node affinity of their rack.
+// The affinity is computed from the spec, so the pods are found before the StatefulSets are updated.
+// Pods on nodes missing a label of the affinity are not returned, the label may not be set by the Kubernetes version
+// and relocating would drop the pod volumes without moving it.
+func (r *ReconcileAerospikeCluster) getMisplacedPods(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]corev1.Pod, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	var pods []corev1.Pod
+	for _, state := range getNewRackStateList(aeroCluster) {
+		found := &appsv1.StatefulSet{}
+		if err := r.client.Get(context.TODO(), getNamespacedNameForStatefulSet(aeroCluster, state.Rack.ID), found); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		desired := found.DeepCopy()
+		updateStatefulSetAffinity(aeroCluster, desired, utils.LabelsForAerospikeClusterRack(aeroCluster.Name, state.Rack.ID), state)
+		affinity := desired.Spec.Template.Spec.Affinity
+		if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+			continue
+		}
+
+		podList, err := r.getOrderedRackPodList(aeroCluster, state.Rack.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list pods: %v", err)
+		}
+		for _, pod := range podList {
+			if utils.IsTerminating(&pod) || pod.Spec.NodeName == "" {
+				continue
+			}
+
+			node := &corev1.Node{}
+			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+				if errors.IsNotFound(err) {
+					// Pods on lost nodes are recovered by lost node recovery
+					continue
+				}
+				return nil, fmt.Errorf("Failed to get node %s: %v", pod.Spec.NodeName, err)
+			}
+
+			matches, err := nodeMatchesSelector(node, affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid node affinity of rack %d: %v", state.Rack.ID, err)
+			}
+			if matches {
+				continue
+			}
+			if key := getMissingNodeLabel(node, affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution); key != "" {
+				logger.Warn("Not relocating pod, node does not have a label of the rack placement", log.Ctx{"podName": pod.Name, "nodeName": node.Name, "label": key})
+				continue
+			}
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// nodeMatchesSelector returns true if the node matches any of the node selector terms.
+func nodeMatchesSelector(node *corev1.Node, nodeSelector *corev1.NodeSelector) (bool, error) {
+	for _, term := range nodeSelector.NodeSelectorTerms {
+		// An empty term matches no nodes
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+
+		selector, err := nodeSelectorRequirementsAsSelector(term.MatchExpressions)
+		if err != nil {
+			return false, err
+		}
+		if selector.Matches(labels.Set(node.Labels)) && nodeMatchesFields(node, term.MatchFields) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getMissingNodeLabel returns a label required by the node selector terms that the node does not have, or "" if it has
+// all the labels of a term. Labels with the NotIn and DoesNotExist operators are not required.
+func getMissingNodeLabel(node *corev1.Node, nodeSelector *corev1.NodeSelector) string {
+	missing := ""
+	for _, term := range nodeSelector.NodeSelectorTerms {
+		termMissing := ""
+		for _, requirement := range term.MatchExpressions {
+			if requirement.Operator == corev1.NodeSelectorOpNotIn || requirement.Operator == corev1.NodeSelectorOpDoesNotExist {
+				continue
+			}
+			if _, ok := node.Labels[requirement.Key]; !ok {
+				termMissing = requirement.Key
+				break
+			}
+		}
+		if termMissing == "" {
+			return ""
+		}
+		if missing == "" {
+			missing = termMissing
+		}
+	}
+	return missing
+}
+
+// nodeMatchesFields returns true if the node name matches the field requirements, metadata.name is the only supported field.
+func nodeMatchesFields(node *corev1.Node, requirements []corev1.NodeSelectorRequirement) bool {
+	for _, requirement := range requirements {
+		if requirement.Key != "metadata.name" {
+			return false
+		}
+		in := containsString(requirement.Values, node.Name)
+		if (requirement.Operator == corev1.NodeSelectorOpIn && !in) || (requirement.Operator == corev1.NodeSelectorOpNotIn && in) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeSelectorRequirementsAsSelector(requirements []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	operators := map[corev1.NodeSelectorOperator]selection.Operator{
+		corev1.NodeSelectorOpIn:           selection.In,
+		corev1.NodeSelectorOpNotIn:        selection.NotIn,
+		corev1.NodeSelectorOpExists:       selection.Exists,
+		corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+		corev1.NodeSelectorOpGt:           selection.GreaterThan,
+		corev1.NodeSelectorOpLt:           selection.LessThan,
+	}
+
+	selector := labels.NewSelector()
+	for _, requirement := range requirements {
+		op, ok := operators[requirement.Operator]
+		if !ok {
+			return nil, fmt.Errorf("Invalid node selector operator %s", requirement.Operator)
+		}
+		r, err := labels.NewRequirement(requirement.Key, op, requirement.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*r)
+	}
+	return selector, nil
+}
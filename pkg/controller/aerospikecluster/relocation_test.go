@@ -0,0 +1,71 @@
+package aerospikecluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetMissingNodeLabel(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "node-1",
+		Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east-1a"},
+	}}
+
+	labelTests := []struct {
+		requirements []corev1.NodeSelectorRequirement
+		wantMatch    bool
+		wantMissing  string
+	}{
+		{[]corev1.NodeSelectorRequirement{{Key: "failure-domain.beta.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}}}, true, ""},
+		{[]corev1.NodeSelectorRequirement{{Key: "failure-domain.beta.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1b"}}}, false, ""},
+		{[]corev1.NodeSelectorRequirement{{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}}}, false, "topology.kubernetes.io/zone"},
+		{[]corev1.NodeSelectorRequirement{{Key: "aerospike.com/rack-label", Operator: corev1.NodeSelectorOpExists}}, false, "aerospike.com/rack-label"},
+		{[]corev1.NodeSelectorRequirement{{Key: "aerospike.com/rack-label", Operator: corev1.NodeSelectorOpDoesNotExist}}, true, ""},
+	}
+
+	for _, tt := range labelTests {
+		nodeSelector := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: tt.requirements}}}
+		if got, err := nodeMatchesSelector(node, nodeSelector); err != nil || got != tt.wantMatch {
+			t.Errorf("nodeMatchesSelector(%v) = (%v, %v), want %v", tt.requirements, got, err, tt.wantMatch)
+		}
+		if got := getMissingNodeLabel(node, nodeSelector); got != tt.wantMissing {
+			t.Errorf("getMissingNodeLabel(%v) = %q, want %q", tt.requirements, got, tt.wantMissing)
+		}
+	}
+}
+
+func TestGetMissingNodeLabelTerms(t *testing.T) {
+	zone := func(key, value string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: key, Operator: corev1.NodeSelectorOpIn, Values: []string{value}}}}
+	}
+	// The rack zone is matched with the default label or the legacy label
+	nodeSelector := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+		zone("topology.kubernetes.io/zone", "us-east-1a"),
+		zone("failure-domain.beta.kubernetes.io/zone", "us-east-1a"),
+	}}
+
+	nodeTests := []struct {
+		name        string
+		labels      map[string]string
+		wantMatch   bool
+		wantMissing string
+	}{
+		{"default label", map[string]string{"topology.kubernetes.io/zone": "us-east-1a"}, true, ""},
+		{"legacy label", map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east-1a"}, true, ""},
+		{"other zone", map[string]string{"topology.kubernetes.io/zone": "us-east-1b"}, false, ""},
+		{"other legacy zone", map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east-1b"}, false, ""},
+		{"no zone labels", map[string]string{"kubernetes.io/hostname": "node-1"}, false, "topology.kubernetes.io/zone"},
+	}
+
+	for _, tt := range nodeTests {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: tt.labels}}
+		if got, err := nodeMatchesSelector(node, nodeSelector); err != nil || got != tt.wantMatch {
+			t.Errorf("nodeMatchesSelector(%s) = (%v, %v), want %v", tt.name, got, err, tt.wantMatch)
+		}
+		if got := getMissingNodeLabel(node, nodeSelector); got != tt.wantMissing {
+			t.Errorf("getMissingNodeLabel(%s) = %q, want %q", tt.name, got, tt.wantMissing)
+		}
+	}
+}
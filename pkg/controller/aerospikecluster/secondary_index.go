@@ -0,0 +1,262 @@
+package aerospikecluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+	as "github.com/ashishshinde/aerospike-client-go"
+	astypes "github.com/ashishshinde/aerospike-client-go/types"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// secondaryIndexRequeueInterval is the interval to requeue at while secondary indexes are building.
+const secondaryIndexRequeueInterval = 10 * time.Second
+
+// secondaryIndexReadWrite is the state of an index built on a node.
+const secondaryIndexReadWrite = "RW"
+
+// secondaryIndexWriteOnly is the state of an index building on a node.
+const secondaryIndexWriteOnly = "WO"
+
+// reconcileSecondaryIndexes creates the missing secondary indexes of the spec, re-creates the changed ones and drops the removed ones if
+// the drop policy is Drop. The build state of the indexes is reported in status. It returns true if some index is still building.
+func (r *ReconcileAerospikeCluster) reconcileSecondaryIndexes(aeroCluster *aerospikev1alpha1.AerospikeCluster) (bool, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	spec := aeroCluster.Spec.SecondaryIndexes
+	if spec == nil {
+		if len(aeroCluster.Status.SecondaryIndexStates) == 0 {
+			return false, nil
+		}
+		return false, r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+			status.SecondaryIndexStates = nil
+		})
+	}
+
+	pods, err := r.getReadyClusterPods(aeroCluster)
+	if err != nil {
+		return false, fmt.Errorf("Cannot reconcile secondary indexes: %v", err)
+	}
+	asConn, err := r.newAsConn(aeroCluster, &pods[0])
+	if err != nil {
+		return false, err
+	}
+	existing, err := getSecondaryIndexes(r.getClientPolicy(aeroCluster), asConn)
+	if err != nil {
+		return false, fmt.Errorf("Failed to get secondary indexes from pod %s: %v", pods[0].Name, err)
+	}
+
+	aeroClient, err := r.newAerospikeClient(aeroCluster)
+	if err != nil {
+		return false, err
+	}
+	defer aeroClient.Close()
+
+	var previous []aerospikev1alpha1.AerospikeSecondaryIndex
+	if aeroCluster.Status.SecondaryIndexes != nil {
+		previous = aeroCluster.Status.SecondaryIndexes.Indexes
+	}
+	drops, creates := getSecondaryIndexChanges(spec, previous, existing)
+	for _, index := range drops {
+		logger.Info("Dropping secondary index", log.Ctx{"namespace": index.Namespace, "index": index.Name})
+		if err := dropSecondaryIndex(aeroClient, index); err != nil {
+			return false, err
+		}
+	}
+	for _, index := range creates {
+		logger.Info("Creating secondary index", log.Ctx{"namespace": index.Namespace, "index": index.Name})
+		if err := createSecondaryIndex(aeroClient, index); err != nil {
+			return false, err
+		}
+	}
+
+	states, err := r.getSecondaryIndexStates(aeroCluster, pods, spec.Indexes)
+	if err != nil {
+		return false, err
+	}
+	building := false
+	for _, state := range states {
+		if state.State != secondaryIndexReadWrite {
+			building = true
+		}
+	}
+
+	return building, r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.SecondaryIndexStates = states
+	})
+}
+
+// getSecondaryIndexStates returns the build state of the indexes. The lowest load percent across the pods is reported.
+func (r *ReconcileAerospikeCluster) getSecondaryIndexStates(aeroCluster *aerospikev1alpha1.AerospikeCluster, pods []corev1.Pod, indexes []aerospikev1alpha1.AerospikeSecondaryIndex) ([]aerospikev1alpha1.AerospikeSecondaryIndexState, error) {
+	var states []aerospikev1alpha1.AerospikeSecondaryIndexState
+	for _, index := range indexes {
+		states = append(states, aerospikev1alpha1.AerospikeSecondaryIndexState{
+			Name:        index.Name,
+			Namespace:   index.Namespace,
+			State:       secondaryIndexReadWrite,
+			LoadPercent: 100,
+		})
+	}
+
+	for i := range pods {
+		asConn, err := r.newAsConn(aeroCluster, &pods[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var cmds []string
+		for _, index := range indexes {
+			cmds = append(cmds, fmt.Sprintf("sindex/%s/%s", index.Namespace, index.Name))
+		}
+		res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), asConn, cmds...)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get secondary index stats from pod %s: %v", pods[i].Name, err)
+		}
+
+		for j, cmd := range cmds {
+			stats, err := parseInfoIntoMap(res[cmd], ";", "=")
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse stats of secondary index %s: %v", indexes[j].Name, err)
+			}
+			// load_pct is not reported till the index is created on the node
+			loadPct, err := getIntStat(stats, "load_pct")
+			if err != nil {
+				return nil, err
+			}
+			if int32(loadPct) < states[j].LoadPercent {
+				states[j].LoadPercent = int32(loadPct)
+			}
+			if loadPct < 100 {
+				states[j].State = secondaryIndexWriteOnly
+			}
+		}
+	}
+	return states, nil
+}
+
+// getSecondaryIndexes returns the secondary indexes of all namespaces from the sindex-list info command.
+func getSecondaryIndexes(policy *as.ClientPolicy, asConn *deployment.ASConn) ([]aerospikev1alpha1.AerospikeSecondaryIndex, error) {
+	cmd := "sindex-list:"
+	res, err := deployment.RunInfo(policy, asConn, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []aerospikev1alpha1.AerospikeSecondaryIndex
+	for _, entry := range strings.Split(strings.TrimSpace(res[cmd]), ";") {
+		if entry == "" {
+			continue
+		}
+		info, err := parseInfoIntoMap(entry, ":", "=")
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse secondary index %s: %v", entry, err)
+		}
+		indexes = append(indexes, parseSecondaryIndex(info))
+	}
+	return indexes, nil
+}
+
+// parseSecondaryIndex returns the index of a sindex-list entry. Older servers report the bin as bins and the collection type as NONE.
+func parseSecondaryIndex(info map[string]interface{}) aerospikev1alpha1.AerospikeSecondaryIndex {
+	get := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := info[key].(string); ok && !strings.EqualFold(value, "NULL") {
+				return value
+			}
+		}
+		return ""
+	}
+
+	index := aerospikev1alpha1.AerospikeSecondaryIndex{
+		Name:      get("indexname"),
+		Namespace: get("ns"),
+		Set:       get("set"),
+		Bin:       get("bin", "bins"),
+		Type:      aerospikev1alpha1.AerospikeSecondaryIndexType(strings.ToLower(get("type"))),
+	}
+	if collectionType := strings.ToLower(get("indextype")); collectionType != "none" && collectionType != "default" {
+		index.CollectionType = aerospikev1alpha1.AerospikeSecondaryIndexCollectionType(collectionType)
+	}
+	return index
+}
+
+// getSecondaryIndexChanges returns the indexes to drop and to create, in order, to get from the existing indexes to the spec indexes.
+// Changed indexes are dropped and created again. The indexes removed from the spec since the previous reconcile are dropped only if the
+// drop policy is Drop.
+func getSecondaryIndexChanges(spec *aerospikev1alpha1.AerospikeSecondaryIndexSpec, previous, existing []aerospikev1alpha1.AerospikeSecondaryIndex) ([]aerospikev1alpha1.AerospikeSecondaryIndex, []aerospikev1alpha1.AerospikeSecondaryIndex) {
+	var drops, creates []aerospikev1alpha1.AerospikeSecondaryIndex
+
+	if spec.DropPolicy == aerospikev1alpha1.AerospikeSecondaryIndexDrop {
+		for _, index := range previous {
+			if getSecondaryIndex(spec.Indexes, index.Namespace, index.Name) != nil || getSecondaryIndex(existing, index.Namespace, index.Name) == nil {
+				continue
+			}
+			drops = append(drops, index)
+		}
+	}
+
+	for _, index := range spec.Indexes {
+		if current := getSecondaryIndex(existing, index.Namespace, index.Name); current != nil {
+			if isSameSecondaryIndex(*current, index) {
+				continue
+			}
+			drops = append(drops, *current)
+		}
+		creates = append(creates, index)
+	}
+	return drops, creates
+}
+
+func createSecondaryIndex(aeroClient *as.Client, index aerospikev1alpha1.AerospikeSecondaryIndex) error {
+	indexType := as.NUMERIC
+	switch index.Type {
+	case aerospikev1alpha1.AerospikeSecondaryIndexString:
+		indexType = as.STRING
+	case aerospikev1alpha1.AerospikeSecondaryIndexGeo2DSphere:
+		indexType = as.GEO2DSPHERE
+	}
+
+	collectionType := as.ICT_DEFAULT
+	switch index.CollectionType {
+	case aerospikev1alpha1.AerospikeSecondaryIndexCollectionList:
+		collectionType = as.ICT_LIST
+	case aerospikev1alpha1.AerospikeSecondaryIndexCollectionMapKeys:
+		collectionType = as.ICT_MAPKEYS
+	case aerospikev1alpha1.AerospikeSecondaryIndexCollectionMapValues:
+		collectionType = as.ICT_MAPVALUES
+	}
+
+	// The index is built in the background, the build progress is reported in status
+	if _, err := aeroClient.CreateComplexIndex(nil, index.Namespace, index.Set, index.Name, index.Bin, indexType, collectionType); err != nil {
+		if asErr, ok := err.(astypes.AerospikeError); ok && asErr.ResultCode() == astypes.INDEX_FOUND {
+			return nil
+		}
+		return fmt.Errorf("Failed to create secondary index %s in namespace %s: %v", index.Name, index.Namespace, err)
+	}
+	return nil
+}
+
+func dropSecondaryIndex(aeroClient *as.Client, index aerospikev1alpha1.AerospikeSecondaryIndex) error {
+	if err := aeroClient.DropIndex(nil, index.Namespace, index.Set, index.Name); err != nil {
+		return fmt.Errorf("Failed to drop secondary index %s in namespace %s: %v", index.Name, index.Namespace, err)
+	}
+	return nil
+}
+
+func getSecondaryIndex(indexes []aerospikev1alpha1.AerospikeSecondaryIndex, namespace, name string) *aerospikev1alpha1.AerospikeSecondaryIndex {
+	for i := range indexes {
+		if indexes[i].Namespace == namespace && indexes[i].Name == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func isSameSecondaryIndex(current, desired aerospikev1alpha1.AerospikeSecondaryIndex) bool {
+	return current.Set == desired.Set && current.Bin == desired.Bin && current.Type == desired.Type && current.CollectionType == desired.CollectionType
+}
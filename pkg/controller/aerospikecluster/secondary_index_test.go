@@ -0,0 +1,96 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+)
+
+func TestParseSecondaryIndex(t *testing.T) {
+	indexTests := []struct {
+		entry string
+		want  aerospikev1alpha1.AerospikeSecondaryIndex
+	}{
+		{
+			"ns=test:set=demo:indexname=age_idx:num_bins=1:bins=age:type=NUMERIC:indextype=NONE:path=age:state=RW",
+			aerospikev1alpha1.AerospikeSecondaryIndex{Name: "age_idx", Namespace: "test", Set: "demo", Bin: "age", Type: aerospikev1alpha1.AerospikeSecondaryIndexNumeric},
+		},
+		{
+			"ns=test:indexname=tags_idx:set=NULL:bin=tags:type=STRING:indextype=LIST:context=NULL:state=RW",
+			aerospikev1alpha1.AerospikeSecondaryIndex{Name: "tags_idx", Namespace: "test", Bin: "tags", Type: aerospikev1alpha1.AerospikeSecondaryIndexString, CollectionType: aerospikev1alpha1.AerospikeSecondaryIndexCollectionList},
+		},
+		{
+			"ns=test:indexname=loc_idx:set=places:bin=loc:type=GEO2DSPHERE:indextype=DEFAULT:state=WO",
+			aerospikev1alpha1.AerospikeSecondaryIndex{Name: "loc_idx", Namespace: "test", Set: "places", Bin: "loc", Type: aerospikev1alpha1.AerospikeSecondaryIndexGeo2DSphere},
+		},
+	}
+
+	for _, tt := range indexTests {
+		info, err := parseInfoIntoMap(tt.entry, ":", "=")
+		if err != nil {
+			t.Fatalf("parseInfoIntoMap(%s) error = %v", tt.entry, err)
+		}
+		if got := parseSecondaryIndex(info); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSecondaryIndex(%s) = %+v, want %+v", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestGetSecondaryIndexChanges(t *testing.T) {
+	index := func(name, bin string) aerospikev1alpha1.AerospikeSecondaryIndex {
+		return aerospikev1alpha1.AerospikeSecondaryIndex{Name: name, Namespace: "test", Bin: bin, Type: aerospikev1alpha1.AerospikeSecondaryIndexNumeric}
+	}
+	indexes := func(indexes ...aerospikev1alpha1.AerospikeSecondaryIndex) []aerospikev1alpha1.AerospikeSecondaryIndex {
+		return indexes
+	}
+
+	changeTests := []struct {
+		name        string
+		spec        aerospikev1alpha1.AerospikeSecondaryIndexSpec
+		previous    []aerospikev1alpha1.AerospikeSecondaryIndex
+		existing    []aerospikev1alpha1.AerospikeSecondaryIndex
+		wantDrops   []aerospikev1alpha1.AerospikeSecondaryIndex
+		wantCreates []aerospikev1alpha1.AerospikeSecondaryIndex
+	}{
+		{
+			name:     "no change",
+			spec:     aerospikev1alpha1.AerospikeSecondaryIndexSpec{Indexes: indexes(index("age_idx", "age"))},
+			previous: indexes(index("age_idx", "age")),
+			existing: indexes(index("age_idx", "age")),
+		},
+		{
+			name:        "missing index is created",
+			spec:        aerospikev1alpha1.AerospikeSecondaryIndexSpec{Indexes: indexes(index("age_idx", "age"), index("name_idx", "name"))},
+			existing:    indexes(index("age_idx", "age")),
+			wantCreates: indexes(index("name_idx", "name")),
+		},
+		{
+			name:        "changed index is re-created",
+			spec:        aerospikev1alpha1.AerospikeSecondaryIndexSpec{Indexes: indexes(index("age_idx", "years"))},
+			existing:    indexes(index("age_idx", "age")),
+			wantDrops:   indexes(index("age_idx", "age")),
+			wantCreates: indexes(index("age_idx", "years")),
+		},
+		{
+			name:     "removed index is retained",
+			spec:     aerospikev1alpha1.AerospikeSecondaryIndexSpec{DropPolicy: aerospikev1alpha1.AerospikeSecondaryIndexRetain},
+			previous: indexes(index("age_idx", "age")),
+			existing: indexes(index("age_idx", "age")),
+		},
+		{
+			name:      "removed index is dropped, out of band and already dropped indexes are left",
+			spec:      aerospikev1alpha1.AerospikeSecondaryIndexSpec{DropPolicy: aerospikev1alpha1.AerospikeSecondaryIndexDrop},
+			previous:  indexes(index("age_idx", "age"), index("name_idx", "name")),
+			existing:  indexes(index("age_idx", "age"), index("manual_idx", "manual")),
+			wantDrops: indexes(index("age_idx", "age")),
+		},
+	}
+
+	for _, tt := range changeTests {
+		drops, creates := getSecondaryIndexChanges(&tt.spec, tt.previous, tt.existing)
+		if !reflect.DeepEqual(drops, tt.wantDrops) || !reflect.DeepEqual(creates, tt.wantCreates) {
+			t.Errorf("getSecondaryIndexChanges(%s) = (%v, %v), want (%v, %v)", tt.name, drops, creates, tt.wantDrops, tt.wantCreates)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// clusterServiceSuffix is appended to the cluster name for the name of the cluster seed Service.
+	clusterServiceSuffix = "-client"
+
+	// lastAppliedAnnotationsKey is the annotation with the comma separated keys of the spec annotations applied to a Service,
+	// so that the annotations removed from the spec are removed from the Service without touching the annotations of others.
+	lastAppliedAnnotationsKey = "aerospike.com/last-applied-annotations"
+)
+
+// reconcileServices creates, updates or deletes the cluster seed Service and the per pod Services of the spec.
+func (r *ReconcileAerospikeCluster) reconcileServices(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	if err := r.reconcileClusterService(aeroCluster); err != nil {
+		return err
+	}
+	return r.reconcilePodServices(aeroCluster)
+}
+
+// reconcileClusterService creates or updates the cluster seed Service, or deletes it if it is not in the spec.
+func (r *ReconcileAerospikeCluster) reconcileClusterService(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	name := types.NamespacedName{Name: aeroCluster.Name + clusterServiceSuffix, Namespace: aeroCluster.Namespace}
+	found := &corev1.Service{}
+	if err := r.client.Get(context.TODO(), name, found); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("Failed to get cluster service %s: %v", name.Name, err)
+		}
+		found = nil
+	}
+
+	var spec *aerospikev1alpha1.AerospikeClusterServiceSpec
+	if aeroCluster.Spec.Services != nil {
+		spec = aeroCluster.Spec.Services.ClusterService
+	}
+	if spec == nil {
+		if found == nil || !metav1.IsControlledBy(found, aeroCluster) {
+			return nil
+		}
+		logger.Info("Deleting cluster service", log.Ctx{"name": name.Name})
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("Failed to delete cluster service %s: %v", name.Name, err)
+		}
+		return nil
+	}
+
+	desired := newClusterService(aeroCluster, name, spec)
+	if found == nil {
+		// Set AerospikeCluster instance as the owner and controller
+		controllerutil.SetControllerReference(aeroCluster, desired, r.scheme)
+
+		logger.Info("Creating cluster service", log.Ctx{"name": name.Name, "type": desired.Spec.Type})
+		if err := r.client.Create(context.TODO(), desired, createOption); err != nil {
+			return fmt.Errorf("Failed to create cluster service %s: %v", name.Name, err)
+		}
+		return nil
+	}
+
+	if updateClientService(found, desired) {
+		logger.Info("Updating cluster service", log.Ctx{"name": name.Name, "type": desired.Spec.Type})
+		if err := r.client.Update(context.TODO(), found, updateOption); err != nil {
+			return fmt.Errorf("Failed to update cluster service %s: %v", name.Name, err)
+		}
+	}
+	return nil
+}
+
+// reconcilePodServices creates the missing per pod Services and updates the existing ones to the spec,
+// e.g. converts NodePort Services to LoadBalancer Services when the pod service is enabled and back when it is disabled.
+// The Services not needed without the pod service and MultiPodPerHost are deleted.
+func (r *ReconcileAerospikeCluster) reconcilePodServices(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	for _, pod := range podList.Items {
+		found := &corev1.Service{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, found); err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("Failed to get service for pod %s: %v", pod.Name, err)
+			}
+			if getPodServiceSpec(aeroCluster) != nil {
+				logger.Info("Creating service for pod", log.Ctx{"podName": pod.Name})
+				if err := r.createServiceForPod(aeroCluster, pod.Name, pod.Namespace); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !metav1.IsControlledBy(found, aeroCluster) {
+			continue
+		}
+
+		if !needsServiceForPod(aeroCluster) {
+			logger.Info("Deleting service for pod", log.Ctx{"podName": pod.Name})
+			if err := r.deleteServiceForPod(pod.Name, pod.Namespace); err != nil {
+				return err
+			}
+			continue
+		}
+
+		desired := newServiceForPod(aeroCluster, pod.Name, pod.Namespace)
+		if updateClientService(found, desired) {
+			logger.Info("Updating service for pod", log.Ctx{"podName": pod.Name, "type": desired.Spec.Type})
+			if err := r.client.Update(context.TODO(), found, updateOption); err != nil {
+				return fmt.Errorf("Failed to update service for pod %s: %v", pod.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newClusterService returns the cluster seed Service. It selects the ready pods of all racks.
+func newClusterService(aeroCluster *aerospikev1alpha1.AerospikeCluster, name types.NamespacedName, spec *aerospikev1alpha1.AerospikeClusterServiceSpec) *corev1.Service {
+	ls := utils.LabelsForAerospikeCluster(aeroCluster.Name)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name.Name,
+			Namespace:   name.Namespace,
+			Labels:      ls,
+			Annotations: getServiceAnnotations(spec.Annotations),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: ls,
+			Ports:    getClientServicePorts(aeroCluster),
+		},
+	}
+	if spec.Type == corev1.ServiceTypeLoadBalancer {
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+		service.Spec.LoadBalancerSourceRanges = spec.LoadBalancerSourceRanges
+	}
+	return service
+}
+
+// getClientServicePorts returns the service port and the TLS service port if TLS is enabled.
+func getClientServicePorts(aeroCluster *aerospikev1alpha1.AerospikeCluster) []corev1.ServicePort {
+	ports := []corev1.ServicePort{
+		{
+			Name:       "info",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       utils.ServicePort,
+			TargetPort: intstr.FromInt(utils.ServicePort),
+		},
+	}
+	if name := getServiceTLSName(aeroCluster); name != "" {
+		ports = append(ports, corev1.ServicePort{
+			Name:       "tls",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       utils.ServiceTLSPort,
+			TargetPort: intstr.FromInt(utils.ServiceTLSPort),
+		})
+	}
+	return ports
+}
+
+// getServiceAnnotations returns a copy of the spec annotations of a Service with the last applied annotations key, or nil if there are none.
+func getServiceAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	serviceAnnotations := map[string]string{}
+	var keys []string
+	for key, value := range annotations {
+		serviceAnnotations[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	serviceAnnotations[lastAppliedAnnotationsKey] = strings.Join(keys, ",")
+	return serviceAnnotations
+}
+
+// updateClientService updates the type, the annotations, the source ranges and the ports of the service to the desired service.
+// The annotations applied before and not desired any more are removed, the other annotations are kept.
+// The allocated node ports are kept, so that the clients and the advertised addresses of the pods sharing a host keep working.
+// Returns true if the service is changed.
+func updateClientService(service *corev1.Service, desired *corev1.Service) bool {
+	changed := false
+	if service.Spec.Type != desired.Spec.Type {
+		service.Spec.Type = desired.Spec.Type
+		changed = true
+	}
+
+	if lastApplied, ok := service.Annotations[lastAppliedAnnotationsKey]; ok {
+		for _, key := range append(strings.Split(lastApplied, ","), lastAppliedAnnotationsKey) {
+			if _, ok := desired.Annotations[key]; !ok {
+				if _, ok := service.Annotations[key]; ok {
+					delete(service.Annotations, key)
+					changed = true
+				}
+			}
+		}
+	}
+
+	for key, value := range desired.Annotations {
+		if current, ok := service.Annotations[key]; !ok || current != value {
+			if service.Annotations == nil {
+				service.Annotations = map[string]string{}
+			}
+			service.Annotations[key] = value
+			changed = true
+		}
+	}
+
+	if !reflect.DeepEqual(service.Spec.LoadBalancerSourceRanges, desired.Spec.LoadBalancerSourceRanges) {
+		service.Spec.LoadBalancerSourceRanges = desired.Spec.LoadBalancerSourceRanges
+		changed = true
+	}
+
+	ports := make([]corev1.ServicePort, len(desired.Spec.Ports))
+	copy(ports, desired.Spec.Ports)
+	if desired.Spec.Type != corev1.ServiceTypeClusterIP {
+		for i := range ports {
+			for _, port := range service.Spec.Ports {
+				if port.Name == ports[i].Name {
+					ports[i].NodePort = port.NodePort
+				}
+			}
+		}
+	}
+	if !reflect.DeepEqual(service.Spec.Ports, ports) {
+		service.Spec.Ports = ports
+		changed = true
+	}
+
+	// The external traffic policy is only valid for node port and load balancer services
+	if desired.Spec.Type == corev1.ServiceTypeClusterIP {
+		if service.Spec.ExternalTrafficPolicy != "" {
+			service.Spec.ExternalTrafficPolicy = ""
+			service.Spec.HealthCheckNodePort = 0
+			changed = true
+		}
+	} else if desired.Spec.ExternalTrafficPolicy != "" && service.Spec.ExternalTrafficPolicy != desired.Spec.ExternalTrafficPolicy {
+		service.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+		changed = true
+	}
+	return changed
+}
+
+// getPodServiceSpec returns the per pod LoadBalancer Service spec, nil if the pod service is not enabled.
+func getPodServiceSpec(aeroCluster *aerospikev1alpha1.AerospikeCluster) *aerospikev1alpha1.AerospikePodServiceSpec {
+	if aeroCluster.Spec.Services == nil {
+		return nil
+	}
+	return aeroCluster.Spec.Services.PodService
+}
+
+// needsServiceForPod returns true if the pods need a Service each, to map the ports of the pods sharing a host
+// or to reach the pods through a load balancer.
+func needsServiceForPod(aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	return aeroCluster.Spec.MultiPodPerHost || getPodServiceSpec(aeroCluster) != nil
+}
@@ -0,0 +1,114 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testLBAnnotation = "service.beta.kubernetes.io/aws-load-balancer-internal"
+
+func newServiceTestCluster(podService *aerospikev1alpha1.AerospikePodServiceSpec) *aerospikev1alpha1.AerospikeCluster {
+	aeroCluster := newPlanTestCluster()
+	aeroCluster.Spec.MultiPodPerHost = true
+	if podService != nil {
+		aeroCluster.Spec.Services = &aerospikev1alpha1.AerospikeServicesSpec{PodService: podService}
+	}
+	return aeroCluster
+}
+
+func TestNewServiceForPod(t *testing.T) {
+	serviceTests := []struct {
+		name            string
+		podService      *aerospikev1alpha1.AerospikePodServiceSpec
+		wantType        corev1.ServiceType
+		wantAnnotations map[string]string
+		wantRanges      []string
+	}{
+		{"node port", nil, corev1.ServiceTypeNodePort, nil, nil},
+		{"load balancer", &aerospikev1alpha1.AerospikePodServiceSpec{
+			Annotations:              map[string]string{testLBAnnotation: "true"},
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+		}, corev1.ServiceTypeLoadBalancer, map[string]string{testLBAnnotation: "true", lastAppliedAnnotationsKey: testLBAnnotation}, []string{"10.0.0.0/8"}},
+	}
+
+	for _, tt := range serviceTests {
+		service := newServiceForPod(newServiceTestCluster(tt.podService), "aerocluster-1-0", "aerospike")
+		if service.Spec.Type != tt.wantType {
+			t.Errorf("newServiceForPod(%s) type = %s, want %s", tt.name, service.Spec.Type, tt.wantType)
+		}
+		if !reflect.DeepEqual(service.Annotations, tt.wantAnnotations) {
+			t.Errorf("newServiceForPod(%s) annotations = %v, want %v", tt.name, service.Annotations, tt.wantAnnotations)
+		}
+		if !reflect.DeepEqual(service.Spec.LoadBalancerSourceRanges, tt.wantRanges) {
+			t.Errorf("newServiceForPod(%s) source ranges = %v, want %v", tt.name, service.Spec.LoadBalancerSourceRanges, tt.wantRanges)
+		}
+		if want := map[string]string{"statefulset.kubernetes.io/pod-name": "aerocluster-1-0"}; !reflect.DeepEqual(service.Spec.Selector, want) {
+			t.Errorf("newServiceForPod(%s) selector = %v, want %v", tt.name, service.Spec.Selector, want)
+		}
+	}
+
+	// The service annotations do not alias the spec
+	podService := &aerospikev1alpha1.AerospikePodServiceSpec{Annotations: map[string]string{testLBAnnotation: "true"}}
+	newServiceForPod(newServiceTestCluster(podService), "aerocluster-1-0", "aerospike").Annotations["other"] = "value"
+	if _, ok := podService.Annotations["other"]; ok {
+		t.Errorf("newServiceForPod() annotations alias the pod service spec annotations")
+	}
+}
+
+func TestUpdateClientService(t *testing.T) {
+	loadBalancer := &aerospikev1alpha1.AerospikePodServiceSpec{
+		Annotations:              map[string]string{testLBAnnotation: "true", "service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+		LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+	}
+
+	updateTests := []struct {
+		name            string
+		from            *aerospikev1alpha1.AerospikePodServiceSpec
+		to              *aerospikev1alpha1.AerospikePodServiceSpec
+		wantChanged     bool
+		wantAnnotations map[string]string
+	}{
+		{"not changed", loadBalancer, loadBalancer, false, map[string]string{
+			testLBAnnotation: "true", "service.beta.kubernetes.io/aws-load-balancer-type": "nlb", "other": "value",
+			lastAppliedAnnotationsKey: "service.beta.kubernetes.io/aws-load-balancer-internal,service.beta.kubernetes.io/aws-load-balancer-type",
+		}},
+		{"annotation removed", loadBalancer, &aerospikev1alpha1.AerospikePodServiceSpec{Annotations: map[string]string{testLBAnnotation: "false"}}, true, map[string]string{
+			testLBAnnotation: "false", "other": "value", lastAppliedAnnotationsKey: testLBAnnotation,
+		}},
+		{"load balancer to node port", loadBalancer, nil, true, map[string]string{"other": "value"}},
+		{"node port to load balancer", nil, loadBalancer, true, map[string]string{
+			testLBAnnotation: "true", "service.beta.kubernetes.io/aws-load-balancer-type": "nlb", "other": "value",
+			lastAppliedAnnotationsKey: "service.beta.kubernetes.io/aws-load-balancer-internal,service.beta.kubernetes.io/aws-load-balancer-type",
+		}},
+	}
+
+	for _, tt := range updateTests {
+		service := newServiceForPod(newServiceTestCluster(tt.from), "aerocluster-1-0", "aerospike")
+		// Annotations set by others and the allocated node ports are kept
+		if service.Annotations == nil {
+			service.Annotations = map[string]string{}
+		}
+		service.Annotations["other"] = "value"
+		service.Spec.Ports[0].NodePort = 30000
+
+		desired := newServiceForPod(newServiceTestCluster(tt.to), "aerocluster-1-0", "aerospike")
+		if got := updateClientService(service, desired); got != tt.wantChanged {
+			t.Errorf("updateClientService(%s) = %v, want %v", tt.name, got, tt.wantChanged)
+		}
+		if service.Spec.Type != desired.Spec.Type {
+			t.Errorf("updateClientService(%s) type = %s, want %s", tt.name, service.Spec.Type, desired.Spec.Type)
+		}
+		if !reflect.DeepEqual(service.Annotations, tt.wantAnnotations) {
+			t.Errorf("updateClientService(%s) annotations = %v, want %v", tt.name, service.Annotations, tt.wantAnnotations)
+		}
+		if !reflect.DeepEqual(service.Spec.LoadBalancerSourceRanges, desired.Spec.LoadBalancerSourceRanges) {
+			t.Errorf("updateClientService(%s) source ranges = %v, want %v", tt.name, service.Spec.LoadBalancerSourceRanges, desired.Spec.LoadBalancerSourceRanges)
+		}
+		if got := service.Spec.Ports[0].NodePort; got != 30000 {
+			t.Errorf("updateClientService(%s) node port = %d, want allocated node port 30000", tt.name, got)
+		}
+	}
+}
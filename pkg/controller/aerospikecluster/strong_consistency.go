@@ -0,0 +1,248 @@
+package aerospikecluster
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	clusterStableMaxRetry      = 30
+	clusterStableRetryInterval = time.Second * 5
+)
+
+// reconcileRoster sets the roster of the strong consistency namespaces to the nodes observed in the cluster and reports the roster state in status.
+// The roster is updated only when all pods are ready and the cluster is stable, so that down nodes are not dropped from the roster.
+func (r *ReconcileAerospikeCluster) reconcileRoster(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	namespaces := getStrongConsistencyNamespaces(aeroCluster)
+	if len(namespaces) == 0 {
+		if len(aeroCluster.Status.Rosters) == 0 {
+			return nil
+		}
+		return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+			status.Rosters = nil
+		})
+	}
+
+	pods, err := r.getReadyClusterPods(aeroCluster)
+	if err != nil {
+		return fmt.Errorf("Cannot update roster: %v", err)
+	}
+	if err := r.waitForClusterStable(aeroCluster, pods); err != nil {
+		return fmt.Errorf("Cannot update roster: %v", err)
+	}
+
+	var rosters []aerospikev1alpha1.AerospikeNamespaceRoster
+	for _, namespace := range namespaces {
+		roster, err := r.getNamespaceRoster(aeroCluster, &pods[0], namespace)
+		if err != nil {
+			return err
+		}
+
+		if needsRosterUpdate(roster) {
+			logger.Info("Setting roster to the observed nodes", log.Ctx{"namespace": namespace, "roster": roster.Roster, "observedNodes": roster.ObservedNodes})
+			if err := r.setNamespaceRoster(aeroCluster, pods, namespace, roster.ObservedNodes); err != nil {
+				return err
+			}
+			if roster, err = r.getNamespaceRoster(aeroCluster, &pods[0], namespace); err != nil {
+				return err
+			}
+		}
+		rosters = append(rosters, *roster)
+	}
+
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.Rosters = rosters
+	})
+}
+
+// checkSCPartitionsAvailable returns an error if a strong consistency namespace has unavailable or dead partitions on any node.
+// Removing a node in this state can make the partitions dead.
+func (r *ReconcileAerospikeCluster) checkSCPartitionsAvailable(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	namespaces := getStrongConsistencyNamespaces(aeroCluster)
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	allHostConns, err := r.newAllHostConn(aeroCluster)
+	if err != nil {
+		return fmt.Errorf("Failed to get hostConn for aerospike cluster nodes: %v", err)
+	}
+	for _, hostConn := range allHostConns {
+		for _, namespace := range namespaces {
+			if err := r.checkPartitionsAvailable(aeroCluster, hostConn, namespace); err != nil {
+				return fmt.Errorf("Cannot remove nodes: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// getNamespaceRoster returns the roster and partition availability of the namespace as seen by the pod.
+func (r *ReconcileAerospikeCluster) getNamespaceRoster(aeroCluster *aerospikev1alpha1.AerospikeCluster, pod *corev1.Pod, namespace string) (*aerospikev1alpha1.AerospikeNamespaceRoster, error) {
+	asConn, err := r.newAsConn(aeroCluster, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	rosterCmd := "roster:namespace=" + namespace
+	statsCmd := "namespace/" + namespace
+	res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), asConn, rosterCmd, statsCmd)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get roster of namespace %s from pod %s: %v", namespace, pod.Name, err)
+	}
+
+	rosterInfo, err := parseInfoIntoMap(res[rosterCmd], ":", "=")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse roster of namespace %s: %v", namespace, err)
+	}
+	stats, err := parseInfoIntoMap(res[statsCmd], ";", "=")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse stats of namespace %s: %v", namespace, err)
+	}
+
+	roster := &aerospikev1alpha1.AerospikeNamespaceRoster{
+		Namespace:     namespace,
+		Roster:        splitRosterNodes(rosterInfo["roster"]),
+		PendingRoster: splitRosterNodes(rosterInfo["pending_roster"]),
+		ObservedNodes: splitRosterNodes(rosterInfo["observed_nodes"]),
+	}
+	if roster.UnavailablePartitions, err = getIntStat(stats, "unavailable_partitions"); err != nil {
+		return nil, err
+	}
+	if roster.DeadPartitions, err = getIntStat(stats, "dead_partitions"); err != nil {
+		return nil, err
+	}
+	return roster, nil
+}
+
+// setNamespaceRoster sets the pending roster of the namespace on all pods and reclusters to make it active.
+func (r *ReconcileAerospikeCluster) setNamespaceRoster(aeroCluster *aerospikev1alpha1.AerospikeCluster, pods []corev1.Pod, namespace string, nodes []string) error {
+	rosterSetCmd := fmt.Sprintf("roster-set:namespace=%s;nodes=%s", namespace, strings.Join(nodes, ","))
+	if err := r.runInfoOnPods(aeroCluster, pods, rosterSetCmd, func(res string) bool {
+		return strings.EqualFold(res, "ok")
+	}); err != nil {
+		return err
+	}
+
+	// Only the principal node reclusters, the others ignore it
+	return r.runInfoOnPods(aeroCluster, pods, "recluster:", func(res string) bool {
+		return !strings.HasPrefix(strings.ToUpper(res), "ERROR")
+	})
+}
+
+// waitForClusterStable waits till all the pods see a stable cluster of all the pods. Migrations are ignored.
+func (r *ReconcileAerospikeCluster) waitForClusterStable(aeroCluster *aerospikev1alpha1.AerospikeCluster, pods []corev1.Pod) error {
+	cmd := fmt.Sprintf("cluster-stable:size=%d;ignore-migrations=yes", len(pods))
+
+	var err error
+	for i := 0; i < clusterStableMaxRetry; i++ {
+		err = r.runInfoOnPods(aeroCluster, pods, cmd, func(res string) bool {
+			return !strings.HasPrefix(strings.ToUpper(res), "ERROR")
+		})
+		if err == nil {
+			return nil
+		}
+		time.Sleep(clusterStableRetryInterval)
+	}
+	return fmt.Errorf("Cluster of %d pods is not stable: %v", len(pods), err)
+}
+
+// runInfoOnPods runs the info command on the pods and returns an error if a result is not ok.
+func (r *ReconcileAerospikeCluster) runInfoOnPods(aeroCluster *aerospikev1alpha1.AerospikeCluster, pods []corev1.Pod, cmd string, isOk func(res string) bool) error {
+	for i := range pods {
+		asConn, err := r.newAsConn(aeroCluster, &pods[i])
+		if err != nil {
+			return err
+		}
+		res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), asConn, cmd)
+		if err != nil {
+			return fmt.Errorf("Failed to run %s on pod %s: %v", cmd, pods[i].Name, err)
+		}
+		if result := strings.TrimSpace(res[cmd]); !isOk(result) {
+			return fmt.Errorf("Failed to run %s on pod %s: %s", cmd, pods[i].Name, result)
+		}
+	}
+	return nil
+}
+
+// getReadyClusterPods returns the pods of the cluster. It returns an error if any pod is not running and ready.
+func (r *ReconcileAerospikeCluster) getReadyClusterPods(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]corev1.Pod, error) {
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if utils.IsTerminating(&pod) {
+			continue
+		}
+		if !utils.IsPodRunningAndReady(&pod) {
+			return nil, fmt.Errorf("Pod %s is not running and ready", pod.Name)
+		}
+		pods = append(pods, pod)
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("Pod list empty")
+	}
+	return pods, nil
+}
+
+// getStrongConsistencyNamespaces returns the strong consistency namespaces of the cluster and rack config.
+func getStrongConsistencyNamespaces(aeroCluster *aerospikev1alpha1.AerospikeCluster) []string {
+	namespaces := utils.GetStrongConsistencyNamespaces(aeroCluster.Spec.AerospikeConfig)
+	for _, rack := range aeroCluster.Spec.RackConfig.Racks {
+		for _, namespace := range utils.GetStrongConsistencyNamespaces(rack.AerospikeConfig) {
+			if !containsString(namespaces, namespace) {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+	return namespaces
+}
+
+// splitRosterNodes returns the nodes of a roster info value. The value is "null" for an empty roster.
+func splitRosterNodes(value interface{}) []string {
+	str, _ := value.(string)
+	if str == "" || str == "null" {
+		return nil
+	}
+	return strings.Split(str, ",")
+}
+
+// needsRosterUpdate returns true if the roster differs from the observed nodes. An empty observed node list is not set as the roster.
+func needsRosterUpdate(roster *aerospikev1alpha1.AerospikeNamespaceRoster) bool {
+	return len(roster.ObservedNodes) != 0 && !isSameNodeList(roster.Roster, roster.ObservedNodes)
+}
+
+func isSameNodeList(nodes1, nodes2 []string) bool {
+	sorted1 := append([]string{}, nodes1...)
+	sorted2 := append([]string{}, nodes2...)
+	sort.Strings(sorted1)
+	sort.Strings(sorted2)
+	return reflect.DeepEqual(sorted1, sorted2)
+}
+
+func getIntStat(stats map[string]interface{}, name string) (int64, error) {
+	value, ok := stats[name].(string)
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s %s: %v", name, value, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,136 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetIntStat(t *testing.T) {
+	stats := map[string]interface{}{"dead_partitions": "0", "unavailable_partitions": "12", "migrate_partitions_remaining": "many"}
+
+	statTests := []struct {
+		name    string
+		want    int64
+		wantErr bool
+	}{
+		{"dead_partitions", 0, false},
+		{"unavailable_partitions", 12, false},
+		{"missing_stat", 0, false},
+		{"migrate_partitions_remaining", 0, true},
+	}
+
+	for _, tt := range statTests {
+		got, err := getIntStat(stats, tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("getIntStat(%s) = (%d, %v), want %d", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestSplitRosterNodes(t *testing.T) {
+	nodeTests := []struct {
+		value interface{}
+		want  []string
+	}{
+		{nil, nil},
+		{"null", nil},
+		{"", nil},
+		{"BB9020011AC4202,BB9030011AC4202", []string{"BB9020011AC4202", "BB9030011AC4202"}},
+	}
+
+	for _, tt := range nodeTests {
+		if got := splitRosterNodes(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitRosterNodes(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNeedsRosterUpdate(t *testing.T) {
+	rosterTests := []struct {
+		roster        []string
+		observedNodes []string
+		want          bool
+	}{
+		{nil, nil, false},
+		{[]string{"A1", "B1"}, nil, false},
+		{nil, []string{"A1", "B1"}, true},
+		{[]string{"A1", "B1"}, []string{"B1", "A1"}, false},
+		{[]string{"A1", "B1"}, []string{"A1", "B1", "C1"}, true},
+		{[]string{"A1", "B1", "C1"}, []string{"A1", "B1"}, true},
+	}
+
+	for _, tt := range rosterTests {
+		roster := &aerospikev1alpha1.AerospikeNamespaceRoster{Roster: tt.roster, ObservedNodes: tt.observedNodes}
+		if got := needsRosterUpdate(roster); got != tt.want {
+			t.Errorf("needsRosterUpdate(roster: %v, observed: %v) = %v, want %v", tt.roster, tt.observedNodes, got, tt.want)
+		}
+	}
+
+	// The node lists are not reordered
+	roster := []string{"B1", "A1"}
+	isSameNodeList(roster, []string{"A1", "B1"})
+	if !reflect.DeepEqual(roster, []string{"B1", "A1"}) {
+		t.Errorf("isSameNodeList() reordered the roster to %v", roster)
+	}
+}
+
+func TestGetStrongConsistencyNamespaces(t *testing.T) {
+	namespace := func(name string, strongConsistency bool) map[string]interface{} {
+		return map[string]interface{}{"name": name, "strong-consistency": strongConsistency}
+	}
+
+	aeroCluster := &aerospikev1alpha1.AerospikeCluster{
+		Spec: aerospikev1alpha1.AerospikeClusterSpec{
+			AerospikeConfig: aerospikev1alpha1.Values{"namespaces": []interface{}{namespace("test", true), namespace("bar", false)}},
+			RackConfig: aerospikev1alpha1.RackConfig{
+				Racks: []aerospikev1alpha1.Rack{
+					{ID: 1, AerospikeConfig: aerospikev1alpha1.Values{"namespaces": []interface{}{namespace("test", true), namespace("bar", false)}}},
+					{ID: 2, AerospikeConfig: aerospikev1alpha1.Values{"namespaces": []interface{}{namespace("test", true), namespace("bar", true)}}},
+				},
+			},
+		},
+	}
+
+	want := []string{"test", "bar"}
+	if got := getStrongConsistencyNamespaces(aeroCluster); !reflect.DeepEqual(got, want) {
+		t.Errorf("getStrongConsistencyNamespaces() = %v, want %v", got, want)
+	}
+}
+
+func TestGetReadyClusterPods(t *testing.T) {
+	pod := func(name string, ready corev1.ConditionStatus, terminating bool) k8sRuntime.Object {
+		pod := newUpgradeTestPod(name, testTargetImage)
+		pod.Status = corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}}
+		if terminating {
+			now := metav1.Now()
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+
+	readyTests := []struct {
+		name    string
+		objs    []k8sRuntime.Object
+		want    int
+		wantErr bool
+	}{
+		{"ready", []k8sRuntime.Object{pod("aerocluster-1-0", corev1.ConditionTrue, false), pod("aerocluster-1-1", corev1.ConditionTrue, false),
+			pod("aerocluster-2-0", corev1.ConditionTrue, false)}, 3, false},
+		{"terminating skipped", []k8sRuntime.Object{pod("aerocluster-1-0", corev1.ConditionTrue, false), pod("aerocluster-1-1", corev1.ConditionFalse, true)}, 1, false},
+		{"not ready", []k8sRuntime.Object{pod("aerocluster-1-0", corev1.ConditionTrue, false), pod("aerocluster-1-1", corev1.ConditionFalse, false)}, 0, true},
+		{"no pods", nil, 0, true},
+	}
+
+	for _, tt := range readyTests {
+		r := &ReconcileAerospikeCluster{client: newTestClient(t, tt.objs...)}
+		pods, err := r.getReadyClusterPods(newPlanTestCluster())
+		if (err != nil) != tt.wantErr || len(pods) != tt.want {
+			t.Errorf("getReadyClusterPods(%s) = %d pods, %v, want %d pods, error %v", tt.name, len(pods), err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package aerospikecluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	as "github.com/ashishshinde/aerospike-client-go"
+	log "github.com/inconshreveable/log15"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// udfModuleSuffix is the suffix of the ConfigMap keys having Lua UDF modules.
+const udfModuleSuffix = ".lua"
+
+// udfModule is a Lua UDF module read from a ConfigMap.
+type udfModule struct {
+	aerospikev1alpha1.AerospikeUDFModuleStatus
+	content []byte
+}
+
+// reconcileUDFModules registers the UDF modules of the spec ConfigMaps that are not registered or whose content hash changed,
+// and removes the modules registered by the operator that are not in the ConfigMaps any more. The registered modules are recorded in status.
+func (r *ReconcileAerospikeCluster) reconcileUDFModules(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	if len(aeroCluster.Spec.UDFModules) == 0 && len(aeroCluster.Status.RegisteredUDFModules) == 0 {
+		return nil
+	}
+
+	modules, err := r.getUDFModules(aeroCluster)
+	if err != nil {
+		return err
+	}
+
+	aeroClient, err := r.newAerospikeClient(aeroCluster)
+	if err != nil {
+		return err
+	}
+	defer aeroClient.Close()
+
+	registered, err := aeroClient.ListUDF(nil)
+	if err != nil {
+		return fmt.Errorf("Failed to list UDF modules: %v", err)
+	}
+	registeredNames := map[string]bool{}
+	for _, udf := range registered {
+		registeredNames[udf.Filename] = true
+	}
+
+	registeredHashes := map[string]string{}
+	for _, module := range aeroCluster.Status.RegisteredUDFModules {
+		registeredHashes[module.Name] = module.Hash
+	}
+
+	var statuses []aerospikev1alpha1.AerospikeUDFModuleStatus
+	for _, module := range modules {
+		if !registeredNames[module.Name] || registeredHashes[module.Name] != module.Hash {
+			logger.Info("Registering UDF module", log.Ctx{"module": module.Name, "configMap": module.ConfigMapName, "hash": module.Hash})
+			if err := registerUDFModule(aeroClient, module); err != nil {
+				return err
+			}
+		}
+		statuses = append(statuses, module.AerospikeUDFModuleStatus)
+	}
+
+	for _, module := range aeroCluster.Status.RegisteredUDFModules {
+		if getUDFModule(modules, module.Name) != nil || !registeredNames[module.Name] {
+			continue
+		}
+		logger.Info("Removing UDF module", log.Ctx{"module": module.Name, "configMap": module.ConfigMapName})
+		if err := removeUDFModule(aeroClient, module.Name); err != nil {
+			return err
+		}
+	}
+
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.RegisteredUDFModules = statuses
+	})
+}
+
+// getUDFModules returns the Lua modules of the spec ConfigMaps sorted by name.
+func (r *ReconcileAerospikeCluster) getUDFModules(aeroCluster *aerospikev1alpha1.AerospikeCluster) ([]udfModule, error) {
+	var modules []udfModule
+	for _, source := range aeroCluster.Spec.UDFModules {
+		configMap := &corev1.ConfigMap{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: source.ConfigMapName, Namespace: aeroCluster.Namespace}, configMap); err != nil {
+			return nil, fmt.Errorf("Failed to get UDF ConfigMap %s: %v", source.ConfigMapName, err)
+		}
+
+		for name, content := range configMap.Data {
+			if !strings.HasSuffix(name, udfModuleSuffix) {
+				continue
+			}
+			if module := getUDFModule(modules, name); module != nil {
+				return nil, fmt.Errorf("UDF module %s is in both ConfigMaps %s and %s", name, module.ConfigMapName, source.ConfigMapName)
+			}
+
+			hash := sha256.Sum256([]byte(content))
+			modules = append(modules, udfModule{
+				AerospikeUDFModuleStatus: aerospikev1alpha1.AerospikeUDFModuleStatus{
+					Name:          name,
+					ConfigMapName: source.ConfigMapName,
+					Hash:          hex.EncodeToString(hash[:]),
+				},
+				content: []byte(content),
+			})
+		}
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
+	return modules, nil
+}
+
+// getUDFConfigMapRequests returns the requests of the clusters in the ConfigMap namespace having UDF modules in the ConfigMap.
+func getUDFConfigMapRequests(c client.Client, obj handler.MapObject) []reconcile.Request {
+	aeroClusterList := &aerospikev1alpha1.AerospikeClusterList{}
+	if err := c.List(context.TODO(), aeroClusterList, &client.ListOptions{Namespace: obj.Meta.GetNamespace()}); err != nil {
+		pkglog.Error("Failed to list AerospikeClusters for UDF ConfigMap", log.Ctx{"ConfigMap": obj.Meta.GetName(), "err": err})
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, aeroCluster := range aeroClusterList.Items {
+		for _, source := range aeroCluster.Spec.UDFModules {
+			if source.ConfigMapName == obj.Meta.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: aeroCluster.Name, Namespace: aeroCluster.Namespace}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// registerUDFModule registers the module with udf-put and waits till it is registered on all nodes.
+func registerUDFModule(aeroClient *as.Client, module udfModule) error {
+	task, err := aeroClient.RegisterUDF(nil, module.content, module.Name, as.LUA)
+	if err != nil {
+		return fmt.Errorf("Failed to register UDF module %s: %v", module.Name, err)
+	}
+	if err := <-task.OnComplete(); err != nil {
+		return fmt.Errorf("Failed to register UDF module %s: %v", module.Name, err)
+	}
+	return nil
+}
+
+// removeUDFModule removes the module and waits till it is removed from all nodes.
+func removeUDFModule(aeroClient *as.Client, name string) error {
+	task, err := aeroClient.RemoveUDF(nil, name)
+	if err != nil {
+		return fmt.Errorf("Failed to remove UDF module %s: %v", name, err)
+	}
+	if err := <-task.OnComplete(); err != nil {
+		return fmt.Errorf("Failed to remove UDF module %s: %v", name, err)
+	}
+	return nil
+}
+
+func getUDFModule(modules []udfModule, name string) *udfModule {
+	for i := range modules {
+		if modules[i].Name == name {
+			return &modules[i]
+		}
+	}
+	return nil
+}
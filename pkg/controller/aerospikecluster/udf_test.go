@@ -0,0 +1,49 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestGetUDFConfigMapRequests(t *testing.T) {
+	cluster := func(name, namespace string, configMaps ...string) *aerospikev1alpha1.AerospikeCluster {
+		aeroCluster := &aerospikev1alpha1.AerospikeCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		for _, configMap := range configMaps {
+			aeroCluster.Spec.UDFModules = append(aeroCluster.Spec.UDFModules, aerospikev1alpha1.AerospikeUDFModuleSource{ConfigMapName: configMap})
+		}
+		return aeroCluster
+	}
+	c := newTestClient(t,
+		cluster("aerocluster-1", "aerospike", "udf-1", "udf-2"),
+		cluster("aerocluster-2", "aerospike", "udf-2"),
+		cluster("aerocluster-3", "aerospike"),
+		cluster("aerocluster-4", "other", "udf-1"),
+	)
+
+	requestTests := []struct {
+		configMap string
+		want      []string
+	}{
+		{"udf-1", []string{"aerocluster-1"}},
+		{"udf-2", []string{"aerocluster-1", "aerocluster-2"}},
+		{"aerocluster-1-1", nil},
+	}
+
+	for _, tt := range requestTests {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: tt.configMap, Namespace: "aerospike"}}
+		var want []reconcile.Request
+		for _, name := range tt.want {
+			want = append(want, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "aerospike"}})
+		}
+		if got := getUDFConfigMapRequests(c, handler.MapObject{Meta: configMap, Object: configMap}); !reflect.DeepEqual(got, want) {
+			t.Errorf("getUDFConfigMapRequests(%s) = %v, want %v", tt.configMap, got, want)
+		}
+	}
+}
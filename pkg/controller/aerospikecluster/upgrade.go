@@ -0,0 +1,596 @@
+package aerospikecluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+	log "github.com/inconshreveable/log15"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const healthCheckJobPollInterval = time.Second * 10
+
+const upgradeFailedReason = "UpgradeFailed"
+
+// reconcileUpgradeStrategy runs the canary phase of a server image upgrade.
+// It returns a non nil result if the reconcile should stop and return the result, nil if the rollout can go ahead.
+func (r *ReconcileAerospikeCluster) reconcileUpgradeStrategy(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	pendingPods, err := r.getPodsPendingImageUpgrade(aeroCluster)
+	if err != nil {
+		return nil, err
+	}
+	if len(pendingPods) == 0 {
+		return nil, nil
+	}
+
+	upgradeStatus := aeroCluster.Status.Upgrade
+	if !isUpgradeTarget(aeroCluster, upgradeStatus) {
+		phase := aerospikev1alpha1.AerospikeUpgradePhaseRollingOut
+		if getCanarySpec(aeroCluster) != nil {
+			phase = aerospikev1alpha1.AerospikeUpgradePhaseCanary
+		}
+		logger.Info("Starting upgrade", log.Ctx{"image": aeroCluster.Spec.Image, "phase": phase})
+
+		// Status spec is updated only after a successful reconcile, so it has the last known good image
+		upgradeStatus = &aerospikev1alpha1.AerospikeUpgradeStatus{
+			TargetImage:   aeroCluster.Spec.Image,
+			PreviousImage: aeroCluster.Status.Image,
+			Racks:         getRackUpgradeStatus(aeroCluster),
+			Phase:         phase,
+		}
+		if err := r.setUpgradeStatus(aeroCluster, upgradeStatus); err != nil {
+			return nil, err
+		}
+	}
+
+	strategy := aeroCluster.Spec.UpgradeStrategy
+	paused := strategy != nil && strategy.Paused
+	if paused != upgradeStatus.Paused {
+		if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+			status.Paused = paused
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if paused {
+		logger.Info("Upgrade is paused", log.Ctx{"image": aeroCluster.Spec.Image})
+		return &reconcile.Result{}, nil
+	}
+
+	switch aeroCluster.Status.Upgrade.Phase {
+	case aerospikev1alpha1.AerospikeUpgradePhaseCanary:
+		return r.upgradeCanary(aeroCluster, pendingPods)
+
+	case aerospikev1alpha1.AerospikeUpgradePhaseSoaking, aerospikev1alpha1.AerospikeUpgradePhaseHealthCheck:
+		return r.checkCanaryHealthGates(aeroCluster)
+
+	case aerospikev1alpha1.AerospikeUpgradePhaseAwaitingApproval:
+		return r.passCanary(aeroCluster)
+
+	case aerospikev1alpha1.AerospikeUpgradePhaseCanaryFailed:
+		logger.Info("Upgrade is held, canary failed", log.Ctx{"image": aeroCluster.Spec.Image, "reason": aeroCluster.Status.Upgrade.Message})
+		return &reconcile.Result{}, nil
+
+	case aerospikev1alpha1.AerospikeUpgradePhaseRollingBack:
+		// Earlier rollback did not finish, retry
+		return r.rollbackUpgrade(aeroCluster, aeroCluster.Status.Upgrade.Message)
+
+	case aerospikev1alpha1.AerospikeUpgradePhaseRolledBack:
+		logger.Info("Upgrade is held, upgrade was rolled back", log.Ctx{"image": aeroCluster.Spec.Image, "reason": aeroCluster.Status.Upgrade.Message})
+		return &reconcile.Result{}, nil
+	}
+	return nil, nil
+}
+
+// completeUpgrade marks the upgrade to the spec image as completed and clears the Degraded condition set by a failed upgrade.
+func (r *ReconcileAerospikeCluster) completeUpgrade(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	pendingPods, err := r.getPodsPendingImageUpgrade(aeroCluster)
+	if err != nil {
+		return err
+	}
+	if len(pendingPods) != 0 {
+		return nil
+	}
+
+	degraded := getClusterCondition(aeroCluster.Status.Conditions, aerospikev1alpha1.AerospikeClusterDegraded)
+	if degraded != nil && degraded.Status == corev1.ConditionTrue && degraded.Reason == upgradeFailedReason {
+		if err := r.setStatusCondition(aeroCluster, aerospikev1alpha1.AerospikeClusterCondition{
+			Type:    aerospikev1alpha1.AerospikeClusterDegraded,
+			Status:  corev1.ConditionFalse,
+			Reason:  "UpgradeCompleted",
+			Message: fmt.Sprintf("All pods run image %s", aeroCluster.Spec.Image),
+		}); err != nil {
+			return err
+		}
+	}
+
+	upgradeStatus := aeroCluster.Status.Upgrade
+	if !isUpgradeTarget(aeroCluster, upgradeStatus) || upgradeStatus.Phase == aerospikev1alpha1.AerospikeUpgradePhaseCompleted {
+		return nil
+	}
+
+	return r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseCompleted
+		status.Message = ""
+	})
+}
+
+// upgradeCanary upgrades the canary pod or rack and starts the soak period.
+func (r *ReconcileAerospikeCluster) upgradeCanary(aeroCluster *aerospikev1alpha1.AerospikeCluster, pendingPods map[string]bool) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	canary := getCanarySpec(aeroCluster)
+	if canary == nil {
+		// Canary removed from the spec, go ahead with the rollout
+		return nil, r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+			status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseRollingOut
+		})
+	}
+
+	for _, rackState := range getNewRackStateList(aeroCluster) {
+		podList, err := r.getOrderedRackPodList(aeroCluster, rackState.Rack.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list pods: %v", err)
+		}
+		canaryPods := selectCanaryPods(podList, pendingPods, canary.Scope)
+		if len(canaryPods) == 0 {
+			continue
+		}
+
+		found, err := r.getStatefulSet(aeroCluster, rackState)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get StatefulSet for rack %d: %v", rackState.Rack.ID, err)
+		}
+
+		logger.Info("Upgrading canary", log.Ctx{"pods": canaryPods, "image": aeroCluster.Spec.Image})
+
+		if _, err := r.upgradeRack(aeroCluster, found, aeroCluster.Spec.Image, rackState, canaryPods); err != nil {
+			return r.failCanary(aeroCluster, canaryPods, fmt.Sprintf("Canary upgrade failed: %v", err))
+		}
+
+		now := metav1.Now()
+		if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+			status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseSoaking
+			status.CanaryPods = canaryPods
+			status.CanaryUpgradedTime = &now
+			status.Message = ""
+		}); err != nil {
+			return nil, err
+		}
+		return &reconcile.Result{RequeueAfter: getCanarySoakPeriod(canary)}, nil
+	}
+
+	// Pending pods are not in any rack, nothing to canary
+	return nil, r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseRollingOut
+	})
+}
+
+// selectCanaryPods returns the pending pods of the rack to upgrade as the canary.
+func selectCanaryPods(podList []corev1.Pod, pendingPods map[string]bool, scope aerospikev1alpha1.AerospikeCanaryScope) []string {
+	var canaryPods []string
+	for _, pod := range podList {
+		if !pendingPods[pod.Name] {
+			continue
+		}
+		canaryPods = append(canaryPods, pod.Name)
+		if scope != aerospikev1alpha1.AerospikeCanaryScopeRack {
+			break
+		}
+	}
+	return canaryPods
+}
+
+// checkCanaryHealthGates waits for the soak period and checks the canary health gates and the health check Job.
+func (r *ReconcileAerospikeCluster) checkCanaryHealthGates(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	upgradeStatus := aeroCluster.Status.Upgrade
+	canary := getCanarySpec(aeroCluster)
+	if canary == nil {
+		return r.passCanary(aeroCluster)
+	}
+
+	if upgradeStatus.Phase == aerospikev1alpha1.AerospikeUpgradePhaseHealthCheck {
+		return r.checkHealthCheckJob(aeroCluster)
+	}
+
+	if upgradeStatus.CanaryUpgradedTime != nil {
+		soakEnd := upgradeStatus.CanaryUpgradedTime.Add(getCanarySoakPeriod(canary))
+		if remaining := time.Until(soakEnd); remaining > 0 {
+			logger.Info("Canary is soaking", log.Ctx{"pods": upgradeStatus.CanaryPods, "remaining": remaining})
+			return &reconcile.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	if err := r.checkCanaryHealth(aeroCluster, upgradeStatus.CanaryPods); err != nil {
+		return r.failCanary(aeroCluster, upgradeStatus.CanaryPods, err.Error())
+	}
+
+	if canary.HealthCheckJob == nil {
+		return r.passCanary(aeroCluster)
+	}
+
+	if err := r.createHealthCheckJob(aeroCluster, canary.HealthCheckJob); err != nil {
+		return nil, err
+	}
+	if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseHealthCheck
+	}); err != nil {
+		return nil, err
+	}
+	return &reconcile.Result{RequeueAfter: healthCheckJobPollInterval}, nil
+}
+
+// checkCanaryHealth checks the canary pods did not crash and the cluster is stable with all partitions available.
+func (r *ReconcileAerospikeCluster) checkCanaryHealth(aeroCluster *aerospikev1alpha1.AerospikeCluster, canaryPods []string) error {
+	for _, podName := range canaryPods {
+		pod := &corev1.Pod{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: aeroCluster.Namespace}, pod); err != nil {
+			return fmt.Errorf("Failed to get canary pod %s: %v", podName, err)
+		}
+		if err := utils.CheckPodFailed(pod); err != nil {
+			return fmt.Errorf("Canary pod %s failed: %v", podName, err)
+		}
+		if !utils.IsPodRunningAndReady(pod) {
+			return fmt.Errorf("Canary pod %s is not running and ready", podName)
+		}
+		for _, ps := range pod.Status.ContainerStatuses {
+			if ps.Name == "aerospike-server" && ps.RestartCount != 0 {
+				return fmt.Errorf("Canary pod %s restarted %d times", podName, ps.RestartCount)
+			}
+		}
+	}
+
+	allHostConns, err := r.newAllHostConn(aeroCluster)
+	if err != nil {
+		return fmt.Errorf("Failed to get hostConn for aerospike cluster nodes: %v", err)
+	}
+
+	isStable, err := deployment.IsClusterAndStable(r.getClientPolicy(aeroCluster), allHostConns)
+	if err != nil {
+		return fmt.Errorf("Failed to check cluster stability: %v", err)
+	}
+	if !isStable {
+		return fmt.Errorf("Cluster is not stable")
+	}
+
+	namespaces, err := utils.ListAerospikeNamespaces(aeroCluster.Spec.AerospikeConfig)
+	if err != nil {
+		return err
+	}
+	for _, hostConn := range allHostConns {
+		for _, ns := range namespaces {
+			if ns == "" {
+				continue
+			}
+			if err := r.checkPartitionsAvailable(aeroCluster, hostConn, ns); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkPartitionsAvailable returns an error if the namespace has dead or unavailable partitions on the host.
+func (r *ReconcileAerospikeCluster) checkPartitionsAvailable(aeroCluster *aerospikev1alpha1.AerospikeCluster, hostConn *deployment.HostConn, ns string) error {
+	cmd := "namespace/" + ns
+	res, err := deployment.RunInfo(r.getClientPolicy(aeroCluster), hostConn.ASConn, cmd)
+	if err != nil {
+		return fmt.Errorf("Failed to get namespace %s stats from %s: %v", ns, hostConn.ID, err)
+	}
+
+	stats, err := parseInfoIntoMap(res[cmd], ";", "=")
+	if err != nil {
+		return fmt.Errorf("Failed to parse namespace %s stats from %s: %v", ns, hostConn.ID, err)
+	}
+
+	for _, key := range []string{"dead_partitions", "unavailable_partitions"} {
+		value, ok := stats[key]
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(fmt.Sprintf("%v", value))
+		if err != nil {
+			return fmt.Errorf("Failed to parse %s for namespace %s from %s: %v", key, ns, hostConn.ID, err)
+		}
+		if count != 0 {
+			return fmt.Errorf("Namespace %s has %d %s on %s", ns, count, key, hostConn.ID)
+		}
+	}
+	return nil
+}
+
+// createHealthCheckJob creates the canary health check Job, replacing a Job left over by an earlier upgrade.
+func (r *ReconcileAerospikeCluster) createHealthCheckJob(aeroCluster *aerospikev1alpha1.AerospikeCluster, jobSpec *batchv1.JobSpec) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	jobName := getNamespacedNameForHealthCheckJob(aeroCluster)
+
+	oldJob := &batchv1.Job{}
+	if err := r.client.Get(context.TODO(), jobName, oldJob); err == nil {
+		propagation := metav1.DeletePropagationBackground
+		if err := r.client.Delete(context.TODO(), oldJob, client.PropagationPolicy(propagation)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("Failed to delete old health check Job: %v", err)
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("Failed to get health check Job: %v", err)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName.Name,
+			Namespace: jobName.Namespace,
+			Labels:    utils.LabelsForAerospikeCluster(aeroCluster.Name),
+		},
+		Spec: *jobSpec.DeepCopy(),
+	}
+	// Set AerospikeCluster instance as the owner and controller
+	controllerutil.SetControllerReference(aeroCluster, job, r.scheme)
+
+	if err := r.client.Create(context.TODO(), job, createOption); err != nil {
+		return fmt.Errorf("Failed to create health check Job: %v", err)
+	}
+	logger.Info("Created health check Job", log.Ctx{"Job.Namespace": job.Namespace, "Job.Name": job.Name})
+	return nil
+}
+
+// checkHealthCheckJob passes or fails the canary once the health check Job finishes.
+func (r *ReconcileAerospikeCluster) checkHealthCheckJob(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	job := &batchv1.Job{}
+	if err := r.client.Get(context.TODO(), getNamespacedNameForHealthCheckJob(aeroCluster), job); err != nil {
+		if errors.IsNotFound(err) {
+			return r.failCanary(aeroCluster, aeroCluster.Status.Upgrade.CanaryPods, "Health check Job not found")
+		}
+		return nil, fmt.Errorf("Failed to get health check Job: %v", err)
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			logger.Info("Health check Job succeeded", log.Ctx{"Job.Name": job.Name})
+			return r.passCanary(aeroCluster)
+		case batchv1.JobFailed:
+			return r.failCanary(aeroCluster, aeroCluster.Status.Upgrade.CanaryPods, fmt.Sprintf("Health check Job failed: %s", cond.Message))
+		}
+	}
+
+	logger.Info("Waiting for health check Job", log.Ctx{"Job.Name": job.Name})
+	return &reconcile.Result{RequeueAfter: healthCheckJobPollInterval}, nil
+}
+
+// passCanary moves the upgrade to the rollout, or holds it till the spec image is approved.
+func (r *ReconcileAerospikeCluster) passCanary(aeroCluster *aerospikev1alpha1.AerospikeCluster) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	canary := getCanarySpec(aeroCluster)
+	if canary != nil && canary.RequireApproval && canary.ApprovedImage != aeroCluster.Spec.Image {
+		if aeroCluster.Status.Upgrade.Phase != aerospikev1alpha1.AerospikeUpgradePhaseAwaitingApproval {
+			if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+				status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseAwaitingApproval
+				status.Message = "Canary passed health gates, set upgradeStrategy.canary.approvedImage to the target image to continue"
+			}); err != nil {
+				return nil, err
+			}
+		}
+		logger.Info("Upgrade is waiting for approval", log.Ctx{"image": aeroCluster.Spec.Image})
+		return &reconcile.Result{}, nil
+	}
+
+	logger.Info("Canary passed, rolling out upgrade", log.Ctx{"image": aeroCluster.Spec.Image})
+	return nil, r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseRollingOut
+		status.Message = ""
+	})
+}
+
+// failCanary holds the upgrade with the reason in the upgrade status, or rolls it back if auto rollback is enabled.
+func (r *ReconcileAerospikeCluster) failCanary(aeroCluster *aerospikev1alpha1.AerospikeCluster, canaryPods []string, reason string) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	logger.Error("Canary failed, holding upgrade", log.Ctx{"pods": canaryPods, "image": aeroCluster.Spec.Image, "reason": reason})
+	if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseCanaryFailed
+		status.CanaryPods = canaryPods
+		status.Message = reason
+	}); err != nil {
+		return nil, err
+	}
+	if canRollbackUpgrade(aeroCluster) {
+		return r.rollbackUpgrade(aeroCluster, reason)
+	}
+	return &reconcile.Result{}, nil
+}
+
+// rollbackUpgrade rolls the upgraded pods back to the previous image and marks the cluster Degraded.
+// The upgrade is held till the spec image is changed.
+func (r *ReconcileAerospikeCluster) rollbackUpgrade(aeroCluster *aerospikev1alpha1.AerospikeCluster, reason string) (*reconcile.Result, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	previousImage := aeroCluster.Status.Upgrade.PreviousImage
+	if previousImage == "" {
+		logger.Error("No previous image to roll back to", log.Ctx{"image": aeroCluster.Spec.Image, "reason": reason})
+		return &reconcile.Result{}, r.setUpgradeFailedCondition(aeroCluster, fmt.Sprintf("Upgrade to %s failed, no previous image to roll back to: %s", aeroCluster.Spec.Image, reason))
+	}
+
+	logger.Info("Rolling back upgrade", log.Ctx{"image": aeroCluster.Spec.Image, "previousImage": previousImage, "reason": reason})
+
+	if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseRollingBack
+		status.Message = reason
+	}); err != nil {
+		return nil, err
+	}
+
+	// Desired images are read from the cluster spec, so roll back using a copy with the previous images.
+	// The cluster DeepCopy does not copy the racks, set them from the cluster racks.
+	rollbackCluster := aeroCluster.DeepCopy()
+	rollbackCluster.Spec.Image = previousImage
+	rollbackCluster.Spec.RackConfig.Racks = nil
+	for _, rack := range aeroCluster.Spec.RackConfig.Racks {
+		_, rack.PodSpec.Image = getUpgradeRackImages(aeroCluster.Status.Upgrade, rack.ID)
+		rollbackCluster.Spec.RackConfig.Racks = append(rollbackCluster.Spec.RackConfig.Racks, rack)
+	}
+
+	for _, rackState := range getNewRackStateList(aeroCluster) {
+		found, err := r.getStatefulSet(aeroCluster, rackState)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Failed to get StatefulSet for rack %d: %v", rackState.Rack.ID, err)
+		}
+		rackPreviousImage := utils.GetRackImage(rollbackCluster, rackState.Rack.ID)
+		if _, err := r.upgradeRack(rollbackCluster, found, rackPreviousImage, rackState, nil); err != nil {
+			return nil, fmt.Errorf("Failed to roll back rack %d to image %s: %v", rackState.Rack.ID, rackPreviousImage, err)
+		}
+	}
+
+	if err := r.updateUpgradeStatus(aeroCluster, func(status *aerospikev1alpha1.AerospikeUpgradeStatus) {
+		status.Phase = aerospikev1alpha1.AerospikeUpgradePhaseRolledBack
+	}); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Upgrade rolled back", log.Ctx{"image": aeroCluster.Spec.Image, "previousImage": previousImage})
+	return &reconcile.Result{}, r.setUpgradeFailedCondition(aeroCluster, fmt.Sprintf("Upgrade to %s failed and was rolled back to %s, change the image to retry: %s", aeroCluster.Spec.Image, previousImage, reason))
+}
+
+func (r *ReconcileAerospikeCluster) setUpgradeFailedCondition(aeroCluster *aerospikev1alpha1.AerospikeCluster, message string) error {
+	return r.setStatusCondition(aeroCluster, aerospikev1alpha1.AerospikeClusterCondition{
+		Type:    aerospikev1alpha1.AerospikeClusterDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  upgradeFailedReason,
+		Message: message,
+	})
+}
+
+// canRollbackUpgrade returns true if auto rollback is enabled and a server image upgrade to the spec images is in progress.
+func canRollbackUpgrade(aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	strategy := aeroCluster.Spec.UpgradeStrategy
+	return strategy != nil && strategy.AutoRollback && isUpgradeTarget(aeroCluster, aeroCluster.Status.Upgrade)
+}
+
+// isUpgradeTarget returns true if the upgrade status is for the cluster image and the rack images in the spec.
+func isUpgradeTarget(aeroCluster *aerospikev1alpha1.AerospikeCluster, upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus) bool {
+	if upgradeStatus == nil || upgradeStatus.TargetImage != aeroCluster.Spec.Image {
+		return false
+	}
+	for _, rackState := range getNewRackStateList(aeroCluster) {
+		if targetImage, _ := getUpgradeRackImages(upgradeStatus, rackState.Rack.ID); targetImage != utils.GetRackImage(aeroCluster, rackState.Rack.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// getRackUpgradeStatus returns the target and previous images of the racks which override the cluster image in the spec,
+// or overrode it in the status spec. The status spec has the images of the last successful reconcile.
+func getRackUpgradeStatus(aeroCluster *aerospikev1alpha1.AerospikeCluster) []aerospikev1alpha1.AerospikeRackUpgradeStatus {
+	previousCluster := &aerospikev1alpha1.AerospikeCluster{Spec: aeroCluster.Status.AerospikeClusterSpec}
+
+	var racks []aerospikev1alpha1.AerospikeRackUpgradeStatus
+	for _, rackState := range getNewRackStateList(aeroCluster) {
+		targetImage := utils.GetRackImage(aeroCluster, rackState.Rack.ID)
+		previousImage := utils.GetRackImage(previousCluster, rackState.Rack.ID)
+		if targetImage != aeroCluster.Spec.Image || previousImage != aeroCluster.Status.Image {
+			racks = append(racks, aerospikev1alpha1.AerospikeRackUpgradeStatus{
+				ID:            rackState.Rack.ID,
+				TargetImage:   targetImage,
+				PreviousImage: previousImage,
+			})
+		}
+	}
+	return racks
+}
+
+// getUpgradeRackImages returns the target and previous image of a rack in the upgrade.
+func getUpgradeRackImages(upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus, rackID int) (string, string) {
+	for _, rack := range upgradeStatus.Racks {
+		if rack.ID == rackID {
+			return rack.TargetImage, rack.PreviousImage
+		}
+	}
+	return upgradeStatus.TargetImage, upgradeStatus.PreviousImage
+}
+
+// getPodsPendingImageUpgrade returns the names of the pods not running the spec image of their rack.
+func (r *ReconcileAerospikeCluster) getPodsPendingImageUpgrade(aeroCluster *aerospikev1alpha1.AerospikeCluster) (map[string]bool, error) {
+	podList, err := r.getClusterPodList(aeroCluster)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %v", err)
+	}
+
+	pendingPods := map[string]bool{}
+	for _, pod := range podList.Items {
+		rackID, err := getRackIDFromPodName(pod.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range pod.Spec.Containers {
+			if container.Name == "aerospike-server" && !utils.IsImageEqual(container.Image, utils.GetRackImage(aeroCluster, *rackID)) {
+				pendingPods[pod.Name] = true
+			}
+		}
+	}
+	return pendingPods, nil
+}
+
+// setUpgradeStatus replaces the upgrade status.
+func (r *ReconcileAerospikeCluster) setUpgradeStatus(aeroCluster *aerospikev1alpha1.AerospikeCluster, upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus) error {
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.Upgrade = upgradeStatus.DeepCopy()
+	})
+}
+
+// updateUpgradeStatus applies mutate to a copy of the current upgrade status and patches it.
+func (r *ReconcileAerospikeCluster) updateUpgradeStatus(aeroCluster *aerospikev1alpha1.AerospikeCluster, mutate func(status *aerospikev1alpha1.AerospikeUpgradeStatus)) error {
+	upgradeStatus := aeroCluster.Status.Upgrade.DeepCopy()
+	if upgradeStatus == nil {
+		upgradeStatus = &aerospikev1alpha1.AerospikeUpgradeStatus{TargetImage: aeroCluster.Spec.Image}
+	}
+	mutate(upgradeStatus)
+	return r.setUpgradeStatus(aeroCluster, upgradeStatus)
+}
+
+func getCanarySpec(aeroCluster *aerospikev1alpha1.AerospikeCluster) *aerospikev1alpha1.AerospikeCanarySpec {
+	if aeroCluster.Spec.UpgradeStrategy == nil {
+		return nil
+	}
+	return aeroCluster.Spec.UpgradeStrategy.Canary
+}
+
+// getCanarySoakPeriod returns the soak period of the canary. It is never zero, the soaking canary is only checked on a requeue
+// as the status updates do not trigger a reconcile.
+func getCanarySoakPeriod(canary *aerospikev1alpha1.AerospikeCanarySpec) time.Duration {
+	if canary.SoakSeconds <= 0 {
+		return utils.DefaultCanarySoakSeconds * time.Second
+	}
+	return time.Duration(canary.SoakSeconds) * time.Second
+}
+
+func getNamespacedNameForHealthCheckJob(aeroCluster *aerospikev1alpha1.AerospikeCluster) types.NamespacedName {
+	return types.NamespacedName{
+		Name:      aeroCluster.Name + "-upgrade-check",
+		Namespace: aeroCluster.Namespace,
+	}
+}
@@ -0,0 +1,143 @@
+package aerospikecluster
+
+import (
+	"fmt"
+	"reflect"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	log "github.com/inconshreveable/log15"
+)
+
+// applyUpgradePlan plans the version change from the last reconciled version to the spec version through the versions that cannot be skipped.
+// If an intermediate version is needed, the in memory spec is replaced with the hop image and the config translated for the hop version,
+// so that this reconcile does a full rolling upgrade to the hop. It returns true if the spec was replaced.
+func (r *ReconcileAerospikeCluster) applyUpgradePlan(aeroCluster *aerospikev1alpha1.AerospikeCluster) (bool, error) {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	if aeroCluster.Status.Image == "" {
+		// Nothing deployed yet
+		return false, nil
+	}
+
+	fromVersion, err := utils.GetAerospikeVersion(&aeroCluster.Status.AerospikeClusterSpec)
+	if err != nil {
+		return false, err
+	}
+	toVersion, err := utils.GetAerospikeVersion(&aeroCluster.Spec)
+	if err != nil {
+		return false, err
+	}
+	hops, err := utils.GetUpgradeHops(fromVersion, toVersion)
+	if err != nil {
+		return false, err
+	}
+
+	var hopImages []string
+	if aeroCluster.Spec.UpgradeStrategy != nil {
+		hopImages = aeroCluster.Spec.UpgradeStrategy.HopImages
+	}
+
+	plan := aeroCluster.Status.UpgradePlan.DeepCopy()
+	if len(hops) != 0 && (plan == nil || plan.TargetImage != aeroCluster.Spec.Image) {
+		plan = &aerospikev1alpha1.AerospikeUpgradePlan{TargetImage: aeroCluster.Spec.Image}
+		for _, hop := range hops {
+			image, version := utils.GetHopImage(aeroCluster.Spec.Image, hopImages, hop)
+			plan.Steps = append(plan.Steps, aerospikev1alpha1.AerospikeUpgradeStep{Version: version, Image: image})
+		}
+		plan.Steps = append(plan.Steps, aerospikev1alpha1.AerospikeUpgradeStep{Version: toVersion, Image: aeroCluster.Spec.Image})
+		logger.Info("Planned upgrade through intermediate versions", log.Ctx{"from": fromVersion, "to": toVersion, "steps": plan.Steps})
+	}
+	if plan == nil || plan.TargetImage != aeroCluster.Spec.Image {
+		return false, nil
+	}
+	if len(hops) == 0 && plan.Steps[len(plan.Steps)-1].Phase == aerospikev1alpha1.AerospikeUpgradeStepCompleted {
+		return false, nil
+	}
+
+	// The current step is the first hop left, or the target if no hops are left
+	currentImage := aeroCluster.Spec.Image
+	currentVersion := toVersion
+	if len(hops) != 0 {
+		currentImage, currentVersion = utils.GetHopImage(aeroCluster.Spec.Image, hopImages, hops[0])
+	}
+	setUpgradeStepPhases(plan, currentImage)
+
+	if !reflect.DeepEqual(plan, aeroCluster.Status.UpgradePlan) {
+		if err := r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+			status.UpgradePlan = plan.DeepCopy()
+		}); err != nil {
+			return false, fmt.Errorf("Failed to update upgrade plan: %v", err)
+		}
+	}
+
+	if len(hops) == 0 {
+		return false, nil
+	}
+
+	logger.Info("Upgrading to intermediate version", log.Ctx{"image": currentImage, "version": currentVersion, "targetImage": aeroCluster.Spec.Image})
+	if err := setHopSpec(aeroCluster, currentImage, currentVersion, toVersion); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// completeUpgradePlan marks the last step of the upgrade plan completed once the cluster is reconciled with the spec image.
+func (r *ReconcileAerospikeCluster) completeUpgradePlan(aeroCluster *aerospikev1alpha1.AerospikeCluster) error {
+	plan := aeroCluster.Status.UpgradePlan
+	if plan == nil || plan.TargetImage != aeroCluster.Spec.Image || len(plan.Steps) == 0 ||
+		plan.Steps[len(plan.Steps)-1].Phase == aerospikev1alpha1.AerospikeUpgradeStepCompleted {
+		return nil
+	}
+
+	return r.patchStatusFields(aeroCluster, func(status *aerospikev1alpha1.AerospikeClusterStatus) {
+		status.UpgradePlan = plan.DeepCopy()
+		for i := range status.UpgradePlan.Steps {
+			status.UpgradePlan.Steps[i].Phase = aerospikev1alpha1.AerospikeUpgradeStepCompleted
+		}
+	})
+}
+
+// setUpgradeStepPhases marks the steps before the step with currentImage completed, the step in progress and the rest pending.
+func setUpgradeStepPhases(plan *aerospikev1alpha1.AerospikeUpgradePlan, currentImage string) {
+	phase := aerospikev1alpha1.AerospikeUpgradeStepCompleted
+	for i := range plan.Steps {
+		if plan.Steps[i].Image == currentImage {
+			plan.Steps[i].Phase = aerospikev1alpha1.AerospikeUpgradeStepInProgress
+			phase = aerospikev1alpha1.AerospikeUpgradeStepPending
+			continue
+		}
+		plan.Steps[i].Phase = phase
+	}
+}
+
+// setHopSpec replaces the in memory spec image, version and aerospike config with the ones for the hop version.
+// The config is translated from the spec config, params the hop version does not support are dropped.
+func setHopSpec(aeroCluster *aerospikev1alpha1.AerospikeCluster, hopImage, hopVersion, toVersion string) error {
+	logger := pkglog.New(log.Ctx{"AerospikeCluster": utils.ClusterNamespacedName(aeroCluster)})
+
+	config, changes, err := configschema.TranslateConfig(aeroCluster.Spec.AerospikeConfig, toVersion, hopVersion)
+	if err != nil {
+		return fmt.Errorf("Failed to translate aerospikeConfig to version %s: %v", hopVersion, err)
+	}
+	if len(changes) != 0 {
+		logger.Warn("Translated aerospikeConfig for intermediate version", log.Ctx{"version": hopVersion, "changes": changes})
+	}
+
+	for i, rack := range aeroCluster.Spec.RackConfig.Racks {
+		translated, changes, err := configschema.TranslateConfig(rack.AerospikeConfig, toVersion, hopVersion)
+		if err != nil {
+			return fmt.Errorf("Failed to translate aerospikeConfig of rack %d to version %s: %v", rack.ID, hopVersion, err)
+		}
+		if len(changes) != 0 {
+			logger.Warn("Translated rack aerospikeConfig for intermediate version", log.Ctx{"rackID": rack.ID, "version": hopVersion, "changes": changes})
+		}
+		aeroCluster.Spec.RackConfig.Racks[i].AerospikeConfig = translated
+	}
+
+	aeroCluster.Spec.Image = hopImage
+	aeroCluster.Spec.Version = hopVersion
+	aeroCluster.Spec.AerospikeConfig = config
+	return nil
+}
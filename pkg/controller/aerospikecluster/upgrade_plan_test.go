@@ -0,0 +1,58 @@
+package aerospikecluster
+
+import (
+	"reflect"
+	"testing"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/configschema"
+)
+
+func TestSetHopSpec(t *testing.T) {
+	if err := configschema.Init(""); err != nil {
+		t.Fatalf("configschema.Init() error = %v", err)
+	}
+
+	// The config edits made along with the image change are kept for the hop
+	aeroCluster := newPlanTestCluster()
+	aeroCluster.Spec.AerospikeConfig = aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 20000}}
+	aeroCluster.Spec.RackConfig.Racks[0].AerospikeConfig = aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 25000}}
+
+	if err := setHopSpec(aeroCluster, "aerospike/aerospike-server-enterprise:4.9", "4.9.0", "5.2.0.7"); err != nil {
+		t.Fatalf("setHopSpec() error = %v", err)
+	}
+
+	if aeroCluster.Spec.Image != "aerospike/aerospike-server-enterprise:4.9" || aeroCluster.Spec.Version != "4.9.0" {
+		t.Errorf("setHopSpec() image, version = %s, %s, want aerospike/aerospike-server-enterprise:4.9, 4.9.0", aeroCluster.Spec.Image, aeroCluster.Spec.Version)
+	}
+	if want := (aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 20000}}); !reflect.DeepEqual(aeroCluster.Spec.AerospikeConfig, want) {
+		t.Errorf("setHopSpec() aerospikeConfig = %v, want %v", aeroCluster.Spec.AerospikeConfig, want)
+	}
+	if want := (aerospikev1alpha1.Values{"service": map[string]interface{}{"proto-fd-max": 25000}}); !reflect.DeepEqual(aeroCluster.Spec.RackConfig.Racks[0].AerospikeConfig, want) {
+		t.Errorf("setHopSpec() rack aerospikeConfig = %v, want %v", aeroCluster.Spec.RackConfig.Racks[0].AerospikeConfig, want)
+	}
+}
+
+func TestSetUpgradeStepPhases(t *testing.T) {
+	plan := &aerospikev1alpha1.AerospikeUpgradePlan{
+		TargetImage: "aerospike/aerospike-server-enterprise:5.2.0.7",
+		Steps: []aerospikev1alpha1.AerospikeUpgradeStep{
+			{Version: "4.2.0", Image: "aerospike/aerospike-server-enterprise:4.2"},
+			{Version: "4.9.0", Image: "aerospike/aerospike-server-enterprise:4.9"},
+			{Version: "5.2.0.7", Image: "aerospike/aerospike-server-enterprise:5.2.0.7"},
+		},
+	}
+
+	setUpgradeStepPhases(plan, "aerospike/aerospike-server-enterprise:4.9")
+
+	want := []aerospikev1alpha1.AerospikeUpgradeStepPhase{
+		aerospikev1alpha1.AerospikeUpgradeStepCompleted,
+		aerospikev1alpha1.AerospikeUpgradeStepInProgress,
+		aerospikev1alpha1.AerospikeUpgradeStepPending,
+	}
+	for i, step := range plan.Steps {
+		if step.Phase != want[i] {
+			t.Errorf("setUpgradeStepPhases() step %s phase = %s, want %s", step.Version, step.Phase, want[i])
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package aerospikecluster
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/apis"
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testPreviousImage = "aerospike/aerospike-server-enterprise:5.1.0.10"
+	testTargetImage   = "aerospike/aerospike-server-enterprise:5.2.0.7"
+)
+
+// newTestClient returns a fake client knowing the Kubernetes and the AerospikeCluster types.
+func newTestClient(t *testing.T, objs ...k8sRuntime.Object) client.Client {
+	return fake.NewFakeClientWithScheme(newTestScheme(t), objs...)
+}
+
+// newTestScheme returns a scheme with the Kubernetes and the operator types.
+func newTestScheme(t *testing.T) *k8sRuntime.Scheme {
+	s := k8sRuntime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newUpgradeTestCluster returns a cluster upgrading from testPreviousImage to testTargetImage.
+func newUpgradeTestCluster(upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus, strategy *aerospikev1alpha1.AerospikeUpgradeStrategy) *aerospikev1alpha1.AerospikeCluster {
+	aeroCluster := newPlanTestCluster()
+	aeroCluster.Spec.Image = testTargetImage
+	aeroCluster.Spec.UpgradeStrategy = strategy
+	aeroCluster.Status.Image = testPreviousImage
+	aeroCluster.Status.Upgrade = upgradeStatus
+	return aeroCluster
+}
+
+func newUpgradeTestPod(name, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "aerospike", Labels: utils.LabelsForAerospikeCluster("aerocluster")},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "aerospike-server", Image: image}}},
+	}
+}
+
+func TestReconcileUpgradeStrategy(t *testing.T) {
+	upgradeStatus := func(phase aerospikev1alpha1.AerospikeUpgradePhase) *aerospikev1alpha1.AerospikeUpgradeStatus {
+		now := metav1.Now()
+		return &aerospikev1alpha1.AerospikeUpgradeStatus{
+			TargetImage:        testTargetImage,
+			PreviousImage:      testPreviousImage,
+			Phase:              phase,
+			CanaryPods:         []string{"aerocluster-1-0"},
+			CanaryUpgradedTime: &now,
+		}
+	}
+	canary := func(canary aerospikev1alpha1.AerospikeCanarySpec) *aerospikev1alpha1.AerospikeUpgradeStrategy {
+		return &aerospikev1alpha1.AerospikeUpgradeStrategy{Canary: &canary}
+	}
+
+	const (
+		proceed = "proceed"
+		hold    = "hold"
+		requeue = "requeue"
+	)
+
+	phaseTests := []struct {
+		name          string
+		podImage      string
+		upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus
+		strategy      *aerospikev1alpha1.AerospikeUpgradeStrategy
+		want          string
+		wantPhase     aerospikev1alpha1.AerospikeUpgradePhase
+	}{
+		{"no pending pods", testTargetImage, nil, nil, proceed, ""},
+		{"start rollout", testPreviousImage, nil, nil, proceed, aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+		{"paused", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseRollingOut), &aerospikev1alpha1.AerospikeUpgradeStrategy{Paused: true},
+			hold, aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+		{"rolling out", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseRollingOut), nil, proceed, aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+		{"canary removed", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseCanary), nil, proceed, aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+		{"soaking", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseSoaking), canary(aerospikev1alpha1.AerospikeCanarySpec{SoakSeconds: 600}),
+			requeue, aerospikev1alpha1.AerospikeUpgradePhaseSoaking},
+		{"soaking without soak seconds", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseSoaking), canary(aerospikev1alpha1.AerospikeCanarySpec{}),
+			requeue, aerospikev1alpha1.AerospikeUpgradePhaseSoaking},
+		{"awaiting approval", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseAwaitingApproval), canary(aerospikev1alpha1.AerospikeCanarySpec{RequireApproval: true}),
+			hold, aerospikev1alpha1.AerospikeUpgradePhaseAwaitingApproval},
+		{"approved", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseAwaitingApproval), canary(aerospikev1alpha1.AerospikeCanarySpec{RequireApproval: true, ApprovedImage: testTargetImage}),
+			proceed, aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+		{"canary failed", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseCanaryFailed), canary(aerospikev1alpha1.AerospikeCanarySpec{}),
+			hold, aerospikev1alpha1.AerospikeUpgradePhaseCanaryFailed},
+		{"rolled back", testPreviousImage, upgradeStatus(aerospikev1alpha1.AerospikeUpgradePhaseRolledBack), nil, hold, aerospikev1alpha1.AerospikeUpgradePhaseRolledBack},
+	}
+
+	for _, tt := range phaseTests {
+		aeroCluster := newUpgradeTestCluster(tt.upgradeStatus, tt.strategy)
+		r := &ReconcileAerospikeCluster{client: newTestClient(t, aeroCluster.DeepCopy(), newUpgradeTestPod("aerocluster-1-0", tt.podImage))}
+
+		res, err := r.reconcileUpgradeStrategy(aeroCluster)
+		if err != nil {
+			t.Errorf("reconcileUpgradeStrategy(%s) error = %v", tt.name, err)
+			continue
+		}
+
+		got := proceed
+		if res != nil {
+			got = hold
+			if res.RequeueAfter > 0 {
+				got = requeue
+			}
+		}
+		if got != tt.want {
+			t.Errorf("reconcileUpgradeStrategy(%s) = %s %v, want %s", tt.name, got, res, tt.want)
+		}
+
+		// The status is patched on the stored cluster and kept in sync in memory
+		stored := &aerospikev1alpha1.AerospikeCluster{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: aeroCluster.Name, Namespace: aeroCluster.Namespace}, stored); err != nil {
+			t.Fatal(err)
+		}
+		for _, status := range []*aerospikev1alpha1.AerospikeUpgradeStatus{aeroCluster.Status.Upgrade, stored.Status.Upgrade} {
+			var phase aerospikev1alpha1.AerospikeUpgradePhase
+			if status != nil {
+				phase = status.Phase
+			}
+			if phase != tt.wantPhase {
+				t.Errorf("reconcileUpgradeStrategy(%s) phase = %q, want %q", tt.name, phase, tt.wantPhase)
+			}
+		}
+		if tt.strategy != nil && tt.strategy.Paused && !aeroCluster.Status.Upgrade.Paused {
+			t.Errorf("reconcileUpgradeStrategy(%s) did not mark the upgrade paused", tt.name)
+		}
+	}
+}
+
+func TestGetCanarySoakPeriod(t *testing.T) {
+	soakTests := []struct {
+		soakSeconds int32
+		want        time.Duration
+	}{
+		{600, 10 * time.Minute},
+		{0, utils.DefaultCanarySoakSeconds * time.Second},
+		{-1, utils.DefaultCanarySoakSeconds * time.Second},
+	}
+
+	for _, tt := range soakTests {
+		if got := getCanarySoakPeriod(&aerospikev1alpha1.AerospikeCanarySpec{SoakSeconds: tt.soakSeconds}); got != tt.want {
+			t.Errorf("getCanarySoakPeriod(%d) = %v, want %v", tt.soakSeconds, got, tt.want)
+		}
+	}
+}
+
+func TestSelectCanaryPods(t *testing.T) {
+	podList := []corev1.Pod{
+		*newUpgradeTestPod("aerocluster-1-0", testTargetImage),
+		*newUpgradeTestPod("aerocluster-1-1", testPreviousImage),
+		*newUpgradeTestPod("aerocluster-1-2", testPreviousImage),
+	}
+	pendingPods := map[string]bool{"aerocluster-1-1": true, "aerocluster-1-2": true}
+
+	canaryTests := []struct {
+		scope       aerospikev1alpha1.AerospikeCanaryScope
+		pendingPods map[string]bool
+		want        []string
+	}{
+		{"", pendingPods, []string{"aerocluster-1-1"}},
+		{aerospikev1alpha1.AerospikeCanaryScopePod, pendingPods, []string{"aerocluster-1-1"}},
+		{aerospikev1alpha1.AerospikeCanaryScopeRack, pendingPods, []string{"aerocluster-1-1", "aerocluster-1-2"}},
+		{aerospikev1alpha1.AerospikeCanaryScopeRack, map[string]bool{}, nil},
+	}
+
+	for _, tt := range canaryTests {
+		if got := selectCanaryPods(podList, tt.pendingPods, tt.scope); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectCanaryPods(%q, %v) = %v, want %v", tt.scope, tt.pendingPods, got, tt.want)
+		}
+	}
+}
+
+func TestIsUpgradeTarget(t *testing.T) {
+	rackImage := "aerospike/aerospike-server-enterprise:5.2.0.8"
+
+	targetTests := []struct {
+		name          string
+		rackImage     string
+		upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus
+		want          bool
+	}{
+		{"no upgrade", "", nil, false},
+		{"cluster image", "", &aerospikev1alpha1.AerospikeUpgradeStatus{TargetImage: testTargetImage}, true},
+		{"earlier upgrade", "", &aerospikev1alpha1.AerospikeUpgradeStatus{TargetImage: testPreviousImage}, false},
+		{"rack image changed", rackImage, &aerospikev1alpha1.AerospikeUpgradeStatus{TargetImage: testTargetImage}, false},
+		{"rack image", rackImage, &aerospikev1alpha1.AerospikeUpgradeStatus{
+			TargetImage: testTargetImage,
+			Racks:       []aerospikev1alpha1.AerospikeRackUpgradeStatus{{ID: 1, TargetImage: rackImage}},
+		}, true},
+		{"rack image removed", "", &aerospikev1alpha1.AerospikeUpgradeStatus{
+			TargetImage: testTargetImage,
+			Racks:       []aerospikev1alpha1.AerospikeRackUpgradeStatus{{ID: 1, TargetImage: rackImage}},
+		}, false},
+	}
+
+	for _, tt := range targetTests {
+		aeroCluster := newUpgradeTestCluster(nil, nil)
+		aeroCluster.Spec.RackConfig.Racks[0].PodSpec.Image = tt.rackImage
+		if got := isUpgradeTarget(aeroCluster, tt.upgradeStatus); got != tt.want {
+			t.Errorf("isUpgradeTarget(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// phaseRecordingClient records the upgrade phase changes of the status patches and can fail the StatefulSet reads.
+type phaseRecordingClient struct {
+	client.Client
+	phases         []aerospikev1alpha1.AerospikeUpgradePhase
+	statefulSetErr error
+}
+
+type phaseRecordingStatusWriter struct {
+	client.StatusWriter
+	c *phaseRecordingClient
+}
+
+func (c *phaseRecordingClient) Get(ctx context.Context, key client.ObjectKey, obj k8sRuntime.Object) error {
+	if _, ok := obj.(*appsv1.StatefulSet); ok && c.statefulSetErr != nil {
+		return c.statefulSetErr
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *phaseRecordingClient) Status() client.StatusWriter {
+	return &phaseRecordingStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+func (w *phaseRecordingStatusWriter) Patch(ctx context.Context, obj k8sRuntime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.StatusWriter.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	if aeroCluster, ok := obj.(*aerospikev1alpha1.AerospikeCluster); ok && aeroCluster.Status.Upgrade != nil {
+		if n := len(w.c.phases); n == 0 || w.c.phases[n-1] != aeroCluster.Status.Upgrade.Phase {
+			w.c.phases = append(w.c.phases, aeroCluster.Status.Upgrade.Phase)
+		}
+	}
+	return nil
+}
+
+func newUpgradeTestStatefulSet(name, image string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "aerospike"},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "aerospike-server", Image: image}}}},
+		},
+	}
+}
+
+func TestRollbackUpgrade(t *testing.T) {
+	const (
+		rackTargetImage   = "aerospike/aerospike-server-enterprise:5.2.0.8"
+		rackPreviousImage = "aerospike/aerospike-server-enterprise:5.1.0.11"
+	)
+
+	// Rack 1 runs the cluster image, rack 2 overrides it
+	newRollbackTestCluster := func(previousImage string) *aerospikev1alpha1.AerospikeCluster {
+		aeroCluster := newUpgradeTestCluster(&aerospikev1alpha1.AerospikeUpgradeStatus{
+			TargetImage:   testTargetImage,
+			PreviousImage: previousImage,
+			Racks:         []aerospikev1alpha1.AerospikeRackUpgradeStatus{{ID: 2, TargetImage: rackTargetImage, PreviousImage: rackPreviousImage}},
+			Phase:         aerospikev1alpha1.AerospikeUpgradePhaseRollingOut,
+		}, &aerospikev1alpha1.AerospikeUpgradeStrategy{AutoRollback: true})
+		rack := aerospikev1alpha1.Rack{ID: 2}
+		rack.PodSpec.Image = rackTargetImage
+		aeroCluster.Spec.RackConfig.Racks = append(aeroCluster.Spec.RackConfig.Racks, rack)
+		return aeroCluster
+	}
+
+	rollbackTests := []struct {
+		name           string
+		previousImage  string
+		statefulSets   bool
+		statefulSetErr error
+		wantPhases     []aerospikev1alpha1.AerospikeUpgradePhase
+		wantImages     map[string]string
+		wantDegraded   bool
+		wantErr        bool
+	}{
+		{"roll back", testPreviousImage, true, nil,
+			[]aerospikev1alpha1.AerospikeUpgradePhase{aerospikev1alpha1.AerospikeUpgradePhaseRollingOut, aerospikev1alpha1.AerospikeUpgradePhaseRollingBack, aerospikev1alpha1.AerospikeUpgradePhaseRolledBack},
+			map[string]string{"aerocluster-1": testPreviousImage, "aerocluster-2": rackPreviousImage}, true, false},
+		{"racks not created", testPreviousImage, false, nil,
+			[]aerospikev1alpha1.AerospikeUpgradePhase{aerospikev1alpha1.AerospikeUpgradePhaseRollingOut, aerospikev1alpha1.AerospikeUpgradePhaseRollingBack, aerospikev1alpha1.AerospikeUpgradePhaseRolledBack},
+			nil, true, false},
+		{"rack read fails", testPreviousImage, true, errors.NewServiceUnavailable("unavailable"),
+			[]aerospikev1alpha1.AerospikeUpgradePhase{aerospikev1alpha1.AerospikeUpgradePhaseRollingOut, aerospikev1alpha1.AerospikeUpgradePhaseRollingBack},
+			map[string]string{"aerocluster-1": testTargetImage, "aerocluster-2": rackTargetImage}, false, true},
+		{"no previous image", "", true, nil, []aerospikev1alpha1.AerospikeUpgradePhase{aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+			map[string]string{"aerocluster-1": testTargetImage, "aerocluster-2": rackTargetImage}, true, false},
+	}
+
+	for _, tt := range rollbackTests {
+		aeroCluster := newRollbackTestCluster(tt.previousImage)
+		objs := []k8sRuntime.Object{aeroCluster.DeepCopy()}
+		if tt.statefulSets {
+			objs = append(objs, newUpgradeTestStatefulSet("aerocluster-1", testTargetImage), newUpgradeTestStatefulSet("aerocluster-2", rackTargetImage))
+		}
+		c := &phaseRecordingClient{
+			Client:         newTestClient(t, objs...),
+			phases:         []aerospikev1alpha1.AerospikeUpgradePhase{aerospikev1alpha1.AerospikeUpgradePhaseRollingOut},
+			statefulSetErr: tt.statefulSetErr,
+		}
+		r := &ReconcileAerospikeCluster{client: c}
+
+		if _, err := r.rollbackUpgrade(aeroCluster, "canary failed"); (err != nil) != tt.wantErr {
+			t.Errorf("rollbackUpgrade(%s) error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(c.phases, tt.wantPhases) {
+			t.Errorf("rollbackUpgrade(%s) phases = %v, want %v", tt.name, c.phases, tt.wantPhases)
+		}
+
+		c.statefulSetErr = nil
+		for name, want := range tt.wantImages {
+			found := &appsv1.StatefulSet{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "aerospike"}, found); err != nil {
+				t.Fatal(err)
+			}
+			if got := found.Spec.Template.Spec.Containers[0].Image; got != want {
+				t.Errorf("rollbackUpgrade(%s) StatefulSet %s image = %s, want %s", tt.name, name, got, want)
+			}
+		}
+
+		degraded := getClusterCondition(aeroCluster.Status.Conditions, aerospikev1alpha1.AerospikeClusterDegraded)
+		if gotDegraded := degraded != nil && degraded.Status == corev1.ConditionTrue && degraded.Reason == upgradeFailedReason; gotDegraded != tt.wantDegraded {
+			t.Errorf("rollbackUpgrade(%s) degraded = %v, want %v", tt.name, degraded, tt.wantDegraded)
+		}
+	}
+}
+
+func TestCanRollbackUpgrade(t *testing.T) {
+	upgradeStatus := &aerospikev1alpha1.AerospikeUpgradeStatus{TargetImage: testTargetImage, PreviousImage: testPreviousImage}
+
+	rollbackTests := []struct {
+		name          string
+		strategy      *aerospikev1alpha1.AerospikeUpgradeStrategy
+		upgradeStatus *aerospikev1alpha1.AerospikeUpgradeStatus
+		want          bool
+	}{
+		{"no strategy", nil, upgradeStatus, false},
+		{"auto rollback disabled", &aerospikev1alpha1.AerospikeUpgradeStrategy{}, upgradeStatus, false},
+		{"auto rollback", &aerospikev1alpha1.AerospikeUpgradeStrategy{AutoRollback: true}, upgradeStatus, true},
+		{"no upgrade", &aerospikev1alpha1.AerospikeUpgradeStrategy{AutoRollback: true}, nil, false},
+		{"image changed", &aerospikev1alpha1.AerospikeUpgradeStrategy{AutoRollback: true},
+			&aerospikev1alpha1.AerospikeUpgradeStatus{TargetImage: "aerospike/aerospike-server-enterprise:5.3.0.1", PreviousImage: testPreviousImage}, false},
+	}
+
+	for _, tt := range rollbackTests {
+		if got := canRollbackUpgrade(newUpgradeTestCluster(tt.upgradeStatus, tt.strategy)); got != tt.want {
+			t.Errorf("canRollbackUpgrade(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetRackUpgradeStatus(t *testing.T) {
+	const (
+		rackTargetImage   = "aerospike/aerospike-server-enterprise:5.2.0.8"
+		rackPreviousImage = "aerospike/aerospike-server-enterprise:5.1.0.11"
+	)
+
+	rackTests := []struct {
+		name              string
+		rackTargetImage   string
+		rackPreviousImage string
+		want              []aerospikev1alpha1.AerospikeRackUpgradeStatus
+	}{
+		{"cluster images", "", "", nil},
+		{"rack target image", rackTargetImage, "", []aerospikev1alpha1.AerospikeRackUpgradeStatus{{ID: 1, TargetImage: rackTargetImage, PreviousImage: testPreviousImage}}},
+		{"rack previous image", "", rackPreviousImage, []aerospikev1alpha1.AerospikeRackUpgradeStatus{{ID: 1, TargetImage: testTargetImage, PreviousImage: rackPreviousImage}}},
+		{"rack images", rackTargetImage, rackPreviousImage, []aerospikev1alpha1.AerospikeRackUpgradeStatus{{ID: 1, TargetImage: rackTargetImage, PreviousImage: rackPreviousImage}}},
+	}
+
+	for _, tt := range rackTests {
+		aeroCluster := newUpgradeTestCluster(nil, nil)
+		aeroCluster.Status.AerospikeClusterSpec.Image = testPreviousImage
+		aeroCluster.Spec.RackConfig.Racks[0].PodSpec.Image = tt.rackTargetImage
+		aeroCluster.Status.RackConfig.Racks[0].PodSpec.Image = tt.rackPreviousImage
+		if got := getRackUpgradeStatus(aeroCluster); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getRackUpgradeStatus(%s) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetUpgradeRackImages(t *testing.T) {
+	upgradeStatus := &aerospikev1alpha1.AerospikeUpgradeStatus{
+		TargetImage:   testTargetImage,
+		PreviousImage: testPreviousImage,
+		Racks: []aerospikev1alpha1.AerospikeRackUpgradeStatus{
+			{ID: 2, TargetImage: "aerospike/aerospike-server-enterprise:5.2.0.8", PreviousImage: "aerospike/aerospike-server-enterprise:5.1.0.11"},
+		},
+	}
+
+	imageTests := []struct {
+		rackID       int
+		wantTarget   string
+		wantPrevious string
+	}{
+		{1, testTargetImage, testPreviousImage},
+		{2, "aerospike/aerospike-server-enterprise:5.2.0.8", "aerospike/aerospike-server-enterprise:5.1.0.11"},
+	}
+
+	for _, tt := range imageTests {
+		if target, previous := getUpgradeRackImages(upgradeStatus, tt.rackID); target != tt.wantTarget || previous != tt.wantPrevious {
+			t.Errorf("getUpgradeRackImages(%d) = %s, %s, want %s, %s", tt.rackID, target, previous, tt.wantTarget, tt.wantPrevious)
+		}
+	}
+}
+
+func TestCheckCanaryHealth(t *testing.T) {
+	canaryPod := func(ready corev1.ConditionStatus, restartCount int32) *corev1.Pod {
+		pod := newUpgradeTestPod("aerocluster-1-0", testTargetImage)
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "aerospike-server", Ready: ready == corev1.ConditionTrue, RestartCount: restartCount}}
+		return pod
+	}
+
+	// The cluster stability is checked on the nodes once the canary pods are healthy, so only the unhealthy pods are tested
+	healthTests := []struct {
+		name    string
+		pod     *corev1.Pod
+		wantErr string
+	}{
+		{"missing", nil, "Failed to get canary pod"},
+		{"running not ready", canaryPod(corev1.ConditionFalse, 0), "is not running and ready"},
+		{"restarted", canaryPod(corev1.ConditionTrue, 1), "restarted 1 times"},
+	}
+
+	for _, tt := range healthTests {
+		var objs []k8sRuntime.Object
+		if tt.pod != nil {
+			objs = append(objs, tt.pod)
+		}
+		r := &ReconcileAerospikeCluster{client: newTestClient(t, objs...)}
+
+		err := r.checkCanaryHealth(newPlanTestCluster(), []string{"aerocluster-1-0"})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("checkCanaryHealth(%s) error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
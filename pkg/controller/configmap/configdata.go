@@ -146,6 +146,29 @@ print(gethost(data, host))")"
 
 export INTERNALIP=$(echo $HOSTIPS | awk '{print $1}')
 export EXTERNALIP=$(echo $HOSTIPS | awk '{print $2}')
+{{- if .LoadBalancer}}
+
+# Wait for the ingress address of the pod's load balancer service.
+for i in $(seq 1 60); do
+    LBSVC="$(curl -f --cacert $CA_CERT -H "Authorization: Bearer $TOKEN" "$KUBE_API_SERVER/api/v1/namespaces/$NAMESPACE/services/$MY_POD_NAME")"
+    LBIP="$(echo $LBSVC | python3 -c "import sys, json
+data = json.load(sys.stdin)
+ingress = data.get('status', {}).get('loadBalancer', {}).get('ingress', [])
+print(ingress[0].get('ip', ingress[0].get('hostname', '')) if ingress else '')")"
+    if [ -n "$LBIP" ]; then
+        break
+    fi
+    echo "Waiting for load balancer address of service $MY_POD_NAME"
+    sleep 5
+done
+
+if [ -z "$LBIP" ]
+then
+   echo "ERROR: no load balancer address for service $MY_POD_NAME"
+   exit 1
+fi
+export LBIP
+{{- end}}
 
 export POD_PORT="{{.PodPort}}"
 export POD_TLSPORT="{{.PodTLSPort}}"
@@ -172,6 +195,7 @@ substituteEndpoint() {
     local externalIP=$5
     local podPort=$6
     local mappedPort=$7
+    local lbIP=$8
 
     case $networkType in
       pod)
@@ -189,6 +213,11 @@ substituteEndpoint() {
         accessPort=$mappedPort
         ;;
 
+      loadBalancer)
+        accessAddress=$lbIP
+        accessPort=$podPort
+        ;;
+
       *)
         accessAddress=$podIP
         accessPort=$podPort
@@ -205,12 +234,12 @@ substituteEndpoint() {
     sed -i "s/^\(\s*\)${addressType}-port.*${podPort}/\1${addressType}-port    ${accessPort}/" ${CFG}
 }
 
-substituteEndpoint "access" {{.NetworkPolicy.AccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_PORT $MAPPED_PORT
-substituteEndpoint "alternate-access" {{.NetworkPolicy.AlternateAccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_PORT $MAPPED_PORT
+substituteEndpoint "access" {{.NetworkPolicy.AccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_PORT $MAPPED_PORT $LBIP
+substituteEndpoint "alternate-access" {{.NetworkPolicy.AlternateAccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_PORT $MAPPED_PORT $LBIP
 
 if [ "true" == "$MY_POD_TLS_ENABLED" ]; then
-  substituteEndpoint "tls-access" {{.NetworkPolicy.TLSAccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_TLSPORT $MAPPED_TLSPORT
-  substituteEndpoint "tls-alternate-access" {{.NetworkPolicy.TLSAlternateAccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_TLSPORT $MAPPED_TLSPORT
+  substituteEndpoint "tls-access" {{.NetworkPolicy.TLSAccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_TLSPORT $MAPPED_TLSPORT $LBIP
+  substituteEndpoint "tls-alternate-access" {{.NetworkPolicy.TLSAlternateAccessType}} $PODIP $INTERNALIP $EXTERNALIP $POD_TLSPORT $MAPPED_TLSPORT $LBIP
 fi
 
 
@@ -512,6 +541,7 @@ type initializeTemplateInput struct {
 	WorkDir         string
 	MultiPodPerHost bool
 	NetworkPolicy   aerospikev1alpha1.AerospikeNetworkPolicy
+	LoadBalancer    bool
 	PodPort         int32
 	PodTLSPort      int32
 }
@@ -524,7 +554,15 @@ func getBaseConfData(aeroCluster *aerospikev1alpha1.AerospikeCluster, rack aeros
 	config := rack.AerospikeConfig
 	workDir := utils.GetWorkDirectory(config)
 
-	initializeTemplateInput := initializeTemplateInput{WorkDir: workDir, MultiPodPerHost: aeroCluster.Spec.MultiPodPerHost, NetworkPolicy: aeroCluster.Spec.AerospikeNetworkPolicy, PodPort: utils.ServicePort, PodTLSPort: utils.ServiceTLSPort}
+	networkPolicy := aeroCluster.Spec.AerospikeNetworkPolicy
+	loadBalancer := false
+	for _, networkType := range []aerospikev1alpha1.AerospikeNetworkType{networkPolicy.AccessType, networkPolicy.AlternateAccessType, networkPolicy.TLSAccessType, networkPolicy.TLSAlternateAccessType} {
+		if networkType == aerospikev1alpha1.AerospikeNetworkTypeLoadBalancer {
+			loadBalancer = true
+		}
+	}
+
+	initializeTemplateInput := initializeTemplateInput{WorkDir: workDir, MultiPodPerHost: aeroCluster.Spec.MultiPodPerHost, NetworkPolicy: networkPolicy, LoadBalancer: loadBalancer, PodPort: utils.ServicePort, PodTLSPort: utils.ServiceTLSPort}
 	var initializeSh bytes.Buffer
 	err := initializeShTemplate.Execute(&initializeSh, initializeTemplateInput)
 	if err != nil {
@@ -2,15 +2,18 @@ package configmap
 
 import (
 	"fmt"
-	"strings"
 
 	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-kubernetes-operator/pkg/controller/utils"
 	"github.com/aerospike/aerospike-management-lib/asconfig"
 	log "github.com/inconshreveable/log15"
 )
 
 var pkglog = log.New(log.Ctx{"module": "lib.asconfig"})
 
+// AerospikeTemplateConfFileName is the key of the rendered aerospike.conf template in the rack ConfigMap
+const AerospikeTemplateConfFileName = "aerospike.template.conf"
+
 // CreateConfigMapData create configMap data
 func CreateConfigMapData(aeroCluster *aerospikev1alpha1.AerospikeCluster, rack aerospikev1alpha1.Rack) (map[string]string, error) {
 	// Add config template
@@ -23,19 +26,22 @@ func CreateConfigMapData(aeroCluster *aerospikev1alpha1.AerospikeCluster, rack a
 		return nil, fmt.Errorf("Failed to build config template: %v", err)
 	}
 
-	confData["aerospike.template.conf"] = temp
+	confData[AerospikeTemplateConfFileName] = temp
 
 	return confData, nil
 }
 
 func buildConfigTemplate(aeroCluster *aerospikev1alpha1.AerospikeCluster, rack aerospikev1alpha1.Rack) (string, error) {
-	version := strings.Split(aeroCluster.Spec.Image, ":")
+	version, err := utils.GetAerospikeVersion(&aeroCluster.Spec)
+	if err != nil {
+		return "", err
+	}
 
 	config := rack.AerospikeConfig
 
-	pkglog.Debug("AerospikeConfig", log.Ctx{"config": config, "image": aeroCluster.Spec.Image})
+	pkglog.Debug("AerospikeConfig", log.Ctx{"config": config, "image": aeroCluster.Spec.Image, "version": version})
 
-	asConf, err := asconfig.NewMapAsConfig(version[1], config)
+	asConf, err := asconfig.NewMapAsConfig(version, config)
 	if err != nil {
 		return "", fmt.Errorf("Failed to load config map by lib: %v", err)
 	}
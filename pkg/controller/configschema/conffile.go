@@ -0,0 +1,327 @@
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aerospike/aerospike-management-lib/asconfig"
+)
+
+// sizeUnits are the multipliers of the size suffixes in aerospike.conf e.g. 4G
+var sizeUnits = map[string]int64{
+	"K": 1 << 10, "k": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30, "g": 1 << 30,
+	"T": 1 << 40, "t": 1 << 40,
+	"P": 1 << 50, "p": 1 << 50,
+}
+
+// timeUnits are the multipliers of the time suffixes in aerospike.conf e.g. 30d
+var timeUnits = map[string]int64{
+	"s": 1,
+	"m": 60,
+	"h": 60 * 60,
+	"d": 24 * 60 * 60,
+}
+
+// confLine is a non empty aerospike.conf line split in tokens.
+type confLine struct {
+	num    int
+	tokens []string
+}
+
+// confParser parses the aerospike.conf lines into the aerospikeConfig format.
+type confParser struct {
+	lines []confLine
+	pos   int
+}
+
+// ParseConfFile parses the aerospike.conf text into the aerospikeConfig format used in the spec.
+// Value types and list params are taken from the config schema of the version.
+func ParseConfFile(text, version string) (map[string]interface{}, error) {
+	schemaVersion, err := GetSchemaVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaLock.RLock()
+	schemaJSON := schemas[schemaVersion]
+	schemaLock.RUnlock()
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return nil, fmt.Errorf("Failed to parse config schema %s: %v", schemaVersion, err)
+	}
+
+	p := &confParser{lines: splitConfLines(text)}
+	config := map[string]interface{}{}
+	if err := p.parseSection(config, schema, ""); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// splitConfLines returns the tokens of the lines without comments and empty lines.
+func splitConfLines(text string) []confLine {
+	var lines []confLine
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.Split(line, "#")[0]
+		line = strings.Replace(line, "{", " { ", -1)
+		line = strings.Replace(line, "}", " } ", -1)
+
+		tokens := strings.Fields(line)
+		if len(tokens) != 0 {
+			lines = append(lines, confLine{num: i + 1, tokens: tokens})
+		}
+	}
+	return lines
+}
+
+// parseSection parses the lines till the end of the section at path into conf.
+func (p *confParser) parseSection(conf map[string]interface{}, schema map[string]interface{}, path string) error {
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		p.pos++
+
+		tokens := line.tokens
+		last := tokens[len(tokens)-1]
+
+		switch {
+		case tokens[0] == "}":
+			if path == "" || len(tokens) != 1 {
+				return fmt.Errorf("Unexpected } at line %d", line.num)
+			}
+			return nil
+
+		case tokens[0] == "include":
+			return fmt.Errorf("include is not supported, line %d", line.num)
+
+		case last == "{":
+			if err := p.parseSubSection(conf, schema, path, line); err != nil {
+				return err
+			}
+
+		default:
+			if err := parseParam(conf, schema, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if path != "" {
+		return fmt.Errorf("Missing } for section %s", path)
+	}
+	return nil
+}
+
+// parseSubSection parses a section starting at line e.g. "namespace test {" into conf.
+func (p *confParser) parseSubSection(conf map[string]interface{}, schema map[string]interface{}, path string, line confLine) error {
+	name := line.tokens[0]
+	args := line.tokens[1 : len(line.tokens)-1]
+	if name == "{" || len(args) > 1 {
+		return fmt.Errorf("Invalid section at line %d", line.num)
+	}
+
+	if name == "logging" {
+		logging, _ := conf[name].([]interface{})
+		logging, err := p.parseLogging(logging, getItemSchema(getProperty(schema, name)), path+"/"+name)
+		if err != nil {
+			return err
+		}
+		conf[name] = logging
+		return nil
+	}
+
+	// Named sections e.g. namespace, set, tls, dc are lists of maps with name
+	key := asconfig.PluralOf(name)
+	if key != name || isArraySchema(getProperty(schema, key)) {
+		if len(args) != 1 {
+			return fmt.Errorf("Missing name for section %s at line %d", name, line.num)
+		}
+
+		item := map[string]interface{}{"name": args[0]}
+		if err := p.parseSection(item, getItemSchema(getProperty(schema, key)), path+"/"+key+"/"+args[0]); err != nil {
+			return err
+		}
+		list, _ := conf[key].([]interface{})
+		conf[key] = append(list, item)
+		return nil
+	}
+
+	if name == "storage-engine" {
+		if len(args) != 1 || args[0] != "device" {
+			return fmt.Errorf("storage-engine %s is not supported, line %d", strings.Join(args, " "), line.num)
+		}
+	} else if len(args) != 0 {
+		return fmt.Errorf("Unexpected name for section %s at line %d", name, line.num)
+	}
+
+	section, ok := conf[name].(map[string]interface{})
+	if !ok {
+		section = map[string]interface{}{}
+	}
+	if err := p.parseSection(section, getObjectSchema(getProperty(schema, name)), path+"/"+name); err != nil {
+		return err
+	}
+	conf[name] = section
+	return nil
+}
+
+// parseLogging parses the log sinks of the logging section e.g. "file /var/log/aerospike.log {" and "console {".
+func (p *confParser) parseLogging(logging []interface{}, schema map[string]interface{}, path string) ([]interface{}, error) {
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		p.pos++
+
+		tokens := line.tokens
+		switch {
+		case len(tokens) == 1 && tokens[0] == "}":
+			return logging, nil
+
+		case len(tokens) == 2 && tokens[0] == "console" && tokens[1] == "{":
+			item := map[string]interface{}{"name": "console"}
+			if err := p.parseSection(item, schema, path+"/console"); err != nil {
+				return nil, err
+			}
+			logging = append(logging, item)
+
+		case len(tokens) == 3 && tokens[0] == "file" && tokens[2] == "{":
+			item := map[string]interface{}{"name": tokens[1]}
+			if err := p.parseSection(item, schema, path+"/"+tokens[1]); err != nil {
+				return nil, err
+			}
+			logging = append(logging, item)
+
+		default:
+			return nil, fmt.Errorf("Invalid logging config at line %d", line.num)
+		}
+	}
+	return nil, fmt.Errorf("Missing } for section %s", path)
+}
+
+// parseParam parses a param line e.g. "replication-factor 2" into conf.
+func parseParam(conf map[string]interface{}, schema map[string]interface{}, line confLine) error {
+	name := line.tokens[0]
+	values := line.tokens[1:]
+	value := strings.Join(values, " ")
+
+	// Log levels e.g. "context any info"
+	if name == "context" {
+		if len(values) != 2 {
+			return fmt.Errorf("Invalid context at line %d", line.num)
+		}
+		conf[values[0]] = values[1]
+		return nil
+	}
+
+	// List params are given once per value e.g. "address any"
+	if key := asconfig.PluralOf(name); isArraySchema(getProperty(schema, key)) {
+		list, _ := conf[key].([]interface{})
+		conf[key] = append(list, value)
+		return nil
+	}
+
+	paramSchema := getObjectSchema(getProperty(schema, name))
+	switch paramSchema["type"] {
+	case "integer":
+		if len(values) != 1 {
+			return fmt.Errorf("Invalid value for %s at line %d", name, line.num)
+		}
+		n, err := parseConfInt(values[0])
+		if err != nil {
+			return fmt.Errorf("Invalid value %s for %s at line %d", values[0], name, line.num)
+		}
+		conf[name] = n
+
+	case "boolean":
+		if len(values) == 0 {
+			// Flags e.g. "enable-benchmarks-read"
+			conf[name] = true
+			return nil
+		}
+		b, err := parseConfBool(value)
+		if err != nil {
+			return fmt.Errorf("Invalid value %s for %s at line %d", value, name, line.num)
+		}
+		conf[name] = b
+
+	case nil:
+		// Not in the schema, keep the value as is to fail the schema validation with the param name
+		if len(values) == 0 {
+			conf[name] = true
+		} else if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			conf[name] = n
+		} else {
+			conf[name] = value
+		}
+
+	default:
+		if len(values) == 0 {
+			return fmt.Errorf("Missing value for %s at line %d", name, line.num)
+		}
+		conf[name] = value
+	}
+	return nil
+}
+
+// parseConfInt parses an integer with an optional size or time suffix.
+func parseConfInt(value string) (int64, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+
+	suffix := value[len(value)-1:]
+	unit, ok := sizeUnits[suffix]
+	if !ok {
+		if unit, ok = timeUnits[suffix]; !ok {
+			return 0, fmt.Errorf("Invalid integer %s", value)
+		}
+	}
+
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * unit, nil
+}
+
+func parseConfBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func getProperty(schema map[string]interface{}, name string) map[string]interface{} {
+	properties, _ := getObjectSchema(schema)["properties"].(map[string]interface{})
+	property, _ := properties[name].(map[string]interface{})
+	return property
+}
+
+// getObjectSchema returns the object schema from the oneOf schemas e.g. storage-engine is memory or an object.
+func getObjectSchema(schema map[string]interface{}) map[string]interface{} {
+	oneOf, ok := schema["oneOf"].([]interface{})
+	if !ok {
+		return schema
+	}
+	for _, s := range oneOf {
+		if sub, ok := s.(map[string]interface{}); ok && sub["type"] == "object" {
+			return sub
+		}
+	}
+	return schema
+}
+
+func getItemSchema(schema map[string]interface{}) map[string]interface{} {
+	items, _ := schema["items"].(map[string]interface{})
+	return items
+}
+
+func isArraySchema(schema map[string]interface{}) bool {
+	return schema != nil && schema["type"] == "array"
+}
@@ -0,0 +1,124 @@
+package configschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfFile(t *testing.T) {
+	text := `
+# Aerospike database configuration file
+service {
+	proto-fd-max 15000
+	feature-key-file /etc/aerospike/secret/features.conf
+}
+
+logging {
+	file /var/log/aerospike/aerospike.log {
+		context any info
+	}
+	console {
+		context any info
+		context migrate debug
+	}
+}
+
+network {
+	service {
+		address any
+		port 3000
+	}
+	heartbeat {
+		mode mesh
+		port 3002
+		mesh-seed-address-port 10.0.0.1 3002
+		mesh-seed-address-port 10.0.0.2 3002
+		interval 150
+	}
+	fabric {
+		port 3001
+	}
+}
+
+namespace test {
+	replication-factor 2
+	memory-size 4G
+	default-ttl 30d
+	storage-engine memory
+}
+
+namespace bar {
+	memory-size 1G
+	storage-engine device {
+		file /opt/aerospike/data/bar.dat
+		filesize 2G
+		data-in-memory true
+	}
+}
+`
+
+	want := map[string]interface{}{
+		"service": map[string]interface{}{
+			"proto-fd-max":     int64(15000),
+			"feature-key-file": "/etc/aerospike/secret/features.conf",
+		},
+		"logging": []interface{}{
+			map[string]interface{}{"name": "/var/log/aerospike/aerospike.log", "any": "info"},
+			map[string]interface{}{"name": "console", "any": "info", "migrate": "debug"},
+		},
+		"network": map[string]interface{}{
+			"service": map[string]interface{}{
+				"addresses": []interface{}{"any"},
+				"port":      int64(3000),
+			},
+			"heartbeat": map[string]interface{}{
+				"mode":                    "mesh",
+				"port":                    int64(3002),
+				"mesh-seed-address-ports": []interface{}{"10.0.0.1 3002", "10.0.0.2 3002"},
+				"interval":                int64(150),
+			},
+			"fabric": map[string]interface{}{
+				"port": int64(3001),
+			},
+		},
+		"namespaces": []interface{}{
+			map[string]interface{}{
+				"name":               "test",
+				"replication-factor": int64(2),
+				"memory-size":        int64(4 << 30),
+				"default-ttl":        int64(30 * 24 * 60 * 60),
+				"storage-engine":     "memory",
+			},
+			map[string]interface{}{
+				"name":        "bar",
+				"memory-size": int64(1 << 30),
+				"storage-engine": map[string]interface{}{
+					"files":          []interface{}{"/opt/aerospike/data/bar.dat"},
+					"filesize":       int64(2 << 30),
+					"data-in-memory": true,
+				},
+			},
+		},
+	}
+
+	got, err := ParseConfFile(text, "5.2.0.7")
+	if err != nil {
+		t.Fatalf("ParseConfFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseConfFile() = %v, want %v", got, want)
+	}
+
+	invalidTests := []string{
+		"service {\n\tproto-fd-max 15000\n",
+		"service {\n}\n}\n",
+		"namespace {\n}\n",
+		"include /etc/aerospike/other.conf\n",
+		"namespace test {\n\treplication-factor two\n}\n",
+	}
+	for _, text := range invalidTests {
+		if _, err := ParseConfFile(text, "5.2.0.7"); err == nil {
+			t.Errorf("ParseConfFile(%q) expected error", text)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package configschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aerospike/aerospike-management-lib/asconfig"
+	log "github.com/inconshreveable/log15"
+)
+
+var pkglog = log.New(log.Ctx{"module": "configschema"})
+
+// schemaVersionRe matches the schema versions e.g. 5.3.0
+var schemaVersionRe = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+
+var (
+	schemaLock sync.RWMutex
+	// schemas has the built in schemas and the loaded schemas.
+	schemas = copySchemas(SchemaMap)
+)
+
+// Init initializes asconfig with the built in schemas merged with the schemas in schemaDir.
+// schemaDir is optional, it can be a mounted ConfigMap. It has to be called once at startup, before the schemas are used.
+func Init(schemaDir string) error {
+	extra := map[string]string{}
+	if schemaDir != "" {
+		var err error
+		if extra, err = ReadSchemaDir(schemaDir); err != nil {
+			return err
+		}
+	}
+	return SetSchemas(extra)
+}
+
+// SetSchemas merges extra with the built in schemas and initializes asconfig with them.
+// The extra schemas take precedence over the built in ones. Map key is the version e.g. 5.3.0 and value is the json schema.
+// asconfig keeps the schemas in a global map which is not safe for concurrent use, so SetSchemas cannot be called while
+// the schemas are used by the webhook or the controller.
+func SetSchemas(extra map[string]string) error {
+	merged := copySchemas(SchemaMap)
+	for version, schema := range extra {
+		if !schemaVersionRe.MatchString(version) {
+			return fmt.Errorf("Invalid config schema version %s", version)
+		}
+		if !json.Valid([]byte(schema)) {
+			return fmt.Errorf("Invalid config schema for version %s", version)
+		}
+		merged[version] = schema
+	}
+
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+
+	schemas = merged
+	asconfig.InitFromMap(schemas)
+
+	pkglog.Info("Initialized config schemas", log.Ctx{"loadedVersions": sortedVersions(extra)})
+	return nil
+}
+
+// GetValidationVersion returns the version to validate a config of the version with. It is the version of the schema
+// of the nearest lower version if there is no schema for the exact version.
+func GetValidationVersion(version string) (string, error) {
+	baseVersion, err := asconfig.BaseVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("Invalid aerospike version %s: %v", version, err)
+	}
+
+	schemaVersion, err := GetSchemaVersion(version)
+	if err != nil {
+		return "", err
+	}
+	if schemaVersion != baseVersion {
+		pkglog.Warn("No config schema for version, using the schema of the nearest lower version", log.Ctx{"version": version, "schemaVersion": schemaVersion})
+		return schemaVersion, nil
+	}
+	return version, nil
+}
+
+// ReadSchemaDir reads the schemas in dir. The file names are the versions e.g. 5_3_0.json or 5.3.0.json.
+// Hidden files are skipped, they are the internal files of a mounted ConfigMap.
+func ReadSchemaDir(dir string) (map[string]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config schema dir %s: %v", dir, err)
+	}
+
+	extra := map[string]string{}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		schema, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read config schema file %s: %v", file.Name(), err)
+		}
+		extra[schemaFileVersion(file.Name())] = string(schema)
+	}
+	return extra, nil
+}
+
+// WatchSchemaDir polls dir every interval till stop is closed. It returns true when the schemas in dir change.
+// The schemas are loaded only at startup, so the operator has to be restarted to load the changed schemas.
+func WatchSchemaDir(dir string, interval time.Duration, stop <-chan struct{}) bool {
+	logger := pkglog.New(log.Ctx{"dir": dir})
+
+	lastHash := ""
+	if extra, err := ReadSchemaDir(dir); err == nil {
+		lastHash = hashSchemas(extra)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-ticker.C:
+		}
+
+		extra, err := ReadSchemaDir(dir)
+		if err != nil {
+			logger.Error("Failed to read config schemas", log.Ctx{"err": err})
+			continue
+		}
+
+		if hashSchemas(extra) != lastHash {
+			logger.Info("Config schemas changed")
+			return true
+		}
+	}
+}
+
+// schemaFileVersion returns the version for a schema file name e.g. 5.3.0 for 5_3_0.json.
+func schemaFileVersion(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.Replace(name, "_", ".", -1)
+}
+
+func hashSchemas(extra map[string]string) string {
+	hash := sha256.New()
+	for _, version := range sortedVersions(extra) {
+		hash.Write([]byte(version))
+		hash.Write([]byte(extra[version]))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func sortedVersions(m map[string]string) []string {
+	versions := make([]string, 0, len(m))
+	for version := range m {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func copySchemas(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,70 @@
+package configschema
+
+import (
+	"testing"
+
+	"github.com/aerospike/aerospike-management-lib/asconfig"
+)
+
+func TestSchemaFileVersion(t *testing.T) {
+	nameTests := []struct {
+		name string
+		want string
+	}{
+		{"5_3_0.json", "5.3.0"},
+		{"5.3.0.json", "5.3.0"},
+		{"5_3_0", "5.3.0"},
+	}
+
+	for _, tt := range nameTests {
+		if got := schemaFileVersion(tt.name); got != tt.want {
+			t.Errorf("schemaFileVersion(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetValidationVersion(t *testing.T) {
+	if err := SetSchemas(map[string]string{"5.3.0": SchemaMap["5.2.0"]}); err != nil {
+		t.Fatalf("SetSchemas() error = %v", err)
+	}
+	defer SetSchemas(nil)
+
+	if got, _ := GetSchemaVersion("5.3.0.2"); got != "5.3.0" {
+		t.Errorf("GetSchemaVersion(5.3.0.2) = %s, want loaded schema 5.3.0", got)
+	}
+
+	versionTests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"5.3.0.2", "5.3.0.2", false},
+		{"5.4.0.1", "5.3.0", false},
+		{"3.0.0", "", true},
+		{"latest", "", true},
+	}
+
+	for _, tt := range versionTests {
+		got, err := GetValidationVersion(tt.version)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GetValidationVersion(%s) = (%s, %v), want %s", tt.version, got, err, tt.want)
+		}
+	}
+
+	// The fallback does not add schemas
+	if supported, _ := asconfig.IsSupportedVersion("5.4.0.1"); supported {
+		t.Errorf("GetValidationVersion() added a fallback schema for 5.4.0.1 to asconfig")
+	}
+	for _, version := range getSchemaVersions() {
+		if version == "5.4.0" {
+			t.Errorf("GetValidationVersion() added a fallback schema for 5.4.0")
+		}
+	}
+
+	if err := SetSchemas(map[string]string{"5.3": "{}"}); err == nil {
+		t.Errorf("SetSchemas() accepted invalid version 5.3")
+	}
+	if err := SetSchemas(map[string]string{"5.3.0": "{"}); err == nil {
+		t.Errorf("SetSchemas() accepted invalid schema json")
+	}
+}
@@ -0,0 +1,403 @@
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aerospike/aerospike-management-lib/asconfig"
+)
+
+// translateFunc rewrites the config in place for the schema version it is registered for and returns the changes made.
+type translateFunc func(config map[string]interface{}) []string
+
+// translations has the config rewrites needed when upgrading to a schema version.
+// Params removed without a replacement are dropped by comparing against the target schema.
+var translations = map[string][]translateFunc{
+	"4.3.1": {moveServiceParamToNamespaces("transaction-pending-limit")},
+	"4.5.1": {moveServiceParamToNamespaces("nsup-period")},
+	"4.7.0": {renameServiceParam("scan-threads", "scan-threads-limit")},
+	"5.0.0": {
+		translateXDR5,
+		renameNamespaceParam("allow-nonxdr-writes", "reject-non-xdr-writes", negateBool),
+		renameNamespaceParam("allow-xdr-writes", "reject-xdr-writes", negateBool),
+	},
+	"5.2.0": {renameLoggingContext("predexp", "exp")},
+}
+
+// TranslateConfig returns a copy of the aerospike config rewritten from the schema of fromVersion to the schema of toVersion,
+// along with the list of changes made.
+func TranslateConfig(config map[string]interface{}, fromVersion, toVersion string) (map[string]interface{}, []string, error) {
+	fromSchema, err := GetSchemaVersion(fromVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	toSchema, err := GetSchemaVersion(toVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	translated, _ := copyValue(config).(map[string]interface{})
+	if fromSchema == toSchema || translated == nil {
+		return translated, nil, nil
+	}
+
+	var changes []string
+	isUpgrade := compareVersions(fromSchema, toSchema) < 0
+	if isUpgrade {
+		for _, ver := range getSchemaVersions() {
+			if compareVersions(ver, fromSchema) <= 0 || compareVersions(ver, toSchema) > 0 {
+				continue
+			}
+			for _, translate := range translations[ver] {
+				changes = append(changes, translate(translated)...)
+			}
+		}
+	}
+
+	schemaLock.RLock()
+	schemaJSON := schemas[toSchema]
+	schemaLock.RUnlock()
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse config schema %s: %v", toSchema, err)
+	}
+	pruneConfig(translated, schema, "", &changes)
+
+	return translated, changes, nil
+}
+
+// GetSchemaVersion returns the latest loaded schema version not newer than version.
+func GetSchemaVersion(version string) (string, error) {
+	baseVersion, err := asconfig.BaseVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("Invalid aerospike version %s: %v", version, err)
+	}
+
+	schemaVersion := ""
+	for _, ver := range getSchemaVersions() {
+		if compareVersions(ver, baseVersion) > 0 {
+			break
+		}
+		schemaVersion = ver
+	}
+	if schemaVersion == "" {
+		return "", fmt.Errorf("Unsupported aerospike version %s", version)
+	}
+	return schemaVersion, nil
+}
+
+// IsDynamicParam returns true if the param of the service or namespace context can be changed with set-config in the version.
+func IsDynamicParam(version, context, name string) bool {
+	schemaVersion, err := GetSchemaVersion(version)
+	if err != nil {
+		return false
+	}
+
+	schemaLock.RLock()
+	schemaJSON := schemas[schemaVersion]
+	schemaLock.RUnlock()
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return false
+	}
+
+	var contextSchema map[string]interface{}
+	switch context {
+	case "service":
+		contextSchema = getProperty(schema, "service")
+	case "namespace":
+		contextSchema = getItemSchema(getProperty(schema, "namespaces"))
+	}
+
+	dynamic, _ := getObjectSchema(getProperty(contextSchema, name))["dynamic"].(bool)
+	return dynamic
+}
+
+// getSchemaVersions returns the loaded schema versions in ascending order.
+func getSchemaVersions() []string {
+	schemaLock.RLock()
+	defer schemaLock.RUnlock()
+
+	versions := make([]string, 0, len(schemas))
+	for ver := range schemas {
+		versions = append(versions, ver)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+func compareVersions(version1, version2 string) int {
+	res, _ := asconfig.CompareVersions(version1, version2)
+	return res
+}
+
+// pruneConfig removes the params not allowed by the schema. Values under oneOf and anyOf schemas are left as is.
+func pruneConfig(value interface{}, schema map[string]interface{}, path string, changes *[]string) {
+	if _, ok := schema["oneOf"]; ok {
+		return
+	}
+	if _, ok := schema["anyOf"]; ok {
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		additionalProperties, ok := schema["additionalProperties"].(bool)
+		strict := ok && !additionalProperties
+
+		for _, key := range sortedKeys(v) {
+			subSchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if strict {
+					delete(v, key)
+					*changes = append(*changes, fmt.Sprintf("Removed %s/%s", path, key))
+				}
+				continue
+			}
+			pruneConfig(v[key], subSchema, path+"/"+key, changes)
+		}
+
+	case []interface{}:
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, item := range v {
+			pruneConfig(item, items, fmt.Sprintf("%s/%s", path, getName(item)), changes)
+		}
+	}
+}
+
+func moveServiceParamToNamespaces(param string) translateFunc {
+	return func(config map[string]interface{}) []string {
+		service, ok := config["service"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value, ok := service[param]
+		if !ok {
+			return nil
+		}
+		delete(service, param)
+
+		for _, ns := range getNamespaces(config) {
+			if _, ok := ns[param]; !ok {
+				ns[param] = value
+			}
+		}
+		return []string{fmt.Sprintf("Moved /service/%s to namespaces", param)}
+	}
+}
+
+func renameServiceParam(oldName, newName string) translateFunc {
+	return func(config map[string]interface{}) []string {
+		service, ok := config["service"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if renameParam(service, oldName, newName, nil) {
+			return []string{fmt.Sprintf("Renamed /service/%s to %s", oldName, newName)}
+		}
+		return nil
+	}
+}
+
+func renameNamespaceParam(oldName, newName string, convert func(interface{}) interface{}) translateFunc {
+	return func(config map[string]interface{}) []string {
+		var changes []string
+		for _, ns := range getNamespaces(config) {
+			if renameParam(ns, oldName, newName, convert) {
+				changes = append(changes, fmt.Sprintf("Renamed /namespaces/%s/%s to %s", getName(ns), oldName, newName))
+			}
+		}
+		return changes
+	}
+}
+
+func renameLoggingContext(oldName, newName string) translateFunc {
+	return func(config map[string]interface{}) []string {
+		logs, _ := config["logging"].([]interface{})
+
+		var changes []string
+		for _, l := range logs {
+			logConf, ok := l.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if renameParam(logConf, oldName, newName, nil) {
+				changes = append(changes, fmt.Sprintf("Renamed /logging/%s/%s to %s", getName(logConf), oldName, newName))
+			}
+		}
+		return changes
+	}
+}
+
+// renameParam renames the param in conf unless newName is already set. It returns true if conf was changed.
+func renameParam(conf map[string]interface{}, oldName, newName string, convert func(interface{}) interface{}) bool {
+	value, ok := conf[oldName]
+	if !ok {
+		return false
+	}
+	delete(conf, oldName)
+
+	if _, ok := conf[newName]; ok {
+		return true
+	}
+	if convert != nil {
+		value = convert(value)
+	}
+	conf[newName] = value
+	return true
+}
+
+func negateBool(value interface{}) interface{} {
+	if b, ok := value.(bool); ok {
+		return !b
+	}
+	return value
+}
+
+// translateXDR5 converts the xdr datacenters and the namespace xdr params to the xdr dcs of server 5.0.
+func translateXDR5(config map[string]interface{}) []string {
+	xdrConf, ok := config["xdr"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	datacenters, ok := xdrConf["datacenters"].([]interface{})
+	if !ok {
+		// Already in the new format
+		return nil
+	}
+
+	if enabled, ok := xdrConf["enable-xdr"].(bool); ok && !enabled {
+		delete(config, "xdr")
+		return []string{"Removed /xdr, xdr is disabled"}
+	}
+
+	globalForward, hasGlobalForward := xdrConf["forward-xdr-writes"]
+
+	var dcs []interface{}
+	for _, d := range datacenters {
+		dc, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := getName(dc)
+		if name == "" {
+			name, _ = dc["dc-name"].(string)
+		}
+
+		newDC := map[string]interface{}{"name": name}
+		copyParam(dc, "dc-node-address-ports", newDC, "node-address-ports")
+		copyParam(dc, "tls-name", newDC, "tls-name")
+		copyParam(dc, "auth-mode", newDC, "auth-mode")
+		copyParam(dc, "dc-use-alternate-services", newDC, "use-alternate-access-address")
+
+		var namespaces []interface{}
+		for _, ns := range getNamespaces(config) {
+			if enabled, _ := ns["enable-xdr"].(bool); !enabled || !containsValue(ns["xdr-remote-datacenters"], name) {
+				continue
+			}
+
+			dcNamespace := map[string]interface{}{"name": getName(ns)}
+			if forward, ok := ns["ns-forward-xdr-writes"]; ok {
+				dcNamespace["forward"] = forward
+			} else if hasGlobalForward {
+				dcNamespace["forward"] = globalForward
+			}
+
+			if setsEnabled, ok := ns["sets-enable-xdr"].(bool); ok && !setsEnabled {
+				dcNamespace["ship-only-specified-sets"] = true
+				var shipSets []interface{}
+				sets, _ := ns["sets"].([]interface{})
+				for _, s := range sets {
+					if set, ok := s.(map[string]interface{}); ok && set["set-enable-xdr"] == "true" {
+						shipSets = append(shipSets, getName(set))
+					}
+				}
+				if len(shipSets) != 0 {
+					dcNamespace["ship-sets"] = shipSets
+				}
+			}
+			namespaces = append(namespaces, dcNamespace)
+		}
+		if len(namespaces) != 0 {
+			newDC["namespaces"] = namespaces
+		}
+		dcs = append(dcs, newDC)
+	}
+
+	config["xdr"] = map[string]interface{}{"dcs": dcs}
+	return []string{"Converted /xdr/datacenters and namespace xdr params to /xdr/dcs"}
+}
+
+func copyParam(from map[string]interface{}, fromName string, to map[string]interface{}, toName string) {
+	if value, ok := from[fromName]; ok {
+		to[toName] = value
+	}
+}
+
+func getNamespaces(config map[string]interface{}) []map[string]interface{} {
+	confs, _ := config["namespaces"].([]interface{})
+
+	var namespaces []map[string]interface{}
+	for _, conf := range confs {
+		if ns, ok := conf.(map[string]interface{}); ok {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+func getName(value interface{}) string {
+	if conf, ok := value.(map[string]interface{}); ok {
+		if name, ok := conf["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func containsValue(list interface{}, value string) bool {
+	values, _ := list.([]interface{})
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// copyValue deep copies maps and lists keeping the value types as is.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = copyValue(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = copyValue(val)
+		}
+		return l
+	}
+	return value
+}
@@ -0,0 +1,123 @@
+package configschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateConfigXDR5(t *testing.T) {
+	config := map[string]interface{}{
+		"service": map[string]interface{}{
+			"proto-fd-max":       int64(15000),
+			"fabric-dump-msgs":   true,
+			"hist-track-back":    int64(300),
+			"migrate-fill-delay": int64(0),
+		},
+		"namespaces": []interface{}{
+			map[string]interface{}{
+				"name":                   "test",
+				"enable-xdr":             true,
+				"xdr-remote-datacenters": []interface{}{"dc1"},
+				"ns-forward-xdr-writes":  true,
+				"allow-xdr-writes":       false,
+				"storage-engine":         "memory",
+			},
+			map[string]interface{}{
+				"name":           "bar",
+				"storage-engine": "memory",
+			},
+		},
+		"xdr": map[string]interface{}{
+			"enable-xdr":         true,
+			"xdr-digestlog-path": "/opt/aerospike/xdr/digestlog 5G",
+			"datacenters": []interface{}{
+				map[string]interface{}{
+					"name":                  "dc1",
+					"dc-node-address-ports": []interface{}{"aeroclusterdst-0-0 3000"},
+				},
+			},
+		},
+	}
+
+	want := map[string]interface{}{
+		"service": map[string]interface{}{
+			"proto-fd-max":       int64(15000),
+			"migrate-fill-delay": int64(0),
+		},
+		"namespaces": []interface{}{
+			map[string]interface{}{
+				"name":              "test",
+				"reject-xdr-writes": true,
+				"storage-engine":    "memory",
+			},
+			map[string]interface{}{
+				"name":           "bar",
+				"storage-engine": "memory",
+			},
+		},
+		"xdr": map[string]interface{}{
+			"dcs": []interface{}{
+				map[string]interface{}{
+					"name":               "dc1",
+					"node-address-ports": []interface{}{"aeroclusterdst-0-0 3000"},
+					"namespaces": []interface{}{
+						map[string]interface{}{"name": "test", "forward": true},
+					},
+				},
+			},
+		},
+	}
+
+	got, changes, err := TranslateConfig(config, "4.9.0.11", "5.2.0.7")
+	if err != nil {
+		t.Fatalf("TranslateConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TranslateConfig() = %v, want %v, changes %v", got, want, changes)
+	}
+
+	// Input is not modified
+	if _, ok := config["xdr"].(map[string]interface{})["datacenters"]; !ok {
+		t.Errorf("TranslateConfig() modified the input config")
+	}
+}
+
+func TestGetSchemaVersion(t *testing.T) {
+	versionTests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"4.9.0.11", "4.9.0", false},
+		{"4.5.4.2", "4.5.3", false},
+		{"5.2.0", "5.2.0", false},
+		{"3.15.1.4", "", true},
+	}
+
+	for _, tt := range versionTests {
+		got, err := GetSchemaVersion(tt.version)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GetSchemaVersion(%s) = (%s, %v), want %s", tt.version, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsDynamicParam(t *testing.T) {
+	paramTests := []struct {
+		context string
+		name    string
+		want    bool
+	}{
+		{"service", "proto-fd-max", true},
+		{"namespace", "default-ttl", true},
+		{"namespace", "replication-factor", false},
+		{"namespace", "unknown-param", false},
+		{"network", "port", false},
+	}
+
+	for _, tt := range paramTests {
+		if got := IsDynamicParam("5.2.0.7", tt.context, tt.name); got != tt.want {
+			t.Errorf("IsDynamicParam(%s, %s) = %v, want %v", tt.context, tt.name, got, tt.want)
+		}
+	}
+}
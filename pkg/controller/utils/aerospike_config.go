@@ -19,6 +19,9 @@ const (
 	confKeyTLS           = "tls"
 	confKeySecurity      = "security"
 
+	confKeyStrongConsistency = "strong-consistency"
+	confKeyReplicationFactor = "replication-factor"
+
 	// XDR keys.
 	confKeyXdr         = "xdr"
 	confKeyXdrDlogPath = "xdr-digestlog-path"
@@ -28,7 +31,8 @@ const (
 	confKeyWorkDirectory = "work-directory"
 
 	// Defaults.
-	defaultWorkDirectory = "/opt/aerospike"
+	defaultWorkDirectory     = "/opt/aerospike"
+	defaultReplicationFactor = 2
 )
 
 // IsTLS tells if cluster is tls enabled
@@ -64,6 +68,62 @@ func IsSecurityEnabled(aerospikeConfig aerospikev1alpha1.Values) (bool, error) {
 	return false, nil
 }
 
+// IsStrongConsistencyEnabled tells if any namespace in aerospikeConfig has strong-consistency enabled.
+func IsStrongConsistencyEnabled(aerospikeConfig aerospikev1alpha1.Values) bool {
+	return len(GetStrongConsistencyNamespaces(aerospikeConfig)) != 0
+}
+
+// GetStrongConsistencyNamespaces returns the names of the namespaces in aerospikeConfig with strong-consistency enabled.
+func GetStrongConsistencyNamespaces(aerospikeConfig aerospikev1alpha1.Values) []string {
+	var namespaces []string
+	if confs, ok := aerospikeConfig[confKeyNamespace].([]interface{}); ok {
+		for _, nsConf := range confs {
+			namespaceConf, ok := nsConf.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if enabled, ok := namespaceConf[confKeyStrongConsistency].(bool); ok && enabled {
+				if name, ok := namespaceConf["name"].(string); ok {
+					namespaces = append(namespaces, name)
+				}
+			}
+		}
+	}
+	return namespaces
+}
+
+// GetMinReplicationFactor returns the lowest replication-factor of the namespaces in aerospikeConfig.
+// Namespaces without replication-factor use the server default of 2.
+func GetMinReplicationFactor(aerospikeConfig aerospikev1alpha1.Values) int {
+	minFactor := 0
+	if confs, ok := aerospikeConfig[confKeyNamespace].([]interface{}); ok {
+		for _, nsConf := range confs {
+			namespaceConf, ok := nsConf.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			factor := defaultReplicationFactor
+			switch value := namespaceConf[confKeyReplicationFactor].(type) {
+			case float64:
+				factor = int(value)
+			case int64:
+				factor = int(value)
+			case int:
+				factor = value
+			}
+			if minFactor == 0 || factor < minFactor {
+				minFactor = factor
+			}
+		}
+	}
+	if minFactor == 0 {
+		return defaultReplicationFactor
+	}
+	return minFactor
+}
+
 // ListAerospikeNamespaces returns the list of namespaecs in the input aerospikeConfig.
 // Assumes the namespace section is validated.
 func ListAerospikeNamespaces(aerospikeConfig aerospikev1alpha1.Values) ([]string, error) {
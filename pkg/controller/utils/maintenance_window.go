@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+)
+
+// maxMaintenanceWindowMinutes is the longest window, a longer window overlaps itself.
+const maxMaintenanceWindowMinutes = 7 * 24 * 60
+
+var weekdays = map[aerospikev1alpha1.AerospikeWeekday]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+// ValidateMaintenanceWindow returns an error if the maintenance window is not valid.
+func ValidateMaintenanceWindow(window aerospikev1alpha1.AerospikeMaintenanceWindow) error {
+	_, err := getMaintenanceWindowStartTime(window, time.Now())
+	return err
+}
+
+// GetMaintenanceWindowState returns true if any of the maintenance windows is open at now.
+// Otherwise it returns the time till the next window opens. Windows are always open if none are given.
+func GetMaintenanceWindowState(windows []aerospikev1alpha1.AerospikeMaintenanceWindow, now time.Time) (bool, time.Duration, error) {
+	if len(windows) == 0 {
+		return true, 0, nil
+	}
+
+	var wait time.Duration = -1
+	for _, window := range windows {
+		dayStart, err := getMaintenanceWindowStartTime(window, now)
+		if err != nil {
+			return false, 0, err
+		}
+		duration := time.Duration(window.DurationMinutes) * time.Minute
+
+		// A window started up to a week back can still be open
+		for day := -7; day <= 7; day++ {
+			start := dayStart.AddDate(0, 0, day)
+			if !isMaintenanceWindowDay(window, start.Weekday()) {
+				continue
+			}
+			if !now.Before(start) && now.Before(start.Add(duration)) {
+				return true, 0, nil
+			}
+			if start.After(now) && (wait < 0 || start.Sub(now) < wait) {
+				wait = start.Sub(now)
+			}
+		}
+	}
+	return false, wait, nil
+}
+
+// getMaintenanceWindowStartTime returns the start time of the window on the day of now in the window time zone.
+func getMaintenanceWindowStartTime(window aerospikev1alpha1.AerospikeMaintenanceWindow, now time.Time) (time.Time, error) {
+	startTime, err := time.Parse("15:04", window.StartTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid maintenance window startTime %s, expected HH:MM", window.StartTime)
+	}
+
+	if window.DurationMinutes <= 0 || window.DurationMinutes > maxMaintenanceWindowMinutes {
+		return time.Time{}, fmt.Errorf("Invalid maintenance window durationMinutes %d, should be between 1 and %d", window.DurationMinutes, maxMaintenanceWindowMinutes)
+	}
+
+	for _, day := range window.Days {
+		if _, ok := weekdays[day]; !ok {
+			return time.Time{}, fmt.Errorf("Invalid maintenance window day %s", day)
+		}
+	}
+
+	location := time.UTC
+	if window.TimeZone != "" {
+		if location, err = time.LoadLocation(window.TimeZone); err != nil {
+			return time.Time{}, fmt.Errorf("Invalid maintenance window timeZone %s: %v", window.TimeZone, err)
+		}
+	}
+
+	localNow := now.In(location)
+	return time.Date(localNow.Year(), localNow.Month(), localNow.Day(), startTime.Hour(), startTime.Minute(), 0, 0, location), nil
+}
+
+func isMaintenanceWindowDay(window aerospikev1alpha1.AerospikeMaintenanceWindow, weekday time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, day := range window.Days {
+		if weekdays[day] == weekday {
+			return true
+		}
+	}
+	return false
+}
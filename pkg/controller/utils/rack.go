@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+)
+
+// SplitRacks returns the number of pods in each rack. The racks with a size get their size and the remaining cluster size is split
+// across the other racks in proportion to their weight. The leftover pods go to the racks with the largest fractional share,
+// the earlier racks first, so that racks without a size or weight are spread evenly.
+func SplitRacks(size int, racks []aerospikev1alpha1.Rack) []int {
+	sizes := make([]int, len(racks))
+	remaining := size
+	totalWeight := 0
+	for i, rack := range racks {
+		if rack.Size > 0 {
+			sizes[i] = int(rack.Size)
+			remaining -= sizes[i]
+			continue
+		}
+		totalWeight += getRackWeight(rack)
+	}
+	if totalWeight == 0 || remaining <= 0 {
+		return sizes
+	}
+
+	type share struct {
+		index     int
+		remainder int
+	}
+	var shares []share
+	assigned := 0
+	for i, rack := range racks {
+		if rack.Size > 0 {
+			continue
+		}
+		weight := getRackWeight(rack)
+		sizes[i] = remaining * weight / totalWeight
+		assigned += sizes[i]
+		shares = append(shares, share{index: i, remainder: remaining * weight % totalWeight})
+	}
+
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].remainder > shares[j].remainder
+	})
+	for i := 0; i < remaining-assigned; i++ {
+		sizes[shares[i].index]++
+	}
+	return sizes
+}
+
+// ValidateRackSizes checks that the rack sizes and weights agree with the cluster size and that every rack gets a pod.
+func ValidateRackSizes(size int, racks []aerospikev1alpha1.Rack) error {
+	total := 0
+	sized := 0
+	for _, rack := range racks {
+		if rack.Size < 0 || rack.Weight < 0 {
+			return fmt.Errorf("Rack %d size and weight cannot be negative", rack.ID)
+		}
+		if rack.Size > 0 && rack.Weight > 0 {
+			return fmt.Errorf("Rack %d can have either a size or a weight", rack.ID)
+		}
+		if rack.Size > 0 {
+			total += int(rack.Size)
+			sized++
+		}
+	}
+
+	if sized == len(racks) && total != size {
+		return fmt.Errorf("Sum of rack sizes %d does not match cluster size %d", total, size)
+	}
+	if total > size {
+		return fmt.Errorf("Sum of rack sizes %d is more than cluster size %d", total, size)
+	}
+
+	for i, rackSize := range SplitRacks(size, racks) {
+		if rackSize == 0 {
+			return fmt.Errorf("Rack %d gets no pods of cluster size %d, increase the cluster size or the rack weight", racks[i].ID, size)
+		}
+	}
+	return nil
+}
+
+func getRackWeight(rack aerospikev1alpha1.Rack) int {
+	if rack.Weight > 0 {
+		return int(rack.Weight)
+	}
+	return 1
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	"github.com/aerospike/aerospike-management-lib/asconfig"
+	"github.com/aerospike/aerospike-management-lib/deployment"
 	log "github.com/inconshreveable/log15"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
@@ -17,6 +19,9 @@ import (
 
 var pkglog = log.New(log.Ctx{"module": "utils"})
 
+// versionRegex matches the leading numeric part of an Aerospike version e.g. 5.2.0.7. At least the x.y.z components are required.
+var versionRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(\.[0-9]+)*`)
+
 const (
 	// DefaultRackID is the ID for the default rack created when no racks are specified.
 	DefaultRackID = 0
@@ -70,12 +75,26 @@ func NamespacedName(namespace, name string) string {
 	return fmt.Sprintf("%s/%s", namespace, name)
 }
 
-// IsPodRunningAndReady returns true if pod is in the PodRunning Phase, if it has a condition of PodReady.
-// TODO: check if its is properly running, error crashLoop also passed in this
-func IsPodRunningAndReady(pod *v1.Pod) bool {
+// IsPodRunning returns true if pod is not terminating and is in the PodRunning Phase. The pod may not be ready.
+func IsPodRunning(pod *v1.Pod) bool {
 	return !IsTerminating(pod) && pod.Status.Phase == v1.PodRunning
 }
 
+// IsPodRunningAndReady returns true if pod is not terminating, is in the PodRunning Phase and has a true PodReady condition.
+// The readiness probe fails while the node is not a member of an integral cluster, so use IsPodRunning to check if the node
+// may be serving, e.g. before stopping it.
+func IsPodRunningAndReady(pod *v1.Pod) bool {
+	if !IsPodRunning(pod) {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // CheckPodFailed checks if pod has failed or has terminated or is in an irrecoverable waiting state.
 func CheckPodFailed(pod *v1.Pod) error {
 	if IsTerminating(pod) {
@@ -152,14 +171,19 @@ func IsCrashed(pod *v1.Pod) bool {
 
 // IsImageEqual returns true if image name image1 is equal to image name image2.
 func IsImageEqual(image1 string, image2 string) bool {
-	desiredImageWithVersion := strings.TrimPrefix(image1, DockerHubImagePrefix)
-	actualImageWithVersion := strings.TrimPrefix(image2, DockerHubImagePrefix)
+	desired := ParseImageReference(image1)
+	actual := ParseImageReference(image2)
 
-	desiredRegistry, desiredName, desiredVersion := ParseDockerImageTag(desiredImageWithVersion)
-	actualRegistry, actualName, actualVersion := ParseDockerImageTag(actualImageWithVersion)
+	// Digest pins the image content, compare digests if both have it.
+	if desired.Digest != "" && actual.Digest != "" {
+		return desired.Digest == actual.Digest
+	}
+
+	desiredVersion := desired.Tag
+	actualVersion := actual.Tag
 
 	// registry name, image name and version should match.
-	return desiredRegistry == actualRegistry && desiredName == actualName && (desiredVersion == actualVersion || (desiredVersion == ":latest" && actualVersion == "") || (actualVersion == ":latest" && desiredVersion == ""))
+	return desired.Registry == actual.Registry && desired.Repository == actual.Repository && (desiredVersion == actualVersion || (desiredVersion == "latest" && actualVersion == "") || (actualVersion == "latest" && desiredVersion == ""))
 }
 
 // GetImageVersion extracts image version from image string/tag.
@@ -173,14 +197,162 @@ func GetImageVersion(tag string) (string, error) {
 	return version, nil
 }
 
+// GetAerospikeVersion returns the Aerospike server version for the cluster spec.
+// The version field takes precedence, else the version is taken from the leading numeric part of the image tag
+// e.g. 5.2.0.7 for aerospike/aerospike-server-enterprise:5.2.0.7 and 7.0.1 for myrepo/aerospike:7.0.1-hardened.
+// The tag has to start with at least x.y.z numeric components, a tag like 7.0-hardened needs the version field.
+func GetAerospikeVersion(spec *aerospikev1alpha1.AerospikeClusterSpec) (string, error) {
+	if spec.Version != "" {
+		if versionRegex.FindString(spec.Version) != spec.Version {
+			return "", fmt.Errorf("Invalid version %s, expected at least x.y.z numeric components e.g. 5.2.0.7", spec.Version)
+		}
+		return spec.Version, nil
+	}
+
+	ref := ParseImageReference(spec.Image)
+	version := versionRegex.FindString(ref.Tag)
+	if version == "" {
+		return "", fmt.Errorf("Cannot get Aerospike version from image %s, the tag does not start with an x.y.z version, version is mandatory for such images", spec.Image)
+	}
+
+	return version, nil
+}
+
+// IsVersionMatch indicates if the version of a running server matches the desired version.
+// The desired version can be partial e.g. 5.2 matches 5.2.0.7.
+func IsVersionMatch(desired, running string) bool {
+	return strings.HasPrefix(running+".", desired+".")
+}
+
+// upgradeJumpVersions are the versions an upgrade or downgrade cannot skip.
+// Same as the jump versions checked by deployment.IsValidUpgrade, in ascending order.
+var upgradeJumpVersions = []string{"3.13", "4.2", "4.3", "4.9"}
+
+// GetUpgradeHops returns the intermediate versions, in order, a version change from fromVersion to toVersion has to go through.
+func GetUpgradeHops(fromVersion, toVersion string) ([]string, error) {
+	isUpgrade, err := deployment.IsUpgrade(fromVersion, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var hops []string
+	for _, jumpVersion := range upgradeJumpVersions {
+		r1, err := asconfig.CompareVersionsIgnoreRevision(fromVersion, jumpVersion)
+		if err != nil {
+			return nil, err
+		}
+		r2, err := asconfig.CompareVersionsIgnoreRevision(toVersion, jumpVersion)
+		if err != nil {
+			return nil, err
+		}
+		if (r1 < 0 && r2 > 0) || (r2 < 0 && r1 > 0) {
+			hops = append(hops, jumpVersion)
+		}
+	}
+
+	if !isUpgrade {
+		// Downgrade goes through the jump versions in descending order
+		for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+			hops[i], hops[j] = hops[j], hops[i]
+		}
+	}
+	return hops, nil
+}
+
+// GetHopImage returns the image for the intermediate version hop and its version.
+// An image for the hop listed in hopImages is used with the version from its tag. Otherwise the image is the repository
+// of image tagged with the hop version e.g. aerospike/aerospike-server-enterprise:4.9 for the 4.9 hop, its version is 4.9.0.
+func GetHopImage(image string, hopImages []string, hop string) (string, string) {
+	for _, hopImage := range hopImages {
+		version := versionRegex.FindString(ParseImageReference(hopImage).Tag)
+		if version != "" && IsVersionMatch(hop, version) {
+			return hopImage, version
+		}
+	}
+
+	ref := ParseImageReference(image)
+	repository := ref.Repository
+	if ref.Registry != "" {
+		repository = ref.Registry + "/" + repository
+	}
+	version := hop
+	for strings.Count(version, ".") < 2 {
+		version += ".0"
+	}
+	return repository + ":" + hop, version
+}
+
+// GetAerospikeEdition returns the Aerospike server edition for the cluster spec.
+// The edition field takes precedence, else the edition is detected from the image name e.g. aerospike/aerospike-server-enterprise.
+func GetAerospikeEdition(spec *aerospikev1alpha1.AerospikeClusterSpec) aerospikev1alpha1.AerospikeEdition {
+	if spec.Edition != aerospikev1alpha1.AerospikeEditionUnspecified {
+		return spec.Edition
+	}
+
+	name := ParseImageReference(spec.Image).Repository
+	// Only the last path component is the image name, the rest is repository.
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	for _, token := range strings.Split(strings.ToLower(name), "-") {
+		if token == string(aerospikev1alpha1.AerospikeEditionEnterprise) {
+			return aerospikev1alpha1.AerospikeEditionEnterprise
+		}
+	}
+	return aerospikev1alpha1.AerospikeEditionCommunity
+}
+
+// IsEnterprise indicates if the cluster spec is for Aerospike Enterprise Edition.
+func IsEnterprise(spec *aerospikev1alpha1.AerospikeClusterSpec) bool {
+	return GetAerospikeEdition(spec) == aerospikev1alpha1.AerospikeEditionEnterprise
+}
+
+// ImageReference is a parsed container image reference of the form [registry/]repository[:tag][@digest].
+type ImageReference struct {
+	// Registry host with optional port. Empty for the default docker hub registry.
+	Registry string
+	// Repository path of the image.
+	Repository string
+	// Tag of the image if any.
+	Tag string
+	// Digest of the image if any, e.g. sha256:...
+	Digest string
+}
+
+// ParseImageReference parses an image reference. Handles registry ports and digests,
+// e.g. registry.example.com:5000/aerospike/aerospike-server-enterprise:5.2.0.7@sha256:...
+func ParseImageReference(image string) ImageReference {
+	ref := ImageReference{}
+
+	image = strings.TrimPrefix(image, DockerHubImagePrefix)
+
+	if idx := strings.Index(image, "@"); idx >= 0 {
+		ref.Digest = image[idx+1:]
+		image = image[:idx]
+	}
+
+	// Tag separator is the last colon after the last slash, a colon before that is a registry port.
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		ref.Tag = image[idx+1:]
+		image = image[:idx]
+	}
+
+	// First component is a registry only if it looks like a host.
+	if idx := strings.Index(image, "/"); idx >= 0 {
+		host := image[:idx]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			image = image[idx+1:]
+		}
+	}
+
+	ref.Repository = image
+	return ref
+}
+
 // ParseDockerImageTag parses input tag into registry, name and version.
 func ParseDockerImageTag(tag string) (registry string, name string, version string) {
-	if tag == "" {
-		return "", "", ""
-	}
-	r := regexp.MustCompile(`(?P<registry>[^/]+/)?(?P<image>[^:]+)(?P<version>:.+)?`)
-	matches := r.FindStringSubmatch(tag)
-	return matches[1], matches[2], strings.TrimPrefix(matches[3], ":")
+	ref := ParseImageReference(tag)
+	return ref.Registry, ref.Repository, ref.Tag
 }
 
 // isImageError indicates whether the specified reason corresponds to an error while pulling or inspecting a container
@@ -214,10 +386,10 @@ func IsPVCTerminating(pvc *corev1.PersistentVolumeClaim) bool {
 	return pvc.DeletionTimestamp != nil
 }
 
-// GetDesiredImage returns the desired image for the input containerName from the aeroCluster spec.
-func GetDesiredImage(aeroCluster *aerospikev1alpha1.AerospikeCluster, containerName string) (string, error) {
+// GetDesiredImage returns the desired image for the input containerName of the rack pods from the aeroCluster spec.
+func GetDesiredImage(aeroCluster *aerospikev1alpha1.AerospikeCluster, rackID int, containerName string) (string, error) {
 	if containerName == "aerospike-server" {
-		return aeroCluster.Spec.Image, nil
+		return GetRackImage(aeroCluster, rackID), nil
 	}
 
 	for _, sidecar := range aeroCluster.Spec.PodSpec.Sidecars {
@@ -229,6 +401,16 @@ func GetDesiredImage(aeroCluster *aerospikev1alpha1.AerospikeCluster, containerN
 	return "", fmt.Errorf("Container %s not found", containerName)
 }
 
+// GetRackImage returns the Aerospike server image of the rack pods, the rack image if given else the cluster image.
+func GetRackImage(aeroCluster *aerospikev1alpha1.AerospikeCluster, rackID int) string {
+	for _, rack := range aeroCluster.Spec.RackConfig.Racks {
+		if rack.ID == rackID && rack.PodSpec.Image != "" {
+			return rack.PodSpec.Image
+		}
+	}
+	return aeroCluster.Spec.Image
+}
+
 // IsPodUpgraded assume that all container have same image or take containerID
 func IsPodUpgraded(pod *corev1.Pod, aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
 	pkglog.Info("Checking pod image")
@@ -254,8 +436,9 @@ func getPodContainerStatus(pod *corev1.Pod, containerName string) *corev1.Contai
 
 // IsPodOnDesiredImage indicates of pod is ready and on desired images for all containers.
 func IsPodOnDesiredImage(pod *corev1.Pod, aeroCluster *aerospikev1alpha1.AerospikeCluster) bool {
+	rackID, _ := strconv.Atoi(pod.Labels[RackIDLabel])
 	for _, ps := range pod.Spec.Containers {
-		desiredImage, err := GetDesiredImage(aeroCluster, ps.Name)
+		desiredImage, err := GetDesiredImage(aeroCluster, rackID, ps.Name)
 		if err != nil {
 			// Maybe a deleted sidecar. Ignore.
 			desiredImage = ps.Image
@@ -311,16 +494,28 @@ func GetPod(podName string, pods []corev1.Pod) *corev1.Pod {
 	return nil
 }
 
+// ClusterNameLabel is the label with the AerospikeCluster CR name on the resources belonging to the cluster.
+const ClusterNameLabel = "aerospike.com/cr"
+
+// RackIDLabel is the label with the rack ID on the rack StatefulSets and pods.
+const RackIDLabel = "aerospike.com/rack-id"
+
+// DefaultCanarySoakSeconds is the default time to wait after the canary is upgraded before checking the health gates.
+const DefaultCanarySoakSeconds = 300
+
+// DefaultPreStopDrainSeconds is the default time the preStop hook waits for the client traffic to drain after the quiesce.
+const DefaultPreStopDrainSeconds = 15
+
 // LabelsForAerospikeCluster returns the labels for selecting the resources
 // belonging to the given AerospikeCluster CR name.
 func LabelsForAerospikeCluster(clName string) map[string]string {
-	return map[string]string{"app": "aerospike-cluster", "aerospike.com/cr": clName}
+	return map[string]string{"app": "aerospike-cluster", ClusterNameLabel: clName}
 }
 
 // LabelsForAerospikeClusterRack returns the labels for specific rack
 func LabelsForAerospikeClusterRack(clName string, rackID int) map[string]string {
 	labels := LabelsForAerospikeCluster(clName)
-	labels["aerospike.com/rack-id"] = strconv.Itoa(rackID)
+	labels[RackIDLabel] = strconv.Itoa(rackID)
 	return labels
 }
 
@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	aerospikev1alpha1 "github.com/aerospike/aerospike-kubernetes-operator/pkg/apis/aerospike/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetAerospikeEdition(t *testing.T) {
+	editionTests := []struct {
+		image   string
+		edition aerospikev1alpha1.AerospikeEdition
+		want    aerospikev1alpha1.AerospikeEdition
+	}{
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", "", aerospikev1alpha1.AerospikeEditionEnterprise},
+		{"aerospike/aerospike-server:5.2.0.7", "", aerospikev1alpha1.AerospikeEditionCommunity},
+		{"aerospike/aerospike-server:enterprise-5.2.0.7", "", aerospikev1alpha1.AerospikeEditionCommunity},
+		{"myrepo/aerospike-server-enterprise-hardened:5.2.0.7", "", aerospikev1alpha1.AerospikeEditionEnterprise},
+		{"myrepo/aerospike:5.2.0.7", aerospikev1alpha1.AerospikeEditionEnterprise, aerospikev1alpha1.AerospikeEditionEnterprise},
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", aerospikev1alpha1.AerospikeEditionCommunity, aerospikev1alpha1.AerospikeEditionCommunity},
+	}
+
+	for _, tt := range editionTests {
+		spec := &aerospikev1alpha1.AerospikeClusterSpec{Image: tt.image, Edition: tt.edition}
+		if got := GetAerospikeEdition(spec); got != tt.want {
+			t.Errorf("GetAerospikeEdition(image: %s, edition: %s) = %s, want %s", tt.image, tt.edition, got, tt.want)
+		}
+	}
+}
+
+func TestParseImageReference(t *testing.T) {
+	imageTests := []struct {
+		image string
+		want  ImageReference
+	}{
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", ImageReference{"", "aerospike/aerospike-server-enterprise", "5.2.0.7", ""}},
+		{"docker.io/aerospike/aerospike-server:5.2.0.7", ImageReference{"", "aerospike/aerospike-server", "5.2.0.7", ""}},
+		{"registry.example.com:5000/aerospike/aerospike-server-enterprise:7.0-hardened", ImageReference{"registry.example.com:5000", "aerospike/aerospike-server-enterprise", "7.0-hardened", ""}},
+		{"localhost:5000/aerospike-server", ImageReference{"localhost:5000", "aerospike-server", "", ""}},
+		{"aerospike/aerospike-server-enterprise@sha256:abcd", ImageReference{"", "aerospike/aerospike-server-enterprise", "", "sha256:abcd"}},
+		{"registry.example.com:5000/aerospike:5.2.0.7@sha256:abcd", ImageReference{"registry.example.com:5000", "aerospike", "5.2.0.7", "sha256:abcd"}},
+	}
+
+	for _, tt := range imageTests {
+		if got := ParseImageReference(tt.image); got != tt.want {
+			t.Errorf("ParseImageReference(%s) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestGetAerospikeVersion(t *testing.T) {
+	versionTests := []struct {
+		image   string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", "", "5.2.0.7", false},
+		{"registry.example.com:5000/aerospike-server-enterprise:7.0.1-hardened", "", "7.0.1", false},
+		{"registry.example.com:5000/aerospike-server-enterprise:7.0-hardened", "", "", true},
+		{"aerospike/aerospike-server-enterprise:5", "", "", true},
+		{"aerospike/aerospike-server-enterprise:hardened", "5.2.0", "5.2.0", false},
+		{"aerospike/aerospike-server-enterprise:hardened", "5.2", "", true},
+		{"aerospike/aerospike-server-enterprise@sha256:abcd", "5.2.0.7", "5.2.0.7", false},
+		{"aerospike/aerospike-server-enterprise@sha256:abcd", "", "", true},
+		{"aerospike/aerospike-server-enterprise:latest", "", "", true},
+		{"aerospike/aerospike-server-enterprise:hardened", "5.2.x", "", true},
+	}
+
+	for _, tt := range versionTests {
+		spec := &aerospikev1alpha1.AerospikeClusterSpec{Image: tt.image, Version: tt.version}
+		got, err := GetAerospikeVersion(spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GetAerospikeVersion(image: %s, version: %s) = (%s, %v), want %s", tt.image, tt.version, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetUpgradeHops(t *testing.T) {
+	hopTests := []struct {
+		from string
+		to   string
+		want []string
+	}{
+		{"4.8.0.5", "5.2.0.7", []string{"4.9"}},
+		{"4.1.0.1", "5.0.0.3", []string{"4.2", "4.3", "4.9"}},
+		{"5.0.0.3", "4.1.0.1", []string{"4.9", "4.3", "4.2"}},
+		{"4.9.0.11", "5.2.0.7", nil},
+		{"5.1.0.1", "5.2.0.7", nil},
+	}
+
+	for _, tt := range hopTests {
+		got, err := GetUpgradeHops(tt.from, tt.to)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetUpgradeHops(%s, %s) = (%v, %v), want %v", tt.from, tt.to, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetHopImage(t *testing.T) {
+	hopTests := []struct {
+		image       string
+		hopImages   []string
+		hop         string
+		wantImage   string
+		wantVersion string
+	}{
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", nil, "4.9", "aerospike/aerospike-server-enterprise:4.9", "4.9.0"},
+		{"docker.io/aerospike/aerospike-server-enterprise:5.2.0.7", nil, "3.13", "aerospike/aerospike-server-enterprise:3.13", "3.13.0"},
+		{"registry.local:5000/aerospike/server:5.2.0.7@sha256:abc", nil, "4.9", "registry.local:5000/aerospike/server:4.9", "4.9.0"},
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", []string{"myrepo/aerospike:4.3.1.14", "myrepo/aerospike:4.9.0.11"}, "4.9",
+			"myrepo/aerospike:4.9.0.11", "4.9.0.11"},
+		{"aerospike/aerospike-server-enterprise:5.2.0.7", []string{"myrepo/aerospike:4.3.1.14"}, "4.9", "aerospike/aerospike-server-enterprise:4.9", "4.9.0"},
+	}
+
+	for _, tt := range hopTests {
+		image, version := GetHopImage(tt.image, tt.hopImages, tt.hop)
+		if image != tt.wantImage || version != tt.wantVersion {
+			t.Errorf("GetHopImage(%s, %v, %s) = (%s, %s), want (%s, %s)", tt.image, tt.hopImages, tt.hop, image, version, tt.wantImage, tt.wantVersion)
+		}
+	}
+}
+
+func TestGetMaintenanceWindowState(t *testing.T) {
+	// Saturday
+	now := time.Date(2021, 1, 2, 23, 0, 0, 0, time.UTC)
+
+	windowTests := []struct {
+		window   *aerospikev1alpha1.AerospikeMaintenanceWindow
+		wantOpen bool
+		wantWait time.Duration
+	}{
+		{nil, true, 0},
+		{&aerospikev1alpha1.AerospikeMaintenanceWindow{Days: []aerospikev1alpha1.AerospikeWeekday{"Sat"}, StartTime: "22:00", DurationMinutes: 120}, true, 0},
+		{&aerospikev1alpha1.AerospikeMaintenanceWindow{Days: []aerospikev1alpha1.AerospikeWeekday{"Sun"}, StartTime: "01:00", DurationMinutes: 60}, false, 2 * time.Hour},
+		{&aerospikev1alpha1.AerospikeMaintenanceWindow{Days: []aerospikev1alpha1.AerospikeWeekday{"Fri"}, StartTime: "23:30", DurationMinutes: 25 * 60}, true, 0},
+		{&aerospikev1alpha1.AerospikeMaintenanceWindow{StartTime: "00:00", DurationMinutes: 60}, false, time.Hour},
+		{&aerospikev1alpha1.AerospikeMaintenanceWindow{Days: []aerospikev1alpha1.AerospikeWeekday{"Mon"}, StartTime: "22:00", DurationMinutes: 60, TimeZone: "America/New_York"}, false, 52 * time.Hour},
+	}
+
+	for _, tt := range windowTests {
+		var windows []aerospikev1alpha1.AerospikeMaintenanceWindow
+		if tt.window != nil {
+			windows = append(windows, *tt.window)
+		}
+		open, wait, err := GetMaintenanceWindowState(windows, now)
+		if err != nil || open != tt.wantOpen || (!open && wait != tt.wantWait) {
+			t.Errorf("GetMaintenanceWindowState(%v) = (%v, %v, %v), want (%v, %v)", tt.window, open, wait, err, tt.wantOpen, tt.wantWait)
+		}
+	}
+
+	invalidWindows := []aerospikev1alpha1.AerospikeMaintenanceWindow{
+		{StartTime: "25:00", DurationMinutes: 60},
+		{StartTime: "22:00"},
+		{StartTime: "22:00", DurationMinutes: 60, Days: []aerospikev1alpha1.AerospikeWeekday{"Funday"}},
+		{StartTime: "22:00", DurationMinutes: 60, TimeZone: "Mars/Olympus_Mons"},
+	}
+	for _, window := range invalidWindows {
+		if err := ValidateMaintenanceWindow(window); err == nil {
+			t.Errorf("ValidateMaintenanceWindow(%v) expected error", window)
+		}
+	}
+}
+
+func TestSplitRacks(t *testing.T) {
+	splitTests := []struct {
+		size  int
+		racks []aerospikev1alpha1.Rack
+		want  []int
+	}{
+		{5, []aerospikev1alpha1.Rack{{ID: 1}, {ID: 2}, {ID: 3}}, []int{2, 2, 1}},
+		{6, []aerospikev1alpha1.Rack{{ID: 1, Size: 4}, {ID: 2}, {ID: 3}}, []int{4, 1, 1}},
+		{8, []aerospikev1alpha1.Rack{{ID: 1, Weight: 3}, {ID: 2}}, []int{6, 2}},
+		{7, []aerospikev1alpha1.Rack{{ID: 1, Weight: 2}, {ID: 2, Weight: 2}, {ID: 3, Weight: 3}}, []int{2, 2, 3}},
+		{10, []aerospikev1alpha1.Rack{{ID: 1, Size: 3}, {ID: 2, Weight: 1}, {ID: 3, Weight: 2}}, []int{3, 2, 5}},
+	}
+
+	for _, tt := range splitTests {
+		if got := SplitRacks(tt.size, tt.racks); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitRacks(%d, %v) = %v, want %v", tt.size, tt.racks, got, tt.want)
+		}
+		if err := ValidateRackSizes(tt.size, tt.racks); err != nil {
+			t.Errorf("ValidateRackSizes(%d, %v) unexpected error: %v", tt.size, tt.racks, err)
+		}
+	}
+
+	invalidTests := []struct {
+		size  int
+		racks []aerospikev1alpha1.Rack
+	}{
+		{4, []aerospikev1alpha1.Rack{{ID: 1, Size: 2}, {ID: 2, Size: 3}}},
+		{4, []aerospikev1alpha1.Rack{{ID: 1, Size: 5}, {ID: 2}}},
+		{4, []aerospikev1alpha1.Rack{{ID: 1, Size: 2, Weight: 1}, {ID: 2}}},
+		{3, []aerospikev1alpha1.Rack{{ID: 1, Weight: 5}, {ID: 2}}},
+	}
+	for _, tt := range invalidTests {
+		if err := ValidateRackSizes(tt.size, tt.racks); err == nil {
+			t.Errorf("ValidateRackSizes(%d, %v) expected error", tt.size, tt.racks)
+		}
+	}
+}
+
+func TestIsPodRunningAndReady(t *testing.T) {
+	now := metav1.Now()
+	readyTests := []struct {
+		name        string
+		phase       corev1.PodPhase
+		conditions  []corev1.PodCondition
+		deletion    *metav1.Time
+		wantRunning bool
+		wantReady   bool
+	}{
+		{"ready", corev1.PodRunning, []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}, nil, true, true},
+		{"not ready", corev1.PodRunning, []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}, nil, true, false},
+		{"no ready condition", corev1.PodRunning, []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}}, nil, true, false},
+		{"pending", corev1.PodPending, nil, nil, false, false},
+		{"terminating", corev1.PodRunning, []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}, &now, false, false},
+	}
+
+	for _, tt := range readyTests {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tt.deletion},
+			Status:     corev1.PodStatus{Phase: tt.phase, Conditions: tt.conditions},
+		}
+		if got := IsPodRunning(pod); got != tt.wantRunning {
+			t.Errorf("IsPodRunning(%s) = %v, want %v", tt.name, got, tt.wantRunning)
+		}
+		if got := IsPodRunningAndReady(pod); got != tt.wantReady {
+			t.Errorf("IsPodRunningAndReady(%s) = %v, want %v", tt.name, got, tt.wantReady)
+		}
+	}
+}
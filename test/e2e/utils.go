@@ -277,7 +277,7 @@ func waitForAerospikeCluster(t *testing.T, f *framework.Framework, aeroCluster *
 					t.Logf("Cluster pod's nodeID is empty")
 					return false, nil
 				}
-				specImage := strings.Split(aeroCluster.Spec.Image, ":")[1]
+				specImage := aeroCluster.Spec.Image
 				if pod.Image != specImage {
 					t.Logf("Cluster pod's image %s not same as spec %s", pod.Image, specImage)
 				}